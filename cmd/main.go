@@ -2,10 +2,12 @@ package main
 
 import (
 	"context"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"path"
+	"strconv"
 	"time"
 
 	"github.com/layer5io/meshery/helpers"
@@ -14,6 +16,7 @@ import (
 	"github.com/layer5io/meshery/handlers"
 	"github.com/layer5io/meshery/models"
 	"github.com/layer5io/meshery/router"
+	"github.com/layer5io/meshery/workers"
 	"github.com/spf13/viper"
 
 	"github.com/sirupsen/logrus"
@@ -22,6 +25,9 @@ import (
 	"github.com/vmihailenco/taskq/memqueue"
 )
 
+// queryTrackerTTL is how long the queries of a load test are tracked when its metrics are not collected
+const queryTrackerTTL = 24 * time.Hour
+
 func main() {
 	ctx := context.Background()
 
@@ -29,6 +35,18 @@ func main() {
 
 	viper.SetDefault("PORT", 8080)
 	viper.SetDefault("ADAPTER_URLS", "")
+	viper.SetDefault("SAAS_RESULTS_SYNC", true)
+	viper.SetDefault("WORKER_MODE", false)
+	viper.SetDefault("WORKER_PORT", 9091)
+	// without a token or mutual TLS the workers only accept jobs from the same host
+	viper.SetDefault("WORKER_BIND_ADDRESS", "127.0.0.1")
+	viper.SetDefault("LOAD_TEST_WORKERS", "")
+	// load tests running concurrently skew each other's results, so by default they run one at a time
+	viper.SetDefault("LOAD_TEST_MAX_CONCURRENCY", 1)
+	viper.SetDefault("LOAD_TEST_MAX_PER_TARGET", 1)
+	viper.SetDefault("QUERY_TRACKER_MAX_QUERIES", 500)
+	viper.SetDefault("QUERY_CACHE_MAX_ENTRIES", 1000)
+	viper.SetDefault("QUERY_CACHE_MAX_BYTES", 64<<20)
 
 	home, err := os.UserHomeDir()
 	if viper.GetString("USER_DATA_FOLDER") == "" {
@@ -52,6 +70,30 @@ func main() {
 	}
 	logrus.Infof("Log level: %s", logrus.GetLevel())
 
+	workerSecurity := &models.LoadTestWorkerSecurity{
+		Token:    viper.GetString("WORKER_TOKEN"),
+		CertFile: viper.GetString("WORKER_TLS_CERT"),
+		KeyFile:  viper.GetString("WORKER_TLS_KEY"),
+		CAFile:   viper.GetString("WORKER_TLS_CA"),
+	}
+
+	// in worker mode Meshery only generates the load it is asked to by another Meshery instance
+	if viper.GetBool("WORKER_MODE") {
+		workerCtx, cancel := context.WithCancel(ctx)
+		c := make(chan os.Signal, 1)
+		signal.Notify(c, os.Interrupt)
+		go func() {
+			<-c
+			logrus.Info("Shutting down the load generator worker")
+			cancel()
+		}()
+		address := net.JoinHostPort(viper.GetString("WORKER_BIND_ADDRESS"), strconv.Itoa(viper.GetInt("WORKER_PORT")))
+		if err := workers.ListenAndServe(workerCtx, address, workerSecurity); err != nil {
+			logrus.Fatal(err)
+		}
+		return
+	}
+
 	saasBaseURL := viper.GetString("SAAS_BASE_URL")
 	if saasBaseURL == "" {
 		logrus.Fatalf("SAAS_BASE_URL environment variable not set.")
@@ -60,7 +102,9 @@ func main() {
 	adapterURLs := viper.GetStringSlice("ADAPTER_URLS")
 
 	adapterTracker := helpers.NewAdaptersTracker(adapterURLs)
-	queryTracker := helpers.NewUUIDQueryTracker()
+	loadTestTracker := helpers.NewLoadTestTracker()
+	loadTestQueue := helpers.NewLoadTestQueue(viper.GetInt("LOAD_TEST_MAX_CONCURRENCY"), viper.GetInt("LOAD_TEST_MAX_PER_TARGET"))
+	queryCache := helpers.NewQueryCache(viper.GetInt("QUERY_CACHE_MAX_ENTRIES"), viper.GetInt64("QUERY_CACHE_MAX_BYTES"))
 
 	// Uncomment line below to generate a new UID and force the user to login every time Meshery is started.
 	// fileSessionStore := sessions.NewFilesystemStore("", []byte(uuid.NewV4().Bytes()))
@@ -73,6 +117,12 @@ func main() {
 	mainQueue := queueFactory.NewQueue(&taskq.QueueOptions{
 		Name: "loadTestReporterQueue",
 	})
+	metricsQueue := queueFactory.NewQueue(&taskq.QueueOptions{
+		Name: "metricsTasksQueue",
+		// the metrics tasks keep failing while prometheus is unreachable, they are retried with a backoff rather
+		// than pausing the queue
+		PauseErrorsThreshold: -1,
+	})
 
 	// sessionPersister := helpers.NewFileSessionPersister(viper.GetString("USER_DATA_FOLDER"))
 	// sessionPersister, err := helpers.NewBadgerSessionPersister(viper.GetString("USER_DATA_FOLDER"))
@@ -88,6 +138,31 @@ func main() {
 	// sessionPersister, _ := helpers.NewMapSessionPersister()
 	defer sessionPersister.Close()
 
+	resultPersister, err := helpers.NewBitCaskResultsPersister(viper.GetString("USER_DATA_FOLDER"))
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	defer resultPersister.Close()
+
+	schedulePersister, err := helpers.NewBitCaskSchedulesPersister(viper.GetString("USER_DATA_FOLDER"))
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	defer schedulePersister.Close()
+
+	metricsTaskPersister, err := helpers.NewBitCaskMetricsTasksPersister(viper.GetString("USER_DATA_FOLDER"))
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	defer metricsTaskPersister.Close()
+
+	queryTracker, err := helpers.NewBitCaskQueryTracker(viper.GetString("USER_DATA_FOLDER"), queryTrackerTTL, viper.GetInt("QUERY_TRACKER_MAX_QUERIES"))
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	defer queryTracker.Close()
+	go queryTracker.RemoveStaleUUIDs(ctx)
+
 	h := handlers.NewHandlerInstance(&models.HandlerConfig{
 		SaaSBaseURL: saasBaseURL,
 
@@ -99,13 +174,27 @@ func main() {
 
 		SaaSTokenName: "meshery_saas",
 
-		AdapterTracker: adapterTracker,
-		QueryTracker:   queryTracker,
+		AdapterTracker:  adapterTracker,
+		QueryTracker:    queryTracker,
+		LoadTestTracker: loadTestTracker,
+		LoadTestQueue:   loadTestQueue,
+		QueryCache:      queryCache,
 
-		Queue: mainQueue,
+		Queue:        mainQueue,
+		MetricsQueue: metricsQueue,
 
 		SessionPersister: sessionPersister,
 
+		ResultPersister:     resultPersister,
+		SyncResultsWithSaaS: viper.GetBool("SAAS_RESULTS_SYNC"),
+
+		SchedulePersister: schedulePersister,
+
+		MetricsTaskPersister: metricsTaskPersister,
+
+		LoadTestWorkers:        viper.GetStringSlice("LOAD_TEST_WORKERS"),
+		LoadTestWorkerSecurity: workerSecurity,
+
 		KubeConfigFolder: viper.GetString("KUBECONFIG_FOLDER"),
 
 		GrafanaClient:         models.NewGrafanaClient(),
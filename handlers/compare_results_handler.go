@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gofrs/uuid"
+	"github.com/gorilla/sessions"
+	"github.com/layer5io/meshery/helpers"
+	"github.com/layer5io/meshery/models"
+	"github.com/sirupsen/logrus"
+)
+
+// maxComparedResults is the maximum number of results which can be compared at once
+const maxComparedResults = 10
+
+// CompareResultsHandler compares the results with the given ids, the first one being the baseline the
+// other ones are compared with
+func (h *Handler) CompareResultsHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, user *models.User) {
+	if req.Method != http.MethodGet {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if h.config.ResultPersister == nil {
+		http.Error(w, "comparing results needs the local result store", http.StatusNotImplemented)
+		return
+	}
+
+	ids := req.URL.Query()["id"]
+	if len(ids) < 2 || len(ids) > maxComparedResults {
+		http.Error(w, fmt.Sprintf("provide between 2 and %d result ids to compare", maxComparedResults), http.StatusBadRequest)
+		return
+	}
+	results := make([]*models.MesheryResult, 0, len(ids))
+	for _, id := range ids {
+		resultUUID, err := uuid.FromString(id)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid result id: %s", id), http.StatusBadRequest)
+			return
+		}
+		result, err := h.config.ResultPersister.GetResult(resultUUID)
+		// the results of the other users are reported as missing
+		if err != nil || result.UserID != user.UserID {
+			http.Error(w, fmt.Sprintf("result not found: %s", id), http.StatusNotFound)
+			return
+		}
+		results = append(results, result)
+	}
+
+	if err := json.NewEncoder(w).Encode(helpers.CompareResults(results)); err != nil {
+		logrus.Errorf("error marshalling the comparison: %v", err)
+		http.Error(w, "unable to marshal the comparison", http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"github.com/gofrs/uuid"
+	"github.com/gorilla/sessions"
+	"github.com/layer5io/meshery/helpers"
+	"github.com/layer5io/meshery/models"
+	"github.com/sirupsen/logrus"
+)
+
+// unsafeFileNameChars matches the characters replaced in the name of the exported file
+var unsafeFileNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// ExportResultHandler exports the result with the given id as csv, junit or html, given as format
+func (h *Handler) ExportResultHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, user *models.User) {
+	if req.Method != http.MethodGet {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if h.config.ResultPersister == nil {
+		http.Error(w, "exporting results needs the local result store", http.StatusNotImplemented)
+		return
+	}
+
+	q := req.URL.Query()
+	format, err := models.ParseResultExportFormat(q.Get("format"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	resultUUID, err := uuid.FromString(q.Get("id"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid result id: %s", q.Get("id")), http.StatusBadRequest)
+		return
+	}
+	result, err := h.config.ResultPersister.GetResult(resultUUID)
+	// the results of the other users are reported as missing
+	if err != nil || result.UserID != user.UserID {
+		http.Error(w, fmt.Sprintf("result not found: %s", resultUUID), http.StatusNotFound)
+		return
+	}
+
+	buf := &bytes.Buffer{}
+	if err = helpers.ExportResult(buf, result, format); err != nil {
+		http.Error(w, "unable to export the result", http.StatusInternalServerError)
+		return
+	}
+	name := unsafeFileNameChars.ReplaceAllString(result.Name, "_")
+	if name == "" {
+		name = "result"
+	}
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%s.%s"`, name, resultUUID, format.Extension()))
+	if _, err = buf.WriteTo(w); err != nil {
+		logrus.Errorf("error writing the exported result: %v", err)
+	}
+}
@@ -1,17 +1,27 @@
 package handlers
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strconv"
 
 	"github.com/gorilla/sessions"
 	"github.com/layer5io/meshery/models"
 	"github.com/sirupsen/logrus"
 )
 
-// FetchResultsHandler fetchs pages of results from SaaS and presents it to the UI
+const (
+	// resultsSourceLocal - the results are read from the local result store
+	resultsSourceLocal = "local"
+	// resultsSourceSaaS - the results are read from SaaS
+	resultsSourceSaaS = "saas"
+)
+
+// FetchResultsHandler fetchs pages of results and presents it to the UI. The source query parameter picks the local
+// result store or SaaS, the local store being used by default when there is one.
 func (h *Handler) FetchResultsHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, user *models.User) {
 	if req.Method != http.MethodGet {
 		w.WriteHeader(http.StatusNotFound)
@@ -30,6 +40,39 @@ func (h *Handler) FetchResultsHandler(w http.ResponseWriter, req *http.Request,
 	}
 	q := req.Form
 
+	source := q.Get("source")
+	if source == "" {
+		source = resultsSourceSaaS
+		if h.config.ResultPersister != nil {
+			source = resultsSourceLocal
+		}
+	}
+	switch source {
+	case resultsSourceLocal:
+		if h.config.ResultPersister == nil {
+			http.Error(w, "the local result store is not available", http.StatusBadRequest)
+			return
+		}
+		page, _ := strconv.ParseUint(q.Get("page"), 10, 64)
+		pageSize, _ := strconv.ParseUint(q.Get("pageSize"), 10, 64)
+		resultPage, err := h.config.ResultPersister.GetResults(user.UserID, page, pageSize, q.Get("search"), q.Get("order"))
+		if err != nil {
+			http.Error(w, "error while getting load test results", http.StatusInternalServerError)
+			return
+		}
+		err = json.NewEncoder(w).Encode(resultPage)
+		if err != nil {
+			logrus.Errorf("error marshalling results: %v", err)
+			http.Error(w, "unable to marshal the results", http.StatusInternalServerError)
+			return
+		}
+		return
+	case resultsSourceSaaS:
+	default:
+		http.Error(w, fmt.Sprintf("source has to be %s or %s", resultsSourceLocal, resultsSourceSaaS), http.StatusBadRequest)
+		return
+	}
+
 	bdr, err := h.getResultsFromSaaS(h.config.SaaSTokenName, tokenVal, q.Get("page"), q.Get("pageSize"), q.Get("search"), q.Get("order"))
 	if err != nil {
 		http.Error(w, "error while getting load test results", http.StatusInternalServerError)
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"encoding/gob"
 	"encoding/json"
 	"io/ioutil"
@@ -161,7 +162,10 @@ func (h *Handler) GrafanaQueryRangeHandler(w http.ResponseWriter, req *http.Requ
 		return
 	}
 
-	data, err := h.config.GrafanaClientForQuery.GrafanaQueryRange(req.Context(), sessObj.Grafana.GrafanaURL, sessObj.Grafana.GrafanaAPIKey, &reqQuery)
+	data, err := h.cacheQueryRange(grafanaBackend(sessObj.Grafana), reqQuery, func() ([]byte, error) {
+		// the query can be shared with other requests, it is not cancelled along with this one
+		return h.config.GrafanaClientForQuery.GrafanaQueryRange(context.Background(), sessObj.Grafana.GrafanaURL, sessObj.Grafana.GrafanaAPIKey, &reqQuery)
+	})
 	if err != nil {
 		msg := "unable to query grafana"
 		logrus.Error(errors.Wrapf(err, msg))
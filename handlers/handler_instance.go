@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"sync"
+
 	"github.com/layer5io/meshery/models"
 	"github.com/vmihailenco/taskq"
 )
@@ -9,6 +11,10 @@ import (
 type Handler struct {
 	config *models.HandlerConfig
 	task   *taskq.Task
+
+	scheduleTask *taskq.Task
+	nextRuns     map[string]string
+	nextRunsLock *sync.Mutex
 }
 
 // NewHandlerInstance returns a Handler instance
@@ -16,13 +22,19 @@ func NewHandlerInstance(
 	handlerConfig *models.HandlerConfig,
 ) models.HandlerInterface {
 	h := &Handler{
-		config: handlerConfig,
+		config:       handlerConfig,
+		nextRuns:     map[string]string{},
+		nextRunsLock: &sync.Mutex{},
 	}
 
-	h.task = handlerConfig.Queue.NewTask(&taskq.TaskOptions{
-		Name:    "submitMetrics",
-		Handler: h.CollectStaticMetrics,
+	h.task = h.newMetricsTask()
+	h.resumeMetricsTasks()
+
+	h.scheduleTask = handlerConfig.Queue.NewTask(&taskq.TaskOptions{
+		Name:    "runScheduledLoadTest",
+		Handler: h.RunScheduledLoadTest,
 	})
+	h.ScheduleLoadTests()
 
 	return h
 }
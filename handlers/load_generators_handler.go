@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/sessions"
+	"github.com/layer5io/meshery/helpers"
+	"github.com/layer5io/meshery/models"
+	"github.com/sirupsen/logrus"
+)
+
+type loadGeneratorInfo struct {
+	Name         models.LoadGenerator              `json:"name"`
+	Capabilities *models.LoadGeneratorCapabilities `json:"capabilities"`
+}
+
+// LoadGeneratorsHandler lists the available load generators along with the features they support
+func (h *Handler) LoadGeneratorsHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, user *models.User) {
+	if req.Method != http.MethodGet {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	lgs := []*loadGeneratorInfo{}
+	for _, lg := range helpers.GetLoadGenerators() {
+		lgs = append(lgs, &loadGeneratorInfo{
+			Name:         lg.Name(),
+			Capabilities: lg.Capabilities(),
+		})
+	}
+	if err := json.NewEncoder(w).Encode(lgs); err != nil {
+		logrus.Errorf("error marshalling the load generators: %v", err)
+		http.Error(w, "unable to marshal the load generators", http.StatusInternalServerError)
+		return
+	}
+}
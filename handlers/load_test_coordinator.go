@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"fortio.org/fortio/periodic"
+	"github.com/layer5io/meshery/helpers"
+	"github.com/layer5io/meshery/models"
+	"github.com/layer5io/meshery/workers"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// workerStartDelay leaves the workers the time to receive their jobs before they all start generating load
+const workerStartDelay = 2 * time.Second
+
+type workerResult struct {
+	index      int
+	resultsMap map[string]interface{}
+	result     *periodic.RunnerResults
+	err        error
+}
+
+// distributedLoadTest fans the load test out to the given workers, each generating an equal share of the load,
+// and merges their results. When one of the workers fails the others are stopped.
+func (h *Handler) distributedLoadTest(ctx context.Context, loadTestOptions *models.LoadTestOptions, addresses []string) (map[string]interface{}, *periodic.RunnerResults, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	shares := helpers.SplitLoadTestOptions(loadTestOptions, len(addresses))
+	startAt := time.Now().Add(workerStartDelay)
+	resChan := make(chan *workerResult, len(addresses))
+	for i, address := range addresses {
+		go func(i int, address string) {
+			res := &workerResult{index: i}
+			defer func() {
+				resChan <- res
+			}()
+			job, err := workers.NewLoadTestJob(shares[i], startAt)
+			if err != nil {
+				res.err = err
+				return
+			}
+			client, err := workers.NewClient(ctx, address, h.config.LoadTestWorkerSecurity)
+			if err != nil {
+				res.err = err
+				return
+			}
+			defer func() {
+				_ = client.Close()
+			}()
+			jobResult, err := client.WClient.RunLoadTest(ctx, job)
+			if err != nil {
+				res.err = errors.Wrapf(err, "load test failed on the worker at %s", address)
+				return
+			}
+			res.resultsMap, res.result, res.err = jobResult.LoadTestResults()
+		}(i, address)
+	}
+
+	resultsMaps := make([]map[string]interface{}, len(addresses))
+	results := make([]*periodic.RunnerResults, len(addresses))
+	var err error
+	for range addresses {
+		res := <-resChan
+		if res.err != nil {
+			logrus.Error(res.err)
+			if err == nil {
+				err = res.err
+				cancel()
+			}
+			continue
+		}
+		resultsMaps[res.index] = res.resultsMap
+		results[res.index] = res.result
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return helpers.MergeWorkerResults(loadTestOptions, addresses, resultsMaps, results)
+}
@@ -3,104 +3,84 @@ package handlers
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/url"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"fortio.org/fortio/periodic"
 	"github.com/gofrs/uuid"
 	"github.com/gorilla/sessions"
 	"github.com/layer5io/meshery/helpers"
 	"github.com/layer5io/meshery/models"
 	"github.com/pkg/errors"
+	promModel "github.com/prometheus/common/model"
 	"github.com/sirupsen/logrus"
-	"fortio.org/fortio/periodic"
 )
 
 // LoadTestHandler runs the load test with the given parameters
 func (h *Handler) LoadTestHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, user *models.User) {
+	if req.Method == http.MethodDelete {
+		h.CancelLoadTestHandler(w, req, session, user)
+		return
+	}
 	if req.Method != http.MethodPost && req.Method != http.MethodGet {
 		w.WriteHeader(http.StatusNotFound)
 		return
 	}
 
 	tokenVal, _ := session.Values[h.config.SaaSTokenName].(string)
-	err := req.ParseForm()
+	loadTestOptions, err := parseLoadTestOptions(req)
 	if err != nil {
-		logrus.Errorf("Error: unable to parse form: %v", err)
-		http.Error(w, "unable to process the received data", http.StatusForbidden)
+		logrus.Errorf("Error: invalid load test options: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 	q := req.URL.Query()
-
-	testName := q.Get("name")
-	if testName == "" {
-		logrus.Errorf("Error: name field is blank")
-		http.Error(w, "Provide a name for the test.", http.StatusForbidden)
-		return
-	}
+	testName := loadTestOptions.Name
 	meshName := q.Get("mesh")
 	testUUID := q.Get("uuid")
 
-	loadTestOptions := &models.LoadTestOptions{}
-
-	tt, _ := strconv.Atoi(q.Get("t"))
-	if tt < 1 {
-		tt = 1
-	}
-	dur := ""
-	switch strings.ToLower(q.Get("dur")) {
-	case "h":
-		dur = "h"
-	case "m":
-		dur = "m"
-	// case "s":
-	default:
-		dur = "s"
-	}
-	loadTestOptions.Duration, err = time.ParseDuration(fmt.Sprintf("%d%s", tt, dur))
+	workerAddrs, err := h.parseLoadTestWorkers(q.Get("workers"))
 	if err != nil {
-		logrus.Errorf("Error: unable to parse load test duration: %v", err)
-		http.Error(w, "unable to process the received data", http.StatusForbidden)
+		logrus.Errorf("Error: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	loadTestOptions.IsGRPC = false
-
-	cc, _ := strconv.Atoi(q.Get("c"))
-	if cc < 1 {
-		cc = 1
+	runID := testUUID
+	if runID == "" {
+		runUUID, err := uuid.NewV4()
+		if err != nil {
+			logrus.Errorf("Error: unable to generate a load test id: %v", err)
+			http.Error(w, "error while running load test", http.StatusInternalServerError)
+			return
+		}
+		runID = runUUID.String()
 	}
-	loadTestOptions.HTTPNumThreads = cc
-
-	loadTestURL := q.Get("url")
-	ltURL, err := url.Parse(loadTestURL)
-	if err != nil || !ltURL.IsAbs() {
-		logrus.Errorf("unable to parse the provided load test url: %v", err)
-		http.Error(w, "invalid load test URL", http.StatusBadRequest)
+	ctx, cancel := context.WithCancel(context.Background())
+	if err = h.config.LoadTestTracker.AddRun(ctx, runID, user.UserID, cancel); err != nil {
+		cancel()
+		logrus.Errorf("Error: unable to start the load test: %v", err)
+		http.Error(w, err.Error(), http.StatusConflict)
 		return
 	}
-	loadTestOptions.URL = loadTestURL
-	loadTestOptions.Name = testName
-
-	qps, _ := strconv.ParseFloat(q.Get("qps"), 64)
-	if qps < 0 {
-		qps = 0
-	}
-	loadTestOptions.HTTPQPS = qps
-
-	loadGenerator := q.Get("loadGenerator")
-
-	switch loadGenerator {
-	case "wrk2":
-		loadTestOptions.LoadGenerator = models.Wrk2LG
-	default:
-		loadTestOptions.LoadGenerator = models.FortioLG
-	}
+	runStarted := false
+	defer func() {
+		// the run is tracked from here on when it is started, otherwise it has to be cleaned up now
+		if !runStarted {
+			h.config.LoadTestTracker.RemoveRun(ctx, runID)
+			cancel()
+		}
+	}()
 
 	// q.Set("json", "on")
 
@@ -124,13 +104,30 @@ func (h *Handler) LoadTestHandler(w http.ResponseWriter, req *http.Request, sess
 		sessObj = &models.Session{}
 	}
 
+	job := &models.LoadTestJob{
+		ID:     runID,
+		UserID: user.UserID,
+		Name:   testName,
+		Target: loadTestTarget(loadTestOptions),
+	}
+	runStarted = h.streamLoadTest(ctx, cancel, w, runID, func(respChan chan *models.LoadTestResponse) {
+		h.queueLoadTest(ctx, job, respChan, func() {
+			h.executeLoadTest(ctx, testName, meshName, tokenVal, testUUID, runID, user.UserID, sessObj, loadTestOptions, workerAddrs, respChan)
+		})
+	})
+}
+
+// streamLoadTest runs the tracked load test with the given id in the background and streams the responses it sends on
+// respChan as server sent events. The load test is cancelled when the client goes away.
+// It returns false when the load test could not be started.
+func (h *Handler) streamLoadTest(ctx context.Context, cancel context.CancelFunc, w http.ResponseWriter, runID string, run func(respChan chan *models.LoadTestResponse)) bool {
 	log := logrus.WithField("file", "load_test_handler")
 
 	flusher, ok := w.(http.Flusher)
 	if !ok {
 		log.Error("Event streaming not supported.")
 		http.Error(w, "Event streaming is not supported at the moment.", http.StatusInternalServerError)
-		return
+		return false
 	}
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
@@ -167,34 +164,420 @@ func (h *Handler) LoadTestHandler(w http.ResponseWriter, req *http.Request, sess
 		log.Debug("response channel closed")
 	}()
 	go func() {
-		h.executeLoadTest(testName, meshName, tokenVal, testUUID, sessObj, loadTestOptions, respChan)
+		defer func() {
+			h.config.LoadTestTracker.RemoveRun(ctx, runID)
+			cancel()
+		}()
+		run(respChan)
 		close(respChan)
 	}()
 	select {
 	case <-notify:
 		log.Debugf("received signal to close connection and channels")
+		// nobody is waiting for the results anymore, so stop generating load
+		cancel()
 		break
 	case <-endChan:
 		log.Debugf("load test completed")
 	}
+	return true
 }
 
-func (h *Handler) executeLoadTest(testName, meshName, tokenVal, testUUID string, sessObj *models.Session, loadTestOptions *models.LoadTestOptions, respChan chan *models.LoadTestResponse) {
+// parseLoadTestOptions parses the load test options from the query parameters and the form of the request
+func parseLoadTestOptions(req *http.Request) (*models.LoadTestOptions, error) {
+	err := req.ParseForm()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to process the received data")
+	}
+	q := req.URL.Query()
+
+	testName := q.Get("name")
+	if testName == "" {
+		return nil, errors.New("provide a name for the test")
+	}
+
+	loadTestOptions := &models.LoadTestOptions{}
+
+	tt, _ := strconv.Atoi(q.Get("t"))
+	if tt < 1 {
+		tt = 1
+	}
+	dur := ""
+	switch strings.ToLower(q.Get("dur")) {
+	case "h":
+		dur = "h"
+	case "m":
+		dur = "m"
+	// case "s":
+	default:
+		dur = "s"
+	}
+	loadTestOptions.Duration, err = time.ParseDuration(fmt.Sprintf("%d%s", tt, dur))
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse load test duration")
+	}
+
+	loadTestOptions.IsGRPC, _ = strconv.ParseBool(q.Get("grpc"))
+
+	cc, _ := strconv.Atoi(q.Get("c"))
+	if cc < 1 {
+		cc = 1
+	}
+	loadTestOptions.HTTPNumThreads = cc
+
+	loadTestURL := q.Get("url")
+	ltURL, err := url.Parse(loadTestURL)
+	// gRPC destinations can also be given as host:port
+	if (err != nil || !ltURL.IsAbs()) && !(loadTestOptions.IsGRPC && isHostPort(loadTestURL)) {
+		return nil, errors.New("invalid load test URL")
+	}
+	loadTestOptions.URL = loadTestURL
+	loadTestOptions.Name = testName
+
+	lg, err := helpers.GetLoadGenerator(models.LoadGenerator(q.Get("loadGenerator")))
+	if err != nil {
+		return nil, err
+	}
+	loadTestOptions.LoadGenerator = lg.Name()
+
+	if err = parseHTTPRequestOptions(req, loadTestOptions, lg); err != nil {
+		return nil, errors.Wrap(err, "invalid http request options")
+	}
+
+	if loadTestOptions.IsGRPC {
+		if err = parseGRPCOptions(req, loadTestOptions); err != nil {
+			return nil, errors.Wrap(err, "invalid grpc options")
+		}
+	}
+
+	if err = parseTLSOptions(req, loadTestOptions); err != nil {
+		return nil, errors.Wrap(err, "invalid tls options")
+	}
+
+	loadTestOptions.HTTP2, _ = strconv.ParseBool(q.Get("http2"))
+	loadTestOptions.OpenModel, _ = strconv.ParseBool(q.Get("openModel"))
+
+	qps, _ := strconv.ParseFloat(q.Get("qps"), 64)
+	if qps < 0 {
+		qps = 0
+	}
+	loadTestOptions.HTTPQPS = qps
+
+	if stages := q.Get("stages"); stages != "" {
+		loadTestOptions.Stages = []*models.LoadTestStage{}
+		if err = json.Unmarshal([]byte(stages), &loadTestOptions.Stages); err != nil {
+			return nil, errors.Wrap(err, "unable to parse the load test stages")
+		}
+		var totalDuration time.Duration
+		for i, stage := range loadTestOptions.Stages {
+			if stage == nil || stage.Duration <= 0 || stage.QPS < 0 || stage.StartQPS < 0 || stage.NumThreads < 0 {
+				return nil, fmt.Errorf("invalid load test stage %d: duration must be positive and qps/threads must not be negative", i+1)
+			}
+			// a rate of 0 would make the load generator run at its max rate
+			if stage.QPS <= 0 && (!stage.Ramp || stage.StartQPS <= 0) {
+				return nil, fmt.Errorf("invalid load test stage %d: qps must be positive", i+1)
+			}
+			totalDuration += stage.Duration
+		}
+		loadTestOptions.Duration = totalDuration
+	}
+
+	if discovery := q.Get("discovery"); discovery != "" {
+		if len(loadTestOptions.Stages) > 0 {
+			return nil, errors.New("the throughput discovery can not be combined with load test stages")
+		}
+		loadTestOptions.Discovery = &models.ThroughputDiscovery{}
+		if err = json.Unmarshal([]byte(discovery), loadTestOptions.Discovery); err != nil {
+			return nil, errors.Wrap(err, "unable to parse the throughput discovery")
+		}
+		d := loadTestOptions.Discovery
+		if d.MinQPS <= 0 || d.MaxQPS < 0 || (d.MaxQPS > 0 && d.MaxQPS < d.MinQPS) || d.Precision < 0 || d.MaxProbes < 0 {
+			return nil, errors.New("invalid throughput discovery: min_qps must be positive and max_qps, when given, at least min_qps")
+		}
+	}
+
+	if err = parseAbortOptions(req, loadTestOptions); err != nil {
+		return nil, errors.Wrap(err, "invalid abort options")
+	}
+
+	// the snapshots are only taken by default when the load generator supports them
+	if lg.Capabilities().Snapshots && !loadTestOptions.IsGRPC {
+		loadTestOptions.SnapshotInterval = defaultSnapshotInterval
+	}
+	if interval := q.Get("snapshotInterval"); interval != "" {
+		loadTestOptions.SnapshotInterval, err = time.ParseDuration(interval)
+		if err != nil || (loadTestOptions.SnapshotInterval != 0 && loadTestOptions.SnapshotInterval < time.Second) {
+			return nil, fmt.Errorf("invalid snapshot interval, expecting 0 to disable them or at least 1s: %s", interval)
+		}
+	}
+
+	if err = lg.Validate(loadTestOptions); err != nil {
+		return nil, errors.Wrap(err, "unsupported load test options")
+	}
+
+	if assertions := q.Get("assertions"); assertions != "" {
+		if err = json.Unmarshal([]byte(assertions), &loadTestOptions.Assertions); err != nil {
+			return nil, errors.Wrap(err, "unable to parse the SLO assertions")
+		}
+		for i, assertion := range loadTestOptions.Assertions {
+			if assertion == nil {
+				return nil, fmt.Errorf("invalid SLO assertion %d", i+1)
+			}
+			if err = assertion.Validate(); err != nil {
+				return nil, errors.Wrapf(err, "invalid SLO assertion %d", i+1)
+			}
+		}
+	}
+	if loadTestOptions.Discovery != nil && len(loadTestOptions.Assertions) == 0 {
+		return nil, errors.New("the throughput discovery needs SLO assertions to tell whether a rate is sustained")
+	}
+	return loadTestOptions, nil
+}
+
+// parseLoadTestWorkers returns the addresses of the given number of load generator workers the load can be
+// shared among, instead of being generated locally
+func (h *Handler) parseLoadTestWorkers(workersCount string) ([]string, error) {
+	if workersCount == "" {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(workersCount)
+	if err != nil || n < 0 || n > len(h.config.LoadTestWorkers) {
+		return nil, fmt.Errorf("invalid number of load generator workers, %d are available", len(h.config.LoadTestWorkers))
+	}
+	return h.config.LoadTestWorkers[:n], nil
+}
+
+// CancelLoadTestHandler cancels the running load test with the given uuid.
+// The load test stops generating load and the results gathered so far are persisted as usual.
+func (h *Handler) CancelLoadTestHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, user *models.User) {
+	if req.Method != http.MethodDelete {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	runID := req.URL.Query().Get("uuid")
+	if runID == "" {
+		http.Error(w, "Provide the uuid of the load test to cancel.", http.StatusBadRequest)
+		return
+	}
+	if !h.config.LoadTestTracker.CancelRun(req.Context(), runID, user.UserID) {
+		logrus.Errorf("Error: no running load test found for the id %s", runID)
+		http.Error(w, "no running load test found for the given uuid", http.StatusNotFound)
+		return
+	}
+	logrus.Infof("load test %s cancelled", runID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parseAbortOptions parses the conditions to abort the load test on: abortOn, an http status code or -1 for
+// socket errors, and maxErrorRate, the percentage of failed requests above which the load test is aborted.
+func parseAbortOptions(req *http.Request, loadTestOptions *models.LoadTestOptions) error {
+	if abortOn := req.FormValue("abortOn"); abortOn != "" {
+		code, err := strconv.Atoi(abortOn)
+		if err != nil || (code != -1 && (code < 100 || code > 599)) {
+			return fmt.Errorf("invalid status code to abort on: %s", abortOn)
+		}
+		loadTestOptions.AbortOn = code
+	}
+	if maxErrorRate := req.FormValue("maxErrorRate"); maxErrorRate != "" {
+		rate, err := strconv.ParseFloat(maxErrorRate, 64)
+		if err != nil || rate <= 0 || rate >= 100 {
+			return fmt.Errorf("invalid maximum error rate, expecting a percentage between 0 and 100: %s", maxErrorRate)
+		}
+		loadTestOptions.MaxErrorRate = rate / 100
+	}
+	return nil
+}
+
+// parseHTTPRequestOptions parses the method, headers, body, content type, credentials and timeout of the
+// requests to be sent during the load test. The body can either be given inline or uploaded as payloadFile.
+// The method has to be supported by the load generator.
+func parseHTTPRequestOptions(req *http.Request, loadTestOptions *models.LoadTestOptions, lg models.LoadGeneratorInterface) error {
+	if strings.HasPrefix(req.Header.Get("Content-Type"), "multipart/form-data") {
+		if err := req.ParseMultipartForm(maxLoadTestPayloadSize); err != nil {
+			return errors.Wrap(err, "unable to parse the multipart form")
+		}
+	}
+
+	body := req.FormValue("body")
+	payload, err := readUploadedFile(req, "payloadFile")
+	if err != nil {
+		return err
+	}
+	if body != "" && payload != "" {
+		return errors.New("provide either an inline body or a payload file, not both")
+	}
+	loadTestOptions.HTTPBody = []byte(body + payload)
+
+	loadTestOptions.HTTPContentType = req.FormValue("contentType")
+
+	method := strings.ToUpper(strings.TrimSpace(req.FormValue("method")))
+	if method == "" {
+		method = http.MethodGet
+		if len(loadTestOptions.HTTPBody) > 0 || loadTestOptions.HTTPContentType != "" {
+			method = http.MethodPost
+		}
+	}
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodDelete:
+		if len(loadTestOptions.HTTPBody) > 0 {
+			return fmt.Errorf("a request body can not be sent with the %s method", method)
+		}
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+	default:
+		return fmt.Errorf("unsupported http method: %s", method)
+	}
+	if !loadTestOptions.IsGRPC {
+		supported := false
+		for _, m := range lg.Capabilities().HTTPMethods {
+			supported = supported || m == method
+		}
+		if !supported {
+			return fmt.Errorf("%s does not support the %s method, only %v", lg.Name(), method, lg.Capabilities().HTTPMethods)
+		}
+	}
+	loadTestOptions.HTTPMethod = method
+
+	for _, hdr := range req.Form["header"] {
+		if kv := strings.SplitN(hdr, ":", 2); len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			return fmt.Errorf("invalid header '%s', expecting Key: Value", hdr)
+		}
+		loadTestOptions.HTTPHeaders = append(loadTestOptions.HTTPHeaders, hdr)
+	}
+
+	if user := req.FormValue("user"); user != "" {
+		loadTestOptions.HTTPUserCredentials = user + ":" + req.FormValue("password")
+	}
+
+	if timeout := req.FormValue("timeout"); timeout != "" {
+		loadTestOptions.HTTPReqTimeout, err = time.ParseDuration(timeout)
+		if err != nil || loadTestOptions.HTTPReqTimeout <= 0 {
+			return fmt.Errorf("invalid request timeout: %s", timeout)
+		}
+	}
+	return nil
+}
+
+// parseGRPCOptions parses the gRPC specific options: the number of streams and the kind of load test, health
+// check or ping. Only a CA certificate can be uploaded for gRPC load tests, the gRPC runner of fortio not supporting
+// client certificates.
+func parseGRPCOptions(req *http.Request, loadTestOptions *models.LoadTestOptions) error {
+	if len(loadTestOptions.HTTPHeaders) > 0 || loadTestOptions.HTTPUserCredentials != "" ||
+		loadTestOptions.HTTPContentType != "" || loadTestOptions.HTTPReqTimeout > 0 || req.FormValue("method") != "" {
+		return errors.New("http method, headers, content type, credentials and timeout can not be used with gRPC")
+	}
+	if hasUploadedFile(req, "cert") || hasUploadedFile(req, "key") {
+		return errors.New("client certificates are not supported for gRPC load tests, only a CA certificate")
+	}
+	loadTestOptions.HTTPMethod = ""
+
+	streams := req.FormValue("grpcStreams")
+	if streams != "" {
+		var err error
+		loadTestOptions.GRPCStreamsCount, err = strconv.Atoi(streams)
+		if err != nil || loadTestOptions.GRPCStreamsCount < 1 {
+			return fmt.Errorf("invalid number of gRPC streams: %s", streams)
+		}
+	} else {
+		loadTestOptions.GRPCStreamsCount = 1
+	}
+
+	loadTestOptions.GRPCDoHealth, _ = strconv.ParseBool(req.FormValue("grpcHealth"))
+	loadTestOptions.GRPCDoPing, _ = strconv.ParseBool(req.FormValue("grpcPing"))
+	if loadTestOptions.GRPCDoHealth && loadTestOptions.GRPCDoPing {
+		return errors.New("gRPC health check and ping can not be combined, please choose one")
+	}
+	if !loadTestOptions.GRPCDoPing {
+		// the health check is the default gRPC load test
+		loadTestOptions.GRPCDoHealth = true
+		if len(loadTestOptions.HTTPBody) > 0 {
+			return errors.New("a payload can only be sent with gRPC ping")
+		}
+	}
+	loadTestOptions.GRPCHealthSvc = req.FormValue("grpcHealthSvc")
+	if loadTestOptions.GRPCHealthSvc != "" && !loadTestOptions.GRPCDoHealth {
+		return errors.New("a health service can only be given with gRPC health check")
+	}
+	if delay := req.FormValue("grpcPingDelay"); delay != "" {
+		if !loadTestOptions.GRPCDoPing {
+			return errors.New("a ping delay can only be given with gRPC ping")
+		}
+		var err error
+		loadTestOptions.GRPCPingDelay, err = time.ParseDuration(delay)
+		if err != nil || loadTestOptions.GRPCPingDelay < 0 {
+			return fmt.Errorf("invalid gRPC ping delay: %s", delay)
+		}
+	}
+
+	return nil
+}
+
+// parseTLSOptions parses the TLS certificates which can be uploaded as caCert, cert and key, cert and key being
+// only supported for http load tests.
+func parseTLSOptions(req *http.Request, loadTestOptions *models.LoadTestOptions) error {
+	var err error
+	if loadTestOptions.CACert, err = readUploadedFile(req, "caCert"); err != nil {
+		return err
+	}
+	if loadTestOptions.Cert, err = readUploadedFile(req, "cert"); err != nil {
+		return err
+	}
+	if loadTestOptions.Key, err = readUploadedFile(req, "key"); err != nil {
+		return err
+	}
+	if (loadTestOptions.Cert == "") != (loadTestOptions.Key == "") {
+		return errors.New("the client certificate and key have to be provided together")
+	}
+	if loadTestOptions.CACert != "" {
+		if ok := x509.NewCertPool().AppendCertsFromPEM([]byte(loadTestOptions.CACert)); !ok {
+			return errors.New("unable to parse the CA certificate, expecting PEM")
+		}
+	}
+	if loadTestOptions.Cert != "" {
+		if _, err = tls.X509KeyPair([]byte(loadTestOptions.Cert), []byte(loadTestOptions.Key)); err != nil {
+			return errors.Wrap(err, "invalid client certificate and key")
+		}
+	}
+	return nil
+}
+
+// hasUploadedFile tells whether a file was uploaded with the given name
+func hasUploadedFile(req *http.Request, name string) bool {
+	return req.MultipartForm != nil && len(req.MultipartForm.File[name]) > 0
+}
+
+// readUploadedFile returns the content of the uploaded file with the given name, or an empty string if there is none
+func readUploadedFile(req *http.Request, name string) (string, error) {
+	file, _, err := req.FormFile(name)
+	if err == http.ErrMissingFile || err == http.ErrNotMultipart {
+		return "", nil
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to read the uploaded %s file", name)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	bd, err := ioutil.ReadAll(file)
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to read the uploaded %s file", name)
+	}
+	return string(bd), nil
+}
+
+// isHostPort returns true if the address is of the form host:port
+func isHostPort(address string) bool {
+	host, port, err := net.SplitHostPort(address)
+	return err == nil && host != "" && port != ""
+}
+
+func (h *Handler) executeLoadTest(ctx context.Context, testName, meshName, tokenVal, testUUID, runID, userID string, sessObj *models.Session, loadTestOptions *models.LoadTestOptions, workerAddrs []string, respChan chan *models.LoadTestResponse) {
 	respChan <- &models.LoadTestResponse{
 		Status:  models.LoadTestInfo,
 		Message: "Initiating load test . . . ",
+		RunID:   runID,
 	}
 	// resultsMap, resultInst, err := helpers.FortioLoadTest(loadTestOptions)
-	var (
-		resultsMap map[string]interface{} 
-		resultInst *periodic.RunnerResults
-		err error
-	)
-	if loadTestOptions.LoadGenerator == models.Wrk2LG {
-		resultsMap, resultInst, err = helpers.WRK2LoadTest(loadTestOptions)	
-	} else {
-		resultsMap, resultInst, err = helpers.FortioLoadTest(loadTestOptions)
-	}
+	resultsMap, resultInst, err := h.runLoadTest(ctx, loadTestOptions, workerAddrs, h.prometheus(sessObj), respChan)
 	if err != nil {
 		msg := "error: unable to perform load test"
 		err = errors.Wrap(err, msg)
@@ -210,67 +593,156 @@ func (h *Handler) executeLoadTest(testName, meshName, tokenVal, testUUID string,
 		Status:  models.LoadTestInfo,
 		Message: "Load test completed, fetching metadata now",
 	}
+	h.addKubernetesMetadata(sessObj, resultsMap)
+	respChan <- &models.LoadTestResponse{
+		Status:  models.LoadTestInfo,
+		Message: "Obtained the needed metadatas, attempting to persist the result",
+	}
 
-	if sessObj.K8SConfig != nil {
-		nodesChan := make(chan []*models.K8SNode)
-		versionChan := make(chan string)
-		installedMeshesChan := make(chan map[string]string)
-
-		go func() {
-			var nodes []*models.K8SNode
-			var err error
-			if len(sessObj.K8SConfig.Nodes) == 0 {
-				nodes, err = helpers.FetchKubernetesNodes(sessObj.K8SConfig.Config, sessObj.K8SConfig.ContextName)
-				if err != nil {
-					err = errors.Wrap(err, "unable to ping kubernetes")
-					// logrus.Error(err)
-					logrus.Warn(err)
-					// return
-				}
-			}
-			nodesChan <- nodes
-		}()
-		go func() {
-			var serverVersion string
-			var err error
-			if sessObj.K8SConfig.ServerVersion == "" {
-				serverVersion, err = helpers.FetchKubernetesVersion(sessObj.K8SConfig.Config, sessObj.K8SConfig.ContextName)
-				if err != nil {
-					err = errors.Wrap(err, "unable to ping kubernetes")
-					// logrus.Error(err)
-					logrus.Warn(err)
-					// return
-				}
+	result := &models.MesheryResult{
+		Name:   testName,
+		Mesh:   meshName,
+		Result: resultsMap,
+	}
+	h.completeLoadTest(tokenVal, testUUID, userID, sessObj, loadTestOptions, result, resultInst, respChan)
+}
+
+// runLoadTest runs the load test, locally or on the given workers, and reports its progress on respChan.
+// prom is needed by the throughput discovery for the prometheus assertions.
+func (h *Handler) runLoadTest(ctx context.Context, loadTestOptions *models.LoadTestOptions, workerAddrs []string, prom *models.Prometheus, respChan chan *models.LoadTestResponse) (map[string]interface{}, *periodic.RunnerResults, error) {
+	var (
+		resultsMap map[string]interface{}
+		resultInst *periodic.RunnerResults
+		err        error
+	)
+	lg, err := helpers.GetLoadGenerator(loadTestOptions.LoadGenerator)
+	if err != nil {
+		return nil, nil, err
+	}
+	loadTestOptions.OnSnapshot = func(snapshot *models.LoadTestSnapshot) {
+		// the load test must not wait for a slow client, the snapshots are kept in the results anyway
+		select {
+		case respChan <- &models.LoadTestResponse{
+			Status: models.LoadTestInterim,
+			Message: fmt.Sprintf("%.1f qps, p50 %.2f ms, p90 %.2f ms, p99 %.2f ms, %d errors",
+				snapshot.QPS, snapshot.P50, snapshot.P90, snapshot.P99, snapshot.Errors),
+			Snapshot: snapshot,
+		}:
+		default:
+			logrus.Debugf("dropping the load test snapshot taken at %v, the client is not keeping up", snapshot.Time)
+		}
+	}
+	loadTest := lg.Run
+	progress := func(msg string) {
+		respChan <- &models.LoadTestResponse{
+			Status:  models.LoadTestInfo,
+			Message: msg,
+		}
+	}
+	if len(workerAddrs) > 0 && loadTestOptions.Discovery != nil {
+		return nil, nil, errors.New("the throughput discovery can not be shared among load generator workers")
+	}
+	if len(workerAddrs) > 0 {
+		respChan <- &models.LoadTestResponse{
+			Status:  models.LoadTestInfo,
+			Message: fmt.Sprintf("Sharing the load test among %d load generator workers", len(workerAddrs)),
+		}
+		resultsMap, resultInst, err = h.distributedLoadTest(ctx, loadTestOptions, workerAddrs)
+	} else if len(loadTestOptions.Stages) > 0 {
+		resultsMap, resultInst, err = helpers.StagedLoadTest(ctx, loadTestOptions, loadTest, progress)
+	} else if loadTestOptions.Discovery != nil {
+		resultsMap, resultInst, err = helpers.DiscoverThroughput(ctx, loadTestOptions, loadTest, h.promQuery(prom), h.firedAlerts(prom), progress)
+	} else {
+		resultsMap, resultInst, err = loadTest(ctx, loadTestOptions)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if abortReason, _ := resultsMap["abort_reason"].(string); abortReason != "" {
+		progress(fmt.Sprintf("Load test aborted: %s, keeping the results gathered so far", abortReason))
+	}
+	if discovery, ok := resultsMap["throughput_discovery"].(*models.ThroughputDiscoveryResult); ok {
+		progress(fmt.Sprintf("Throughput discovery completed after %d probes, knee at %g qps", len(discovery.Probes), discovery.KneeQPS))
+	}
+	return resultsMap, resultInst, nil
+}
+
+// addKubernetesMetadata adds the kubernetes version and nodes along with the detected meshes to the results
+func (h *Handler) addKubernetesMetadata(sessObj *models.Session, resultsMap map[string]interface{}) {
+	if sessObj.K8SConfig == nil {
+		return
+	}
+	nodesChan := make(chan []*models.K8SNode)
+	versionChan := make(chan string)
+	installedMeshesChan := make(chan map[string]string)
+
+	go func() {
+		var nodes []*models.K8SNode
+		var err error
+		if len(sessObj.K8SConfig.Nodes) == 0 {
+			nodes, err = helpers.FetchKubernetesNodes(sessObj.K8SConfig.Config, sessObj.K8SConfig.ContextName)
+			if err != nil {
+				err = errors.Wrap(err, "unable to ping kubernetes")
+				// logrus.Error(err)
+				logrus.Warn(err)
+				// return
 			}
-			versionChan <- serverVersion
-		}()
-		go func() {
-			installedMeshes, err := helpers.ScanKubernetes(sessObj.K8SConfig.Config, sessObj.K8SConfig.ContextName)
+		}
+		nodesChan <- nodes
+	}()
+	go func() {
+		var serverVersion string
+		var err error
+		if sessObj.K8SConfig.ServerVersion == "" {
+			serverVersion, err = helpers.FetchKubernetesVersion(sessObj.K8SConfig.Config, sessObj.K8SConfig.ContextName)
 			if err != nil {
-				err = errors.Wrap(err, "unable to scan kubernetes")
+				err = errors.Wrap(err, "unable to ping kubernetes")
+				// logrus.Error(err)
 				logrus.Warn(err)
+				// return
 			}
-			installedMeshesChan <- installedMeshes
-		}()
+		}
+		versionChan <- serverVersion
+	}()
+	go func() {
+		installedMeshes, err := helpers.ScanKubernetes(sessObj.K8SConfig.Config, sessObj.K8SConfig.ContextName)
+		if err != nil {
+			err = errors.Wrap(err, "unable to scan kubernetes")
+			logrus.Warn(err)
+		}
+		installedMeshesChan <- installedMeshes
+	}()
 
-		sessObj.K8SConfig.Nodes = <-nodesChan
-		sessObj.K8SConfig.ServerVersion = <-versionChan
+	sessObj.K8SConfig.Nodes = <-nodesChan
+	sessObj.K8SConfig.ServerVersion = <-versionChan
 
-		if sessObj.K8SConfig.ServerVersion != "" && len(sessObj.K8SConfig.Nodes) > 0 {
-			resultsMap["kubernetes"] = map[string]interface{}{
-				"server_version": sessObj.K8SConfig.ServerVersion,
-				"nodes":          sessObj.K8SConfig.Nodes,
-			}
-		}
-		installedMeshes := <-installedMeshesChan
-		if len(installedMeshes) > 0 {
-			resultsMap["detected-meshes"] = installedMeshes
+	if sessObj.K8SConfig.ServerVersion != "" && len(sessObj.K8SConfig.Nodes) > 0 {
+		resultsMap["kubernetes"] = map[string]interface{}{
+			"server_version": sessObj.K8SConfig.ServerVersion,
+			"nodes":          sessObj.K8SConfig.Nodes,
 		}
 	}
-	respChan <- &models.LoadTestResponse{
-		Status:  models.LoadTestInfo,
-		Message: "Obtained the needed metadatas, attempting to persist the result",
+	installedMeshes := <-installedMeshesChan
+	if len(installedMeshes) > 0 {
+		resultsMap["detected-meshes"] = installedMeshes
+	}
+}
+
+// detectedMeshes returns the service meshes found by the kubernetes scan of the load test
+func detectedMeshes(result *models.MesheryResult) []string {
+	meshes := []string{}
+	installedMeshes, _ := result.Result["detected-meshes"].(map[string]string)
+	for mesh := range installedMeshes {
+		meshes = append(meshes, mesh)
 	}
+	sort.Strings(meshes)
+	return meshes
+}
+
+// completeLoadTest evaluates the SLO assertions of the result, persists it, schedules the collection of its server
+// metrics and sends it on respChan
+func (h *Handler) completeLoadTest(tokenVal, testUUID, userID string, sessObj *models.Session, loadTestOptions *models.LoadTestOptions, result *models.MesheryResult, resultInst *periodic.RunnerResults, respChan chan *models.LoadTestResponse) {
 	// // defer fortioResp.Body.Close()
 	// // bd, err := ioutil.ReadAll(fortioResp.Body)
 	// bd, err := json.Marshal(resp)
@@ -280,29 +752,32 @@ func (h *Handler) executeLoadTest(testName, meshName, tokenVal, testUUID string,
 	// 	return
 	// }
 
-	result := &models.MesheryResult{
-		Name:   testName,
-		Mesh:   meshName,
-		Result: resultsMap,
+	var promURL string
+	if sessObj.Prometheus != nil {
+		promURL = sessObj.Prometheus.PrometheusURL
 	}
-	// TODO: can we do something to prevent marshalling twice??
-	bd, err := json.Marshal(result)
-	if err != nil {
-		msg := "error: unable to marshal meshery result for shipping"
-		err = errors.Wrap(err, msg)
-		logrus.Error(err)
-		// http.Error(w, "error while running load test", http.StatusInternalServerError)
+
+	prom := h.prometheus(sessObj)
+	if promURL != "" {
+		h.attachFiredAlerts(result, prom, resultInst)
+		if result.AlertsFired {
+			respChan <- &models.LoadTestResponse{
+				Status:  models.LoadTestInfo,
+				Message: fmt.Sprintf("%d prometheus alerts fired during the load test", len(result.Alerts)),
+			}
+		}
+	}
+
+	if len(loadTestOptions.Assertions) > 0 {
+		h.evaluateSLOAssertions(result, loadTestOptions.Assertions, prom)
 		respChan <- &models.LoadTestResponse{
-			Status:  models.LoadTestError,
-			Message: msg,
+			Status:  models.LoadTestInfo,
+			Message: fmt.Sprintf("SLO verdict: %s", result.Verdict),
 		}
-		return
 	}
 
-	resultID, err := h.publishResultsToSaaS(h.config.SaaSTokenName, tokenVal, bd)
+	resultID, saasResultID, err := h.persistResult(tokenVal, userID, result)
 	if err != nil {
-		// http.Error(w, "error while getting load test results", http.StatusInternalServerError)
-		// return
 		msg := "error: unable to persist the load test results"
 		err = errors.Wrap(err, msg)
 		logrus.Error(err)
@@ -318,21 +793,20 @@ func (h *Handler) executeLoadTest(testName, meshName, tokenVal, testUUID string,
 		Message: "Done persisting the load test results.",
 	}
 
-	var promURL string
-	if sessObj.Prometheus != nil {
-		promURL = sessObj.Prometheus.PrometheusURL
-	}
-
-	logrus.Debugf("promURL: %s, testUUID: %s, resultID: %s", promURL, testUUID, resultID)
-	if promURL != "" && testUUID != "" && resultID != "" {
-		_ = h.task.Call(&models.SubmitMetricsConfig{
-			TestUUID:  testUUID,
-			ResultID:  resultID,
-			PromURL:   promURL,
-			StartTime: resultInst.StartTime,
-			EndTime:   resultInst.StartTime.Add(resultInst.ActualDuration),
-			TokenKey:  h.config.SaaSTokenName,
-			TokenVal:  tokenVal,
+	logrus.Debugf("promURL: %s, testUUID: %s, resultID: %s, saasResultID: %s", promURL, testUUID, resultID, saasResultID)
+	if promURL != "" && testUUID != "" && (resultID != "" || saasResultID != "") {
+		h.submitMetrics(&models.SubmitMetricsConfig{
+			TestUUID:     testUUID,
+			UserID:       userID,
+			ResultID:     resultID,
+			SaaSResultID: saasResultID,
+			PromURL:      promURL,
+			PromAuth:     sessObj.Prometheus.Auth,
+			StartTime:    resultInst.StartTime,
+			EndTime:      resultInst.StartTime.Add(resultInst.ActualDuration),
+			TokenKey:     h.config.SaaSTokenName,
+			TokenVal:     tokenVal,
+			Meshes:       detectedMeshes(result),
 		})
 	}
 
@@ -343,65 +817,264 @@ func (h *Handler) executeLoadTest(testName, meshName, tokenVal, testUUID string,
 	}
 }
 
+// evaluateSLOAssertions evaluates the assertions on the results and records them along with the verdict in the result.
+// The prometheus assertions need prom to be set.
+func (h *Handler) evaluateSLOAssertions(result *models.MesheryResult, assertions []*models.SLOAssertion, prom *models.Prometheus) {
+	// the load test may have been cancelled, the assertions are evaluated regardless
+	result.Assertions, result.Verdict = helpers.EvaluateSLOAssertions(context.Background(), assertions, result.Result, h.promQuery(prom))
+	for _, res := range result.Assertions {
+		switch {
+		case res.Error != "":
+			logrus.Warnf("unable to evaluate the SLO assertion %s: %s", res.Assertion, res.Error)
+		case !res.Passed:
+			logrus.Infof("SLO assertion %s failed, actual value: %g", res.Assertion, res.Actual)
+		}
+	}
+}
+
+// queryServerMetrics range queries prometheus over the load test window with the step options of each query, the
+// results being downsampled and keyed by query
+func (h *Handler) queryServerMetrics(ctx context.Context, prom *models.Prometheus, config *models.SubmitMetricsConfig, queries map[string]*models.StepOptions) (map[string]interface{}, error) {
+	queryResults := map[string]interface{}{}
+	for query, opts := range queries {
+		step := h.config.PrometheusClient.ComputeStep(ctx, prom, config.StartTime, config.EndTime, opts)
+		seriesData, err := h.config.PrometheusClient.QueryRangeUsingClient(ctx, prom, query, config.StartTime, config.EndTime, step)
+		if err != nil {
+			return nil, err
+		}
+		seriesData = helpers.DownsampleMatrix(seriesData, opts.GetMaxDataPoints())
+		queryResults[query] = map[string]interface{}{
+			"status": "success",
+			"data": map[string]interface{}{
+				"resultType": seriesData.Type(),
+				"result":     seriesData,
+			},
+		}
+		// sd, _ := json.Marshal(seriesData)
+		// sd, _ := json.Marshal(queryResponse)
+		// logrus.Debugf("Retrieved series data: %s", sd)
+	}
+	return queryResults, nil
+}
+
+// attachFiredAlerts attaches the prometheus alerts which fired during the load test to the result
+func (h *Handler) attachFiredAlerts(result *models.MesheryResult, prom *models.Prometheus, resultInst *periodic.RunnerResults) {
+	ctx, cancel := context.WithTimeout(context.Background(), models.MaxAlertsGracePeriod+firedAlertsTimeout)
+	defer cancel()
+	alerts, err := h.config.PrometheusClient.WaitForFiredAlerts(ctx, prom, resultInst.StartTime, resultInst.StartTime.Add(resultInst.ActualDuration))
+	if err != nil {
+		logrus.Warn(errors.Wrap(err, "unable to get the prometheus alerts fired during the load test"))
+		return
+	}
+	result.Alerts = alerts
+	result.AlertsFired = len(alerts) > 0
+}
+
+// metricsPrometheus returns the prometheus the server metrics are collected from. The one reached through the
+// kubernetes API is taken from the session of the owner of the load test, for its kubeconfig to be the current one.
+func (h *Handler) metricsPrometheus(config *models.SubmitMetricsConfig) *models.Prometheus {
+	prom := &models.Prometheus{
+		PrometheusURL: config.PromURL,
+		Auth:          config.PromAuth,
+	}
+	if config.UserID == "" {
+		return prom
+	}
+	sessObj, err := h.config.SessionPersister.Read(config.UserID)
+	if err != nil || sessObj == nil || sessObj.Prometheus == nil || sessObj.Prometheus.PrometheusURL != config.PromURL ||
+		sessObj.Prometheus.KubernetesService == nil {
+		return prom
+	}
+	return h.prometheus(sessObj)
+}
+
+// queryBoards runs the queries of each of the boards, the results being keyed by board then by query
+func (h *Handler) queryBoards(ctx context.Context, prom *models.Prometheus, config *models.SubmitMetricsConfig, boards map[string]*models.GrafanaBoard) (map[string]interface{}, error) {
+	boardResults := map[string]interface{}{}
+	for key, board := range boards {
+		queryResults, err := h.queryServerMetrics(ctx, prom, config, models.BoardStepOptions(board))
+		if err != nil {
+			return nil, err
+		}
+		boardResults[key] = queryResults
+	}
+	return boardResults, nil
+}
+
+// promQuery returns the function running the instant queries of the SLO assertions, nil when prometheus is not set
+func (h *Handler) promQuery(prom *models.Prometheus) helpers.PromQueryFunc {
+	if prom == nil || prom.PrometheusURL == "" {
+		return nil
+	}
+	return func(ctx context.Context, query string, ts time.Time) (promModel.Value, error) {
+		return h.config.PrometheusClient.QueryUsingClient(ctx, prom, query, ts)
+	}
+}
+
+// firedAlerts returns the function getting the alerts fired during the probes of a throughput discovery, nil when
+// prometheus is not set
+func (h *Handler) firedAlerts(prom *models.Prometheus) helpers.FiredAlertsFunc {
+	if prom == nil || prom.PrometheusURL == "" {
+		return nil
+	}
+	return func(ctx context.Context, start, end time.Time) ([]*models.PrometheusAlert, error) {
+		ctx, cancel := context.WithTimeout(ctx, models.MaxAlertsGracePeriod+firedAlertsTimeout)
+		defer cancel()
+		return h.config.PrometheusClient.WaitForFiredAlerts(ctx, prom, start, end)
+	}
+}
+
 // CollectStaticMetrics is used for collecting static metrics from prometheus and submitting it to SaaS
 func (h *Handler) CollectStaticMetrics(config *models.SubmitMetricsConfig) error {
 	logrus.Debugf("initiating collecting prometheus static board metrics for test id: %s", config.TestUUID)
 	ctx := context.Background()
-	queries := h.config.QueryTracker.GetQueriesForUUID(ctx, config.TestUUID)
-	queryResults := map[string]map[string]interface{}{}
-	step := h.config.PrometheusClient.ComputeStep(ctx, config.StartTime, config.EndTime)
-	for query, flag := range queries {
-		if !flag {
-			seriesData, err := h.config.PrometheusClient.QueryRangeUsingClient(ctx, config.PromURL, query, config.StartTime, config.EndTime, step)
-			if err != nil {
-				return err
-			}
-			queryResults[query] = map[string]interface{}{
-				"status": "success",
-				"data": map[string]interface{}{
-					"resultType": seriesData.Type(),
-					"result":     seriesData,
-				},
-			}
-			// sd, _ := json.Marshal(seriesData)
-			// sd, _ := json.Marshal(queryResponse)
-			// logrus.Debugf("Retrieved series data: %s", sd)
-			h.config.QueryTracker.AddOrFlagQuery(ctx, config.TestUUID, query, true)
-		}
+	// the queries run from the UI use the default step options
+	queries := map[string]*models.StepOptions{}
+	for query := range h.config.QueryTracker.GetQueriesForUUID(ctx, config.TestUUID) {
+		queries[query] = nil
 	}
-
-	board, err := h.config.PrometheusClient.GetClusterStaticBoard(ctx, config.PromURL)
+	// the queries saved with the task survive a restart of Meshery
+	for _, query := range config.Queries {
+		queries[query] = nil
+	}
+	// all the queries are run on each attempt, as the results of a failed attempt are not kept
+	prom := h.metricsPrometheus(config)
+	queryResults, err := h.queryServerMetrics(ctx, prom, config, queries)
 	if err != nil {
 		return err
 	}
-	// TODO: we are NOT persisting the Node metrics for now
+	for query := range queries {
+		h.config.QueryTracker.AddOrFlagQuery(ctx, config.TestUUID, query, true)
+	}
 
-	resultUUID, err := uuid.FromString(config.ResultID)
+	board, err := h.config.PrometheusClient.GetClusterStaticBoard(ctx, prom)
 	if err != nil {
-		logrus.Error(errors.Wrap(err, "error parsing result uuid"))
 		return err
 	}
-	result := &models.MesheryResult{
-		ID:                resultUUID,
-		ServerMetrics:     queryResults,
-		ServerBoardConfig: board,
+
+	serverMetrics := map[string]interface{}{}
+	for query, res := range queryResults {
+		serverMetrics[query] = res
+	}
+	// the node metrics need node exporter, they are skipped when it is not there
+	nodeBoards, err := h.config.PrometheusClient.GetNodeStaticBoards(ctx, prom, config.StartTime, config.EndTime)
+	if err != nil {
+		logrus.Warn(errors.Wrap(err, "unable to get the nodes static boards, skipping the node metrics"))
+	} else if len(nodeBoards) > 0 {
+		if serverMetrics[models.ServerMetricsNodesKey], err = h.queryBoards(ctx, prom, config, nodeBoards); err != nil {
+			return err
+		}
 	}
-	sd, err := json.Marshal(result)
+	meshBoards, err := h.config.PrometheusClient.GetMeshStaticBoards(ctx, config.Meshes)
 	if err != nil {
-		logrus.Error(errors.Wrap(err, "error - unable to marshal meshery metrics for shipping"))
 		return err
 	}
+	if len(meshBoards) > 0 {
+		if serverMetrics[models.ServerMetricsMeshesKey], err = h.queryBoards(ctx, prom, config, meshBoards); err != nil {
+			return err
+		}
+	}
 
-	logrus.Debugf("Result: %s, size: %d", sd, len(sd))
+	if config.ResultID != "" && h.config.ResultPersister != nil {
+		resultUUID, err := uuid.FromString(config.ResultID)
+		if err != nil {
+			logrus.Error(errors.Wrap(err, "error parsing result uuid"))
+			return err
+		}
+		result, err := h.config.ResultPersister.GetResult(resultUUID)
+		if err != nil {
+			logrus.Error(errors.Wrap(err, "error - unable to read the result from the local store"))
+			return err
+		}
+		result.ServerMetrics = serverMetrics
+		result.ServerBoardConfig = board
+		result.NodeBoardConfigs = nodeBoards
+		result.MeshBoardConfigs = meshBoards
+		if err = h.config.ResultPersister.WriteResult(resultUUID, result); err != nil {
+			logrus.Error(errors.Wrap(err, "error - unable to persist meshery metrics in the local store"))
+			return err
+		}
+	}
 
-	if err = h.publishMetricsToSaaS(config.TokenKey, config.TokenVal, sd); err != nil {
-		return err
+	if config.SaaSResultID != "" {
+		resultUUID, err := uuid.FromString(config.SaaSResultID)
+		if err != nil {
+			logrus.Error(errors.Wrap(err, "error parsing result uuid"))
+			return err
+		}
+		result := &models.MesheryResult{
+			ID:                resultUUID,
+			ServerMetrics:     serverMetrics,
+			ServerBoardConfig: board,
+			NodeBoardConfigs:  nodeBoards,
+			MeshBoardConfigs:  meshBoards,
+		}
+		sd, err := json.Marshal(result)
+		if err != nil {
+			logrus.Error(errors.Wrap(err, "error - unable to marshal meshery metrics for shipping"))
+			return err
+		}
+
+		logrus.Debugf("Result: %s, size: %d", sd, len(sd))
+
+		// the token is not persisted with the task, a task resumed after a restart has to be retried by a logged in user
+		if config.TokenVal == "" {
+			return errNoSaaSToken
+		}
+
+		if err = h.publishMetricsToSaaS(config.TokenKey, config.TokenVal, sd); err != nil {
+			return err
+		}
 	}
 	// now to remove all the queries for the uuid
 	h.config.QueryTracker.RemoveUUID(ctx, config.TestUUID)
 	return nil
 }
 
+// persistResult stores the result in the local result store, when one is configured, and publishes it to SaaS.
+// Without a local store SaaS is the only store, so failing to publish is an error. Otherwise SaaS is an
+// optional sync target and publishing is only attempted when enabled; failures are logged and ignored.
+func (h *Handler) persistResult(tokenVal, userID string, result *models.MesheryResult) (string, string, error) {
+	var resultID, saasResultID string
+	if h.config.ResultPersister != nil {
+		resultUUID, err := uuid.NewV4()
+		if err != nil {
+			err = errors.Wrap(err, "unable to generate a result id")
+			logrus.Error(err)
+			return "", "", err
+		}
+		result.ID = resultUUID
+		result.UserID = userID
+		if err = h.config.ResultPersister.WriteResult(resultUUID, result); err != nil {
+			err = errors.Wrap(err, "unable to persist the result in the local store")
+			logrus.Error(err)
+			return "", "", err
+		}
+		resultID = resultUUID.String()
+		if !h.config.SyncResultsWithSaaS {
+			return resultID, "", nil
+		}
+	}
+
+	// TODO: can we do something to prevent marshalling twice??
+	bd, err := json.Marshal(result)
+	if err != nil {
+		err = errors.Wrap(err, "unable to marshal meshery result for shipping")
+		logrus.Error(err)
+		return "", "", err
+	}
+
+	saasResultID, err = h.publishResultsToSaaS(h.config.SaaSTokenName, tokenVal, bd)
+	if err != nil {
+		if h.config.ResultPersister != nil {
+			logrus.Warnf("unable to sync the result with SaaS, it is only available locally: %v", err)
+			return resultID, "", nil
+		}
+		return "", "", err
+	}
+	return resultID, saasResultID, nil
+}
+
 func (h *Handler) publishMetricsToSaaS(tokenKey, tokenVal string, bd []byte) error {
 	logrus.Infof("attempting to publish metrics to SaaS")
 	bf := bytes.NewBuffer(bd)
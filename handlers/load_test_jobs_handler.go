@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/gorilla/sessions"
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// LoadTestJobsHandler lists the jobs of the user in the load test queue, or returns the one with the given id
+func (h *Handler) LoadTestJobsHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, user *models.User) {
+	if req.Method != http.MethodGet {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if h.config.LoadTestQueue == nil {
+		http.Error(w, "the load test queue is not available", http.StatusNotImplemented)
+		return
+	}
+	var data interface{}
+	if id := req.URL.Query().Get("id"); id != "" {
+		job, ok := h.config.LoadTestQueue.GetJob(id)
+		// the jobs of the other users are reported as missing
+		if !ok || job.UserID != user.UserID {
+			http.Error(w, "no load test job found for the given id", http.StatusNotFound)
+			return
+		}
+		data = job
+	} else {
+		data = h.config.LoadTestQueue.GetJobs(user.UserID)
+	}
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		logrus.Errorf("error marshalling load test jobs: %v", err)
+		http.Error(w, "unable to marshal the load test jobs", http.StatusInternalServerError)
+	}
+}
+
+// queueLoadTest calls run once the load test queue lets the load test start, reporting its position in the queue on
+// respChan meanwhile. It returns when run does or when ctx is done before the load test started.
+func (h *Handler) queueLoadTest(ctx context.Context, job *models.LoadTestJob, respChan chan *models.LoadTestResponse, run func()) {
+	if h.config.LoadTestQueue == nil {
+		run()
+		return
+	}
+	done := make(chan struct{})
+	err := h.config.LoadTestQueue.Enqueue(job, func() {
+		defer close(done)
+		run()
+	}, func(position int) {
+		// the queue is locked, the position is dropped rather than waiting for the client
+		select {
+		case respChan <- &models.LoadTestResponse{
+			Status:   models.LoadTestQueued,
+			Message:  fmt.Sprintf("Waiting for other load tests to complete, position %d in the queue", position),
+			RunID:    job.ID,
+			Position: position,
+		}:
+		default:
+		}
+	})
+	if err != nil {
+		logrus.Error(errors.Wrap(err, "unable to queue the load test"))
+		respChan <- &models.LoadTestResponse{
+			Status:  models.LoadTestError,
+			Message: "error: unable to queue the load test",
+		}
+		return
+	}
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		if h.config.LoadTestQueue.Remove(job.ID) {
+			respChan <- &models.LoadTestResponse{
+				Status:  models.LoadTestError,
+				Message: "Load test cancelled before it started",
+			}
+			return
+		}
+		// the load test started already, it stops on its own now that ctx is done
+		<-done
+	}
+}
+
+// loadTestTarget returns the host and port of the URL of the load test
+func loadTestTarget(loadTestOptions *models.LoadTestOptions) string {
+	u, err := url.Parse(loadTestOptions.URL)
+	if err != nil || u.Host == "" {
+		return loadTestOptions.URL
+	}
+	return u.Host
+}
@@ -0,0 +1,358 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/gorilla/sessions"
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+	"github.com/robfig/cron/v3"
+	"github.com/sirupsen/logrus"
+	"github.com/vmihailenco/taskq"
+)
+
+// maxScheduleHistory is the number of runs kept in the history of a schedule
+const maxScheduleHistory = 50
+
+// LoadTestSchedulesHandler manages the scheduled load tests: GET lists them or returns the one with the given id,
+// POST creates one, PUT updates the one with the given id and DELETE removes it.
+// Load tests are described with the same parameters as the ones of the load test endpoint, along with the cron
+// schedule and whether the schedule is enabled.
+func (h *Handler) LoadTestSchedulesHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, user *models.User) {
+	if h.config.SchedulePersister == nil {
+		http.Error(w, "load test schedules are not available", http.StatusNotImplemented)
+		return
+	}
+	switch req.Method {
+	case http.MethodGet:
+		h.getLoadTestSchedules(w, req, user)
+	case http.MethodPost, http.MethodPut:
+		h.saveLoadTestSchedule(w, req, user)
+	case http.MethodDelete:
+		h.deleteLoadTestSchedule(w, req, user)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (h *Handler) getLoadTestSchedules(w http.ResponseWriter, req *http.Request, user *models.User) {
+	var data interface{}
+	if req.URL.Query().Get("id") != "" {
+		schedule, status, err := h.readLoadTestSchedule(req, user)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+		data = schedule.Redacted()
+	} else {
+		schedules, err := h.config.SchedulePersister.GetSchedules(user.UserID)
+		if err != nil {
+			http.Error(w, "error while getting load test schedules", http.StatusInternalServerError)
+			return
+		}
+		// the secrets of the load tests are only used to run them, they are never sent back
+		for i, schedule := range schedules {
+			schedules[i] = schedule.Redacted()
+		}
+		data = schedules
+	}
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		logrus.Errorf("error marshalling load test schedules: %v", err)
+		http.Error(w, "unable to marshal the load test schedules", http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) saveLoadTestSchedule(w http.ResponseWriter, req *http.Request, user *models.User) {
+	schedule := &models.LoadTestSchedule{}
+	if req.Method == http.MethodPut {
+		var (
+			status int
+			err    error
+		)
+		schedule, status, err = h.readLoadTestSchedule(req, user)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+	}
+
+	loadTestOptions, err := parseLoadTestOptions(req)
+	if err != nil {
+		logrus.Errorf("Error: invalid load test options: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	q := req.URL.Query()
+	spec := q.Get("schedule")
+	if _, err = cron.ParseStandard(spec); err != nil {
+		logrus.Errorf("Error: invalid schedule %s: %v", spec, err)
+		http.Error(w, fmt.Sprintf("invalid schedule, expecting a cron expression: %v", err), http.StatusBadRequest)
+		return
+	}
+	enabled := true
+	if e := q.Get("enabled"); e != "" {
+		if enabled, err = strconv.ParseBool(e); err != nil {
+			http.Error(w, "invalid value for enabled", http.StatusBadRequest)
+			return
+		}
+	}
+
+	now := time.Now()
+	if schedule.ID == uuid.Nil {
+		if schedule.ID, err = uuid.NewV4(); err != nil {
+			logrus.Errorf("Error: unable to generate a schedule id: %v", err)
+			http.Error(w, "unable to save the load test schedule", http.StatusInternalServerError)
+			return
+		}
+		schedule.UserID = user.UserID
+		schedule.CreatedAt = now
+	}
+	schedule.Name = loadTestOptions.Name
+	schedule.Mesh = q.Get("mesh")
+	schedule.Schedule = spec
+	schedule.Enabled = enabled
+	schedule.Options = loadTestOptions
+	schedule.UpdatedAt = now
+
+	if err = h.scheduleNextLoadTest(schedule); err != nil {
+		http.Error(w, "unable to schedule the load test", http.StatusInternalServerError)
+		return
+	}
+	if err = h.config.SchedulePersister.WriteSchedule(schedule.ID, schedule); err != nil {
+		logrus.Error(errors.Wrap(err, "unable to persist the load test schedule"))
+		http.Error(w, "unable to save the load test schedule", http.StatusInternalServerError)
+		return
+	}
+
+	if req.Method == http.MethodPost {
+		w.WriteHeader(http.StatusCreated)
+	}
+	if err = json.NewEncoder(w).Encode(schedule.Redacted()); err != nil {
+		logrus.Errorf("error marshalling the load test schedule: %v", err)
+	}
+}
+
+func (h *Handler) deleteLoadTestSchedule(w http.ResponseWriter, req *http.Request, user *models.User) {
+	schedule, status, err := h.readLoadTestSchedule(req, user)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	if err = h.config.SchedulePersister.DeleteSchedule(schedule.ID); err != nil {
+		logrus.Error(errors.Wrap(err, "unable to delete the load test schedule"))
+		http.Error(w, "unable to delete the load test schedule", http.StatusInternalServerError)
+		return
+	}
+	h.setNextScheduledRun(schedule.ID.String(), "")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// readLoadTestSchedule reads the schedule with the id given in the request, which has to belong to the user.
+// On failure the http status to respond with is returned along with the error.
+func (h *Handler) readLoadTestSchedule(req *http.Request, user *models.User) (*models.LoadTestSchedule, int, error) {
+	id, err := uuid.FromString(req.URL.Query().Get("id"))
+	if err != nil {
+		return nil, http.StatusBadRequest, errors.New("invalid schedule id")
+	}
+	schedule, err := h.config.SchedulePersister.GetSchedule(id)
+	if err != nil || schedule.UserID != user.UserID {
+		return nil, http.StatusNotFound, errors.New("load test schedule not found")
+	}
+	return schedule, http.StatusOK, nil
+}
+
+// ScheduleLoadTests schedules the next runs of all the enabled load test schedules, it is meant to be called on startup
+func (h *Handler) ScheduleLoadTests() {
+	if h.config.SchedulePersister == nil {
+		return
+	}
+	schedules, err := h.config.SchedulePersister.GetSchedules("")
+	if err != nil {
+		logrus.Error(errors.Wrap(err, "unable to read the load test schedules"))
+		return
+	}
+	for _, schedule := range schedules {
+		if err = h.scheduleNextLoadTest(schedule); err != nil {
+			continue
+		}
+		if err = h.config.SchedulePersister.WriteSchedule(schedule.ID, schedule); err != nil {
+			logrus.Error(errors.Wrapf(err, "unable to persist the load test schedule %s", schedule.ID))
+		}
+	}
+}
+
+// scheduleNextLoadTest queues the next run of the schedule, replacing the one which might already be queued.
+// The caller is responsible for persisting the updated next run time of the schedule.
+func (h *Handler) scheduleNextLoadTest(schedule *models.LoadTestSchedule) error {
+	id := schedule.ID.String()
+	if !schedule.Enabled {
+		schedule.NextRun = time.Time{}
+		h.setNextScheduledRun(id, "")
+		return nil
+	}
+	cronSchedule, err := cron.ParseStandard(schedule.Schedule)
+	if err != nil {
+		err = errors.Wrapf(err, "invalid schedule for %s", id)
+		logrus.Error(err)
+		return err
+	}
+	token, err := uuid.NewV4()
+	if err != nil {
+		err = errors.Wrap(err, "unable to generate a schedule token")
+		logrus.Error(err)
+		return err
+	}
+
+	schedule.NextRun = cronSchedule.Next(time.Now())
+	h.setNextScheduledRun(id, token.String())
+	msg := taskq.NewMessage(id, token.String())
+	msg.Delay = time.Until(schedule.NextRun)
+	if err = h.scheduleTask.AddMessage(msg); err != nil {
+		err = errors.Wrapf(err, "unable to queue the next run of %s", id)
+		logrus.Error(err)
+		return err
+	}
+	logrus.Debugf("next run of the load test schedule %s at %v", id, schedule.NextRun)
+	return nil
+}
+
+// setNextScheduledRun records the token of the queued run of the schedule, an empty token meaning none.
+// Queued runs can not be removed from the queue, so the runs whose token is not the current one are skipped.
+func (h *Handler) setNextScheduledRun(scheduleID, token string) {
+	h.nextRunsLock.Lock()
+	defer h.nextRunsLock.Unlock()
+	if token == "" {
+		delete(h.nextRuns, scheduleID)
+		return
+	}
+	h.nextRuns[scheduleID] = token
+}
+
+// RunScheduledLoadTest queues the next run of the schedule and starts the current one, recording it in the history of
+// the schedule once it is done
+func (h *Handler) RunScheduledLoadTest(scheduleID, token string) error {
+	h.nextRunsLock.Lock()
+	current := h.nextRuns[scheduleID] == token
+	if current {
+		delete(h.nextRuns, scheduleID)
+	}
+	h.nextRunsLock.Unlock()
+	if !current {
+		logrus.Debugf("skipping a stale run of the load test schedule %s", scheduleID)
+		return nil
+	}
+
+	id, err := uuid.FromString(scheduleID)
+	if err != nil {
+		logrus.Error(errors.Wrap(err, "error parsing schedule uuid"))
+		return nil
+	}
+	schedule, err := h.config.SchedulePersister.GetSchedule(id)
+	if err != nil {
+		logrus.Error(errors.Wrapf(err, "unable to read the load test schedule %s", scheduleID))
+		return nil
+	}
+	// queue the next run first, so that it is not delayed by this one
+	_ = h.scheduleNextLoadTest(schedule)
+	if err = h.config.SchedulePersister.WriteSchedule(id, schedule); err != nil {
+		logrus.Error(errors.Wrapf(err, "unable to persist the load test schedule %s", scheduleID))
+	}
+
+	// the load test waits in the load test queue and lasts as long as it was asked to, so it must not hold the
+	// worker of the task queue
+	go h.recordLoadTestScheduleRun(id, schedule)
+	return nil
+}
+
+// recordLoadTestScheduleRun runs the load test of the schedule and records the run in its history
+func (h *Handler) recordLoadTestScheduleRun(id uuid.UUID, schedule *models.LoadTestSchedule) {
+	scheduleID := id.String()
+	run := h.runLoadTestSchedule(schedule)
+
+	// the schedule may have changed in the meantime
+	schedule, err := h.config.SchedulePersister.GetSchedule(id)
+	if err != nil {
+		logrus.Warnf("the load test schedule %s is gone, not recording its run", scheduleID)
+		return
+	}
+	schedule.History = append(schedule.History, run)
+	if len(schedule.History) > maxScheduleHistory {
+		schedule.History = schedule.History[len(schedule.History)-maxScheduleHistory:]
+	}
+	// failed load tests are recorded in the history, they are not retried
+	if err = h.config.SchedulePersister.WriteSchedule(id, schedule); err != nil {
+		logrus.Error(errors.Wrapf(err, "unable to record the run of the load test schedule %s", scheduleID))
+	}
+}
+
+// runLoadTestSchedule runs the load test of the schedule on behalf of its owner.
+// The run is tracked with the schedule id, so it can be cancelled like any other load test and does not overlap
+// with a previous run which is still going on.
+func (h *Handler) runLoadTestSchedule(schedule *models.LoadTestSchedule) *models.LoadTestScheduleRun {
+	run := &models.LoadTestScheduleRun{
+		StartTime: time.Now(),
+	}
+	defer func() {
+		run.EndTime = time.Now()
+	}()
+
+	runID := schedule.ID.String()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := h.config.LoadTestTracker.AddRun(ctx, runID, schedule.UserID, cancel); err != nil {
+		run.Status = models.LoadTestError
+		run.Message = "skipped, the previous run is still going on"
+		return run
+	}
+	defer h.config.LoadTestTracker.RemoveRun(ctx, runID)
+
+	sessObj, err := h.config.SessionPersister.Read(schedule.UserID)
+	if err != nil || sessObj == nil {
+		logrus.Warnf("unable to read the session of the owner of the load test schedule %s", runID)
+		sessObj = &models.Session{}
+	}
+
+	// each run is a test of its own, so its results and metrics are not mixed up with the ones of the other runs
+	testUUID, err := uuid.NewV4()
+	if err != nil {
+		run.Status = models.LoadTestError
+		run.Message = "unable to generate a load test id"
+		logrus.Error(errors.Wrap(err, run.Message))
+		return run
+	}
+
+	logrus.Infof("running the scheduled load test %s", runID)
+	respChan := make(chan *models.LoadTestResponse, 100)
+	job := &models.LoadTestJob{
+		ID:     runID,
+		UserID: schedule.UserID,
+		Name:   schedule.Name,
+		Target: loadTestTarget(schedule.Options),
+	}
+	go func() {
+		h.queueLoadTest(ctx, job, respChan, func() {
+			// scheduled runs are not tied to a SaaS session, so their results are only stored locally
+			h.executeLoadTest(ctx, schedule.Name, schedule.Mesh, "", testUUID.String(), runID, schedule.UserID, sessObj, schedule.Options, nil, respChan)
+		})
+		close(respChan)
+	}()
+	for resp := range respChan {
+		switch resp.Status {
+		case models.LoadTestError:
+			run.Status = resp.Status
+			run.Message = resp.Message
+		case models.LoadTestSuccess:
+			run.Status = resp.Status
+			if resp.Result != nil && resp.Result.ID != uuid.Nil {
+				run.ResultID = resp.Result.ID.String()
+			}
+		}
+	}
+	return run
+}
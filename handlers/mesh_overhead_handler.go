@@ -0,0 +1,319 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"fortio.org/fortio/periodic"
+	"github.com/gofrs/uuid"
+	"github.com/gorilla/sessions"
+	"github.com/layer5io/meshery/helpers"
+	"github.com/layer5io/meshery/meshes"
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	k8slabels "k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	defaultInjectionLabel = "istio-injection=enabled"
+	defaultReadyTimeout   = 5 * time.Minute
+)
+
+// meshOverheadOptions are the options of a mesh overhead benchmark besides the ones of the load test
+type meshOverheadOptions struct {
+	adapter      string
+	sampleApp    string
+	namespace    string
+	selector     string
+	labels       map[string]string
+	annotations  map[string]string
+	readyTimeout time.Duration
+	cleanup      bool
+}
+
+// MeshOverheadHandler measures the overhead of a service mesh: it deploys a sample application through the adapter in
+// a namespace it creates, load tests it without sidecars, enables the sidecar injection, restarts it and load tests it again.
+// The result of the second run is persisted along with its comparison to the first one.
+func (h *Handler) MeshOverheadHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, user *models.User) {
+	if req.Method != http.MethodPost {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	tokenVal, _ := session.Values[h.config.SaaSTokenName].(string)
+	loadTestOptions, err := parseLoadTestOptions(req)
+	if err != nil {
+		logrus.Errorf("Error: invalid load test options: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	overheadOptions, err := parseMeshOverheadOptions(req)
+	if err != nil {
+		logrus.Errorf("Error: invalid mesh overhead options: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	q := req.URL.Query()
+	testName := loadTestOptions.Name
+	meshName := q.Get("mesh")
+	testUUID := q.Get("uuid")
+
+	workerAddrs, err := h.parseLoadTestWorkers(q.Get("workers"))
+	if err != nil {
+		logrus.Errorf("Error: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sessObj, err := h.config.SessionPersister.Read(user.UserID)
+	if err != nil {
+		logrus.Warn("Unable to read session from the session persister. Starting a new session.")
+	}
+	if sessObj == nil {
+		sessObj = &models.Session{}
+	}
+	if sessObj.K8SConfig == nil || !sessObj.K8SConfig.InClusterConfig && len(sessObj.K8SConfig.Config) == 0 {
+		logrus.Error("No valid kubernetes config found.")
+		http.Error(w, `No valid kubernetes config found.`, http.StatusBadRequest)
+		return
+	}
+	adapterFound := false
+	for _, ad := range sessObj.MeshAdapters {
+		if ad.Location == overheadOptions.adapter {
+			adapterFound = true
+		}
+	}
+	if !adapterFound {
+		logrus.Errorf("Error: unable to find the adapter %s", overheadOptions.adapter)
+		http.Error(w, "Unable to find a valid adapter for the given adapter URL.", http.StatusBadRequest)
+		return
+	}
+
+	runID := testUUID
+	if runID == "" {
+		runUUID, err := uuid.NewV4()
+		if err != nil {
+			logrus.Errorf("Error: unable to generate a load test id: %v", err)
+			http.Error(w, "error while running load test", http.StatusInternalServerError)
+			return
+		}
+		runID = runUUID.String()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	if err = h.config.LoadTestTracker.AddRun(ctx, runID, user.UserID, cancel); err != nil {
+		cancel()
+		logrus.Errorf("Error: unable to start the load test: %v", err)
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	runStarted := false
+	defer func() {
+		if !runStarted {
+			h.config.LoadTestTracker.RemoveRun(ctx, runID)
+			cancel()
+		}
+	}()
+
+	job := &models.LoadTestJob{
+		ID:     runID,
+		UserID: user.UserID,
+		Name:   testName,
+		Target: loadTestTarget(loadTestOptions),
+	}
+	runStarted = h.streamLoadTest(ctx, cancel, w, runID, func(respChan chan *models.LoadTestResponse) {
+		h.queueLoadTest(ctx, job, respChan, func() {
+			h.executeMeshOverhead(ctx, testName, meshName, tokenVal, testUUID, runID, user, sessObj, overheadOptions, loadTestOptions, workerAddrs, respChan)
+		})
+	})
+}
+
+// executeMeshOverhead runs the mesh overhead benchmark, then persists its result
+func (h *Handler) executeMeshOverhead(ctx context.Context, testName, meshName, tokenVal, testUUID, runID string, user *models.User, sessObj *models.Session, overheadOptions *meshOverheadOptions, loadTestOptions *models.LoadTestOptions, workerAddrs []string, respChan chan *models.LoadTestResponse) {
+	respChan <- &models.LoadTestResponse{
+		Status:  models.LoadTestInfo,
+		Message: "Initiating mesh overhead benchmark . . . ",
+		RunID:   runID,
+	}
+	result, resultInst, err := h.runMeshOverhead(ctx, user, sessObj, overheadOptions, loadTestOptions, workerAddrs, respChan)
+	if err != nil {
+		msg := "error: unable to perform the mesh overhead benchmark"
+		err = errors.Wrap(err, msg)
+		logrus.Error(err)
+		respChan <- &models.LoadTestResponse{
+			Status:  models.LoadTestError,
+			Message: err.Error(),
+		}
+		return
+	}
+	result.Name = testName
+	result.Mesh = meshName
+	h.completeLoadTest(tokenVal, testUUID, user.UserID, sessObj, loadTestOptions, result, resultInst, respChan)
+}
+
+func parseMeshOverheadOptions(req *http.Request) (*meshOverheadOptions, error) {
+	q := req.URL.Query()
+	o := &meshOverheadOptions{
+		adapter:      q.Get("adapter"),
+		sampleApp:    q.Get("sampleApp"),
+		namespace:    q.Get("namespace"),
+		selector:     q.Get("selector"),
+		labels:       map[string]string{},
+		annotations:  map[string]string{},
+		readyTimeout: defaultReadyTimeout,
+		cleanup:      strings.ToLower(q.Get("cleanup")) == "true",
+	}
+	if o.adapter == "" {
+		return nil, errors.New("provide the adapter to deploy the sample application with")
+	}
+	if o.sampleApp == "" {
+		return nil, errors.New("provide the operation of the adapter deploying the sample application")
+	}
+	// the namespace is created for the benchmark, the sidecar injection being turned on for all its workloads
+	if o.namespace == "" {
+		return nil, errors.New("provide a namespace dedicated to the benchmark, which must not exist yet")
+	}
+	// only the sample application is restarted and waited for
+	if o.selector == "" {
+		return nil, errors.New("provide the label selector of the deployments and pods of the sample application")
+	}
+	if _, err := k8slabels.Parse(o.selector); err != nil {
+		return nil, errors.Wrap(err, "invalid label selector")
+	}
+
+	label := q.Get("injectionLabel")
+	if label == "" {
+		label = defaultInjectionLabel
+	}
+	if err := parseKeyValue(label, o.labels); err != nil {
+		return nil, errors.Wrap(err, "invalid injection label")
+	}
+	if annotation := q.Get("injectionAnnotation"); annotation != "" {
+		if err := parseKeyValue(annotation, o.annotations); err != nil {
+			return nil, errors.Wrap(err, "invalid injection annotation")
+		}
+	}
+
+	if t := q.Get("readyTimeout"); t != "" {
+		timeout, err := time.ParseDuration(t)
+		if err != nil || timeout <= 0 {
+			return nil, errors.Errorf("invalid ready timeout %s", t)
+		}
+		o.readyTimeout = timeout
+	}
+	return o, nil
+}
+
+// parseKeyValue adds the key=value pair to m
+func parseKeyValue(s string, m map[string]string) error {
+	kv := strings.SplitN(s, "=", 2)
+	if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+		return errors.Errorf("%s is not of the form key=value", s)
+	}
+	m[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	return nil
+}
+
+// runMeshOverhead runs the load test on the sample application without and with sidecars and returns the result of
+// the second run, holding the comparison with the first one
+func (h *Handler) runMeshOverhead(ctx context.Context, user *models.User, sessObj *models.Session, o *meshOverheadOptions, loadTestOptions *models.LoadTestOptions, workerAddrs []string, respChan chan *models.LoadTestResponse) (*models.MesheryResult, *periodic.RunnerResults, error) {
+	info := func(msg string) {
+		respChan <- &models.LoadTestResponse{
+			Status:  models.LoadTestInfo,
+			Message: msg,
+		}
+	}
+	k8sConfig, contextName := sessObj.K8SConfig.Config, sessObj.K8SConfig.ContextName
+
+	mClient, err := meshes.CreateClient(ctx, k8sConfig, contextName, o.adapter)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "unable to create a mesh client")
+	}
+	defer func() {
+		_ = mClient.Close()
+	}()
+	applySampleApp := func(ctx context.Context, deleteOp bool) error {
+		operationID, err := uuid.NewV4()
+		if err != nil {
+			return errors.Wrap(err, "unable to generate an operation id")
+		}
+		_, err = mClient.MClient.ApplyOperation(ctx, &meshes.ApplyRuleRequest{
+			OperationId: operationID.String(),
+			OpName:      o.sampleApp,
+			Username:    user.UserID,
+			Namespace:   o.namespace,
+			DeleteOp:    deleteOp,
+		})
+		return err
+	}
+	waitForPods := func() (float64, error) {
+		waitCtx, cancel := context.WithTimeout(ctx, o.readyTimeout)
+		defer cancel()
+		return helpers.WaitForPodsReady(waitCtx, k8sConfig, contextName, o.namespace, o.selector)
+	}
+
+	info(fmt.Sprintf("Creating the namespace %s", o.namespace))
+	if err = helpers.CreateNamespace(k8sConfig, contextName, o.namespace); err != nil {
+		return nil, nil, err
+	}
+	if o.cleanup {
+		defer func() {
+			// the load test may have been cancelled, the sample application is deleted anyway
+			cleanupCtx, cancel := context.WithTimeout(context.Background(), o.readyTimeout)
+			defer cancel()
+			if err := applySampleApp(cleanupCtx, true); err != nil {
+				logrus.Errorf("Error: unable to delete the sample application: %v", err)
+			}
+			_ = helpers.DeleteNamespace(k8sConfig, contextName, o.namespace)
+		}()
+	}
+	info(fmt.Sprintf("Deploying the sample application in %s without sidecars", o.namespace))
+	if err = applySampleApp(ctx, false); err != nil {
+		return nil, nil, errors.Wrap(err, "unable to deploy the sample application")
+	}
+	baselineContainers, err := waitForPods()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	info("Running the load test without sidecars")
+	baselineMap, _, err := h.runLoadTest(ctx, loadTestOptions, workerAddrs, h.prometheus(sessObj), respChan)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "unable to run the load test without sidecars")
+	}
+
+	info("Enabling the sidecar injection and restarting the sample application")
+	if err = helpers.EnableSidecarInjection(k8sConfig, contextName, o.namespace, o.labels, o.annotations); err != nil {
+		return nil, nil, err
+	}
+	if err = helpers.RestartDeployments(k8sConfig, contextName, o.namespace, o.selector); err != nil {
+		return nil, nil, err
+	}
+	meshContainers, err := waitForPods()
+	if err != nil {
+		return nil, nil, err
+	}
+	if meshContainers <= baselineContainers {
+		return nil, nil, errors.Errorf("no sidecar was injected in the pods of %s matching %s", o.namespace, o.selector)
+	}
+
+	info("Running the load test with sidecars")
+	resultsMap, resultInst, err := h.runLoadTest(ctx, loadTestOptions, workerAddrs, h.prometheus(sessObj), respChan)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "unable to run the load test with sidecars")
+	}
+
+	info("Load tests completed, fetching metadata now")
+	h.addKubernetesMetadata(sessObj, resultsMap)
+	result := &models.MesheryResult{
+		Result: resultsMap,
+	}
+	result.Overhead = helpers.MeshOverhead(&models.MesheryResult{Result: baselineMap}, result)
+	if p99 := result.Overhead.Overhead.Latencies["p99"]; p99 != nil && p99.DeltaPercent != nil {
+		info(fmt.Sprintf("Mesh overhead: p99 latency %+.2f%%", *p99.DeltaPercent))
+	}
+	return result, resultInst, nil
+}
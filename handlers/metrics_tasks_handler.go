@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/gorilla/sessions"
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/vmihailenco/taskq"
+)
+
+// errNoSaaSToken is the reason the metrics tasks which have to be published to SaaS without a token are given up on
+var errNoSaaSToken = errors.New("no SaaS token to publish the server metrics with, retry the metrics dead letter once logged in")
+
+const (
+	metricsTaskRetryLimit = 10
+	metricsTaskMinBackoff = 10 * time.Second
+	metricsTaskMaxBackoff = 10 * time.Minute
+)
+
+// newMetricsTask creates the task collecting the server metrics, which is retried with a backoff when it fails and
+// dead lettered once all its attempts failed. It has a queue of its own, so that its failures do not hold up the
+// other tasks.
+func (h *Handler) newMetricsTask() *taskq.Task {
+	return h.config.MetricsQueue.NewTask(&taskq.TaskOptions{
+		Name:            "submitMetrics",
+		Handler:         h.runMetricsTask,
+		FallbackHandler: h.deadLetterMetricsTask,
+		RetryLimit:      metricsTaskRetryLimit,
+		MinBackoff:      metricsTaskMinBackoff,
+		MaxBackoff:      metricsTaskMaxBackoff,
+	})
+}
+
+// submitMetrics persists the metrics task before queuing it, so that it is resumed after a restart.
+// The credentials are not persisted, the task being resumed with the ones of the session of the owner of the load test.
+func (h *Handler) submitMetrics(config *models.SubmitMetricsConfig) {
+	for query := range h.config.QueryTracker.GetQueriesForUUID(context.Background(), config.TestUUID) {
+		config.Queries = append(config.Queries, query)
+	}
+	sort.Strings(config.Queries)
+
+	if h.config.MetricsTaskPersister != nil {
+		id, err := uuid.NewV4()
+		if err != nil {
+			logrus.Error(errors.Wrap(err, "error generating a metrics task id"))
+		} else {
+			now := time.Now()
+			config.TaskID = id.String()
+			task := &models.MetricsTask{
+				ID:        id,
+				Config:    config.Redacted(),
+				CreatedAt: now,
+				UpdatedAt: now,
+			}
+			if err = h.config.MetricsTaskPersister.WriteTask(id, task); err != nil {
+				logrus.Error(errors.Wrapf(err, "unable to persist the metrics task of the load test %s", config.TestUUID))
+				config.TaskID = ""
+			}
+		}
+	}
+	if err := h.task.Call(config); err != nil {
+		logrus.Error(errors.Wrapf(err, "unable to queue the metrics task of the load test %s", config.TestUUID))
+	}
+}
+
+// resumeMetricsTasks queues again the metrics tasks which were pending when Meshery stopped
+func (h *Handler) resumeMetricsTasks() {
+	if h.config.MetricsTaskPersister == nil {
+		return
+	}
+	tasks, err := h.config.MetricsTaskPersister.GetTasks()
+	if err != nil {
+		logrus.Error(errors.Wrap(err, "unable to read the pending metrics tasks"))
+		return
+	}
+	for _, task := range tasks {
+		if task.DeadLetter || task.Config == nil {
+			continue
+		}
+		task.Config.TaskID = task.ID.String()
+		if task.Config.TokenVal != "" || task.Config.PromAuth != nil {
+			// persisted before the credentials were left out
+			task.Config = task.Config.Redacted()
+			if err = h.config.MetricsTaskPersister.WriteTask(task.ID, task); err != nil {
+				logrus.Error(errors.Wrapf(err, "unable to remove the credentials from the metrics task %s", task.ID))
+			}
+		}
+		logrus.Infof("resuming the collection of the server metrics of the load test %s", task.Config.TestUUID)
+		if err = h.task.Call(h.withCredentials(task.Config, "")); err != nil {
+			logrus.Error(errors.Wrapf(err, "unable to queue the metrics task %s", task.ID))
+		}
+	}
+}
+
+// runMetricsTask collects the server metrics and records the outcome of the attempt in the persisted task
+func (h *Handler) runMetricsTask(config *models.SubmitMetricsConfig) error {
+	// the token is not persisted with the task, so a task resumed after a restart can not publish the metrics however
+	// many times it is attempted: it is given up on right away, to be retried from the dead letters once logged in
+	if config.SaaSResultID != "" && config.TokenVal == "" {
+		return h.giveUpMetricsTask(config, errNoSaaSToken.Error())
+	}
+	err := h.CollectStaticMetrics(config)
+	task := h.readMetricsTask(config)
+	if task == nil {
+		return err
+	}
+	if err == nil {
+		if err := h.config.MetricsTaskPersister.DeleteTask(task.ID); err != nil {
+			logrus.Error(errors.Wrapf(err, "unable to delete the metrics task %s", task.ID))
+		}
+		return nil
+	}
+	task.Attempts++
+	task.LastError = err.Error()
+	task.UpdatedAt = time.Now()
+	if err := h.config.MetricsTaskPersister.WriteTask(task.ID, task); err != nil {
+		logrus.Error(errors.Wrapf(err, "unable to persist the metrics task %s", task.ID))
+	}
+	return err
+}
+
+// deadLetterMetricsTask is called once all the attempts of the metrics task failed
+func (h *Handler) deadLetterMetricsTask(config *models.SubmitMetricsConfig) error {
+	return h.giveUpMetricsTask(config, "")
+}
+
+// giveUpMetricsTask dead letters the metrics task, recording lastError as the reason when it is not empty
+func (h *Handler) giveUpMetricsTask(config *models.SubmitMetricsConfig, lastError string) error {
+	logrus.Errorf("giving up collecting the server metrics of the load test %s", config.TestUUID)
+	// the queries are saved in the task, the query tracker does not need to keep them
+	h.config.QueryTracker.RemoveUUID(context.Background(), config.TestUUID)
+
+	task := h.readMetricsTask(config)
+	if task == nil {
+		return nil
+	}
+	task.DeadLetter = true
+	if lastError != "" {
+		task.LastError = lastError
+	}
+	task.UpdatedAt = time.Now()
+	return h.config.MetricsTaskPersister.WriteTask(task.ID, task)
+}
+
+func (h *Handler) readMetricsTask(config *models.SubmitMetricsConfig) *models.MetricsTask {
+	if h.config.MetricsTaskPersister == nil || config.TaskID == "" {
+		return nil
+	}
+	id, err := uuid.FromString(config.TaskID)
+	if err != nil {
+		logrus.Error(errors.Wrap(err, "error parsing metrics task uuid"))
+		return nil
+	}
+	task, err := h.config.MetricsTaskPersister.GetTask(id)
+	if err != nil {
+		logrus.Error(errors.Wrapf(err, "unable to read the metrics task %s", id))
+		return nil
+	}
+	return task
+}
+
+// MetricsDeadLettersHandler manages the server metrics collections of the user which were given up on: GET lists
+// them, POST retries the one with the given id and DELETE discards it
+func (h *Handler) MetricsDeadLettersHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, user *models.User) {
+	if h.config.MetricsTaskPersister == nil {
+		http.Error(w, "metrics dead letters are not available", http.StatusNotImplemented)
+		return
+	}
+	switch req.Method {
+	case http.MethodGet:
+		tasks, err := h.config.MetricsTaskPersister.GetTasks()
+		if err != nil {
+			http.Error(w, "error while getting the metrics dead letters", http.StatusInternalServerError)
+			return
+		}
+		deadLetters := []*models.MetricsTask{}
+		for _, task := range tasks {
+			if task.DeadLetter && task.Config != nil && task.Config.UserID == user.UserID {
+				deadLetters = append(deadLetters, withoutToken(task))
+			}
+		}
+		if err = json.NewEncoder(w).Encode(deadLetters); err != nil {
+			logrus.Errorf("error marshalling metrics dead letters: %v", err)
+			http.Error(w, "unable to marshal the metrics dead letters", http.StatusInternalServerError)
+		}
+	case http.MethodPost, http.MethodDelete:
+		id, err := uuid.FromString(req.URL.Query().Get("id"))
+		if err != nil {
+			http.Error(w, "Provide a valid id.", http.StatusBadRequest)
+			return
+		}
+		task, err := h.config.MetricsTaskPersister.GetTask(id)
+		// the dead letters of the other users are reported as missing
+		if err != nil || !task.DeadLetter || task.Config == nil || task.Config.UserID != user.UserID {
+			http.Error(w, "no metrics dead letter found for the given id", http.StatusNotFound)
+			return
+		}
+		if req.Method == http.MethodDelete {
+			err = h.config.MetricsTaskPersister.DeleteTask(id)
+		} else {
+			tokenVal, _ := session.Values[h.config.SaaSTokenName].(string)
+			if task.Config.SaaSResultID != "" && tokenVal == "" {
+				http.Error(w, errNoSaaSToken.Error(), http.StatusUnauthorized)
+				return
+			}
+			task.DeadLetter = false
+			task.UpdatedAt = time.Now()
+			task.Config = task.Config.Redacted()
+			task.Config.TaskID = id.String()
+			if err = h.config.MetricsTaskPersister.WriteTask(id, task); err == nil {
+				err = h.task.Call(h.withCredentials(task.Config, tokenVal))
+			}
+		}
+		if err != nil {
+			logrus.Error(errors.Wrapf(err, "unable to update the metrics dead letter %s", id))
+			http.Error(w, "unable to update the metrics dead letter", http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte("{}"))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// withoutToken returns a copy of the task without the SaaS token and the prometheus credentials of its owner
+func withoutToken(task *models.MetricsTask) *models.MetricsTask {
+	t := *task
+	if t.Config != nil {
+		t.Config = t.Config.Redacted()
+	}
+	return &t
+}
+
+// withCredentials returns a copy of the persisted config along with the credentials it is persisted without: the
+// prometheus ones are taken from the session of the owner of the load test and the SaaS token is the given one
+func (h *Handler) withCredentials(config *models.SubmitMetricsConfig, tokenVal string) *models.SubmitMetricsConfig {
+	c := *config
+	if c.TokenVal == "" {
+		c.TokenVal = tokenVal
+	}
+	if c.PromAuth == nil && c.UserID != "" {
+		sessObj, err := h.config.SessionPersister.Read(c.UserID)
+		if err != nil {
+			logrus.Warn(errors.Wrapf(err, "unable to read the session of the owner of the load test %s", c.TestUUID))
+		} else if sessObj != nil && sessObj.Prometheus != nil && sessObj.Prometheus.PrometheusURL == c.PromURL {
+			c.PromAuth = sessObj.Prometheus.Auth
+		}
+	}
+	return &c
+}
@@ -5,10 +5,16 @@ import (
 	"encoding/gob"
 	"encoding/json"
 	"io/ioutil"
+	"math"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/gorilla/sessions"
+	"github.com/layer5io/meshery/helpers"
 	"github.com/layer5io/meshery/models"
 
 	"github.com/pkg/errors"
@@ -37,14 +43,22 @@ func (h *Handler) PrometheusConfigHandler(w http.ResponseWriter, req *http.Reque
 
 	if req.Method == http.MethodPost {
 		promURL := req.FormValue("prometheusURL")
-		if err = h.config.PrometheusClient.Validate(req.Context(), promURL); err != nil {
-			logrus.Errorf("unable to connect to prometheus: %v", err)
-			http.Error(w, "unable to connect to prometheus", http.StatusInternalServerError)
+		auth, err := prometheusAuth(req)
+		if err != nil {
+			logrus.Error(err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
-		sessObj.Prometheus = &models.Prometheus{
+		prom := &models.Prometheus{
 			PrometheusURL: promURL,
+			Auth:          auth,
 		}
+		if err = h.config.PrometheusClient.Validate(req.Context(), prom); err != nil {
+			logrus.Errorf("unable to connect to prometheus: %v", err)
+			http.Error(w, "unable to connect to prometheus", http.StatusInternalServerError)
+			return
+		}
+		sessObj.Prometheus = prom
 		logrus.Debugf("Prometheus URL %s successfully saved", promURL)
 	} else if req.Method == http.MethodDelete {
 		sessObj.Prometheus = nil
@@ -60,6 +74,66 @@ func (h *Handler) PrometheusConfigHandler(w http.ResponseWriter, req *http.Reque
 	_, _ = w.Write([]byte("{}"))
 }
 
+// prometheus returns the prometheus of the session, nil when it is not set. When it is a service reached through the
+// kubernetes API, the credentials are resolved from the kubeconfig of the session as it is now.
+func (h *Handler) prometheus(sessObj *models.Session) *models.Prometheus {
+	if sessObj == nil {
+		return nil
+	}
+	if sessObj.Prometheus == nil || sessObj.Prometheus.KubernetesService == nil {
+		return sessObj.Prometheus
+	}
+	prom := *sessObj.Prometheus
+	if sessObj.K8SConfig == nil {
+		logrus.Warn("kubernetes is not configured anymore, prometheus cannot be reached through the kubernetes API")
+		return &prom
+	}
+	rt, err := helpers.KubernetesTransport(sessObj.K8SConfig.Config, sessObj.K8SConfig.ContextName)
+	if err != nil {
+		logrus.Warn(errors.Wrap(err, "unable to reach prometheus through the kubernetes API"))
+		return &prom
+	}
+	prom.Transport = rt
+	return &prom
+}
+
+// prometheusAuth reads the credentials and TLS settings of prometheus from the form, nil when there are none.
+// The headers are given as a JSON object.
+func prometheusAuth(req *http.Request) (*models.PrometheusAuth, error) {
+	auth := &models.PrometheusAuth{
+		Username:    req.FormValue("username"),
+		Password:    req.FormValue("password"),
+		BearerToken: req.FormValue("bearerToken"),
+		TenantID:    req.FormValue("tenantID"),
+		CACert:      req.FormValue("caCert"),
+		ClientCert:  req.FormValue("clientCert"),
+		ClientKey:   req.FormValue("clientKey"),
+	}
+	if v := req.FormValue("insecureSkipVerify"); v != "" {
+		insecure, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, errors.New("insecureSkipVerify is not a boolean")
+		}
+		auth.InsecureSkipVerify = insecure
+	}
+	if v := req.FormValue("headers"); v != "" {
+		if err := json.Unmarshal([]byte(v), &auth.Headers); err != nil {
+			return nil, errors.New("headers is not a JSON object of strings")
+		}
+	}
+	if auth.Username == "" && auth.Password != "" {
+		return nil, errors.New("a password was given without a username")
+	}
+	if (auth.ClientCert == "") != (auth.ClientKey == "") {
+		return nil, errors.New("both the client certificate and key are needed for mTLS")
+	}
+	if auth.Username == "" && auth.BearerToken == "" && auth.TenantID == "" && len(auth.Headers) == 0 &&
+		auth.CACert == "" && auth.ClientCert == "" && !auth.InsecureSkipVerify {
+		return nil, nil
+	}
+	return auth, nil
+}
+
 // GrafanaBoardImportForPrometheusHandler accepts a Grafana board json, parses it and returns the list of panels
 func (h *Handler) GrafanaBoardImportForPrometheusHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, user *models.User) {
 	if req.Method != http.MethodPost {
@@ -130,7 +204,7 @@ func (h *Handler) PrometheusQueryHandler(w http.ResponseWriter, req *http.Reques
 
 	reqQuery := req.URL.Query()
 
-	data, err := h.config.PrometheusClientForQuery.Query(req.Context(), sessObj.Prometheus.PrometheusURL, &reqQuery)
+	data, err := h.config.PrometheusClientForQuery.Query(req.Context(), h.prometheus(sessObj), &reqQuery)
 	if err != nil {
 		msg := "connection to prometheus failed"
 		logrus.Error(errors.Wrap(err, msg))
@@ -160,6 +234,7 @@ func (h *Handler) PrometheusQueryRangeHandler(w http.ResponseWriter, req *http.R
 		http.Error(w, "Prometheus URL is not configured", http.StatusBadRequest)
 		return
 	}
+	prom := h.prometheus(sessObj)
 
 	reqQuery := req.URL.Query()
 
@@ -169,16 +244,69 @@ func (h *Handler) PrometheusQueryRangeHandler(w http.ResponseWriter, req *http.R
 		h.config.QueryTracker.AddOrFlagQuery(req.Context(), testUUID, q, false)
 	}
 
-	data, err := h.config.PrometheusClientForQuery.QueryRange(req.Context(), sessObj.Prometheus.PrometheusURL, &reqQuery)
+	// the step is computed and the series downsampled when the panel gives its max data points
+	var stepOpts *models.StepOptions
+	if reqQuery.Get("maxDataPoints") != "" {
+		stepOpts, err = h.adaptQueryRangeStep(req.Context(), prom, reqQuery)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	data, err := h.cacheQueryRange(prometheusBackend(sessObj), reqQuery, func() ([]byte, error) {
+		// the query can be shared with other requests, it is not cancelled along with this one
+		return h.config.PrometheusClientForQuery.QueryRange(context.Background(), prom, &reqQuery)
+	})
 	if err != nil {
 		msg := "connection to prometheus failed"
 		logrus.Error(errors.Wrap(err, msg))
 		http.Error(w, msg, http.StatusInternalServerError)
 		return
 	}
+	if stepOpts != nil {
+		if data, err = helpers.DownsampleQueryRangeResponse(data, stepOpts.GetMaxDataPoints()); err != nil {
+			logrus.Error(err)
+			http.Error(w, "unable to downsample the prometheus response", http.StatusInternalServerError)
+			return
+		}
+	}
 	_, _ = w.Write(data)
 }
 
+// adaptQueryRangeStep sets the step of the range query from the maxDataPoints and interval of the panel, returning
+// the step options
+func (h *Handler) adaptQueryRangeStep(ctx context.Context, prom *models.Prometheus, reqQuery url.Values) (*models.StepOptions, error) {
+	maxDataPoints, err := strconv.Atoi(reqQuery.Get("maxDataPoints"))
+	if err != nil || maxDataPoints <= 0 {
+		return nil, errors.New("maxDataPoints has to be a positive integer")
+	}
+	start, err := parsePromTime(reqQuery.Get("start"))
+	if err != nil {
+		return nil, errors.New("start is not a valid time")
+	}
+	end, err := parsePromTime(reqQuery.Get("end"))
+	if err != nil {
+		return nil, errors.New("end is not a valid time")
+	}
+	opts := &models.StepOptions{
+		MaxDataPoints: maxDataPoints,
+		MinInterval:   models.ParseInterval(reqQuery.Get("interval")),
+	}
+	step := h.config.PrometheusClient.ComputeStep(ctx, prom, start, end, opts)
+	reqQuery.Set("step", strconv.FormatFloat(step.Seconds(), 'f', -1, 64))
+	return opts, nil
+}
+
+// parsePromTime parses a time of the prometheus API, given as a unix timestamp or in RFC 3339 format
+func parsePromTime(t string) (time.Time, error) {
+	if ts, err := strconv.ParseFloat(t, 64); err == nil {
+		sec, frac := math.Modf(ts)
+		return time.Unix(int64(sec), int64(frac*float64(time.Second))), nil
+	}
+	return time.Parse(time.RFC3339Nano, t)
+}
+
 // PrometheusStaticBoardHandler returns the static board
 func (h *Handler) PrometheusStaticBoardHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, user *models.User) {
 	if req.Method != http.MethodGet {
@@ -200,21 +328,22 @@ func (h *Handler) PrometheusStaticBoardHandler(w http.ResponseWriter, req *http.
 		return
 	}
 
+	prom := h.prometheus(sessObj)
 	result := map[string]*models.GrafanaBoard{}
 	resultLock := &sync.Mutex{}
 	resultWG := &sync.WaitGroup{}
 
-	boardFunc := map[string]func(context.Context, string) (*models.GrafanaBoard, error){
+	boardFunc := map[string]func(context.Context, *models.Prometheus) (*models.GrafanaBoard, error){
 		"cluster": h.config.PrometheusClient.GetClusterStaticBoard,
 		"node":    h.config.PrometheusClient.GetNodesStaticBoard,
 	}
 
 	for key, bfunc := range boardFunc {
 		resultWG.Add(1)
-		go func(k string, bfun func(context.Context, string) (*models.GrafanaBoard, error)) {
+		go func(k string, bfun func(context.Context, *models.Prometheus) (*models.GrafanaBoard, error)) {
 			defer resultWG.Done()
 
-			board, err := bfun(req.Context(), sessObj.Prometheus.PrometheusURL)
+			board, err := bfun(req.Context(), prom)
 			if err != nil {
 				// error is already logged
 				return
@@ -231,6 +360,25 @@ func (h *Handler) PrometheusStaticBoardHandler(w http.ResponseWriter, req *http.
 		return
 	}
 
+	// the boards of the meshes found in the cluster are added, without failing the request when they are not found
+	if sessObj.K8SConfig != nil {
+		installedMeshes, err := helpers.ScanKubernetes(sessObj.K8SConfig.Config, sessObj.K8SConfig.ContextName)
+		if err != nil {
+			logrus.Warn(errors.Wrap(err, "unable to scan kubernetes, skipping the mesh static boards"))
+		}
+		meshes := []string{}
+		for mesh := range installedMeshes {
+			meshes = append(meshes, mesh)
+		}
+		meshBoards, err := h.config.PrometheusClient.GetMeshStaticBoards(req.Context(), meshes)
+		if err != nil {
+			logrus.Warn(errors.Wrap(err, "unable to get the mesh static boards"))
+		}
+		for mesh, board := range meshBoards {
+			result[strings.ToLower(mesh)] = board
+		}
+	}
+
 	err = json.NewEncoder(w).Encode(result)
 	if err != nil {
 		logrus.Errorf("error marshalling board: %v", err)
@@ -296,3 +444,94 @@ func (h *Handler) SaveSelectedPrometheusBoardsHandler(w http.ResponseWriter, req
 	}
 	_, _ = w.Write([]byte("{}"))
 }
+
+// QueryTrackerStatsHandler returns metrics on the queries tracked for the load tests
+func (h *Handler) QueryTrackerStatsHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, user *models.User) {
+	if req.Method != http.MethodGet {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err := json.NewEncoder(w).Encode(h.config.QueryTracker.Stats(req.Context())); err != nil {
+		logrus.Errorf("error marshalling query tracker stats: %v", err)
+		http.Error(w, "unable to marshal the query tracker stats", http.StatusInternalServerError)
+	}
+}
+
+// PrometheusRulesHandler returns the alerting and recording rules of prometheus
+func (h *Handler) PrometheusRulesHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, user *models.User) {
+	if req.Method != http.MethodGet {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	sessObj, err := h.config.SessionPersister.Read(user.UserID)
+	if err != nil {
+		logrus.Warn("unable to read session from the session persister, starting with a new one")
+	}
+
+	if sessObj == nil || sessObj.Prometheus == nil || sessObj.Prometheus.PrometheusURL == "" {
+		http.Error(w, "Prometheus URL is not configured", http.StatusBadRequest)
+		return
+	}
+
+	groups, err := h.config.PrometheusClient.GetRules(req.Context(), h.prometheus(sessObj))
+	if err != nil {
+		http.Error(w, "unable to get the prometheus rules", http.StatusInternalServerError)
+		return
+	}
+	if err = json.NewEncoder(w).Encode(groups); err != nil {
+		logrus.Errorf("error marshalling prometheus rules: %v", err)
+		http.Error(w, "unable to marshal the prometheus rules", http.StatusInternalServerError)
+	}
+}
+
+// PrometheusAlertsHandler returns the alerts of prometheus which are pending or firing, or the ones which fired
+// between the start and end times, in RFC 3339 format, when they are given
+func (h *Handler) PrometheusAlertsHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, user *models.User) {
+	if req.Method != http.MethodGet {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	sessObj, err := h.config.SessionPersister.Read(user.UserID)
+	if err != nil {
+		logrus.Warn("unable to read session from the session persister, starting with a new one")
+	}
+
+	if sessObj == nil || sessObj.Prometheus == nil || sessObj.Prometheus.PrometheusURL == "" {
+		http.Error(w, "Prometheus URL is not configured", http.StatusBadRequest)
+		return
+	}
+
+	var alerts []*models.PrometheusAlert
+	q := req.URL.Query()
+	if q.Get("start") != "" || q.Get("end") != "" {
+		start, err := time.Parse(time.RFC3339, q.Get("start"))
+		if err != nil {
+			http.Error(w, "start is not a valid RFC 3339 time", http.StatusBadRequest)
+			return
+		}
+		end := time.Now()
+		if q.Get("end") != "" {
+			if end, err = time.Parse(time.RFC3339, q.Get("end")); err != nil {
+				http.Error(w, "end is not a valid RFC 3339 time", http.StatusBadRequest)
+				return
+			}
+		}
+		if !end.After(start) {
+			http.Error(w, "end has to be after start", http.StatusBadRequest)
+			return
+		}
+		alerts, err = h.config.PrometheusClient.GetFiredAlerts(req.Context(), h.prometheus(sessObj), start, end)
+	} else {
+		alerts, err = h.config.PrometheusClient.GetAlerts(req.Context(), h.prometheus(sessObj))
+	}
+	if err != nil {
+		http.Error(w, "unable to get the prometheus alerts", http.StatusInternalServerError)
+		return
+	}
+	if err = json.NewEncoder(w).Encode(alerts); err != nil {
+		logrus.Errorf("error marshalling prometheus alerts: %v", err)
+		http.Error(w, "unable to marshal the prometheus alerts", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/sessions"
+	"github.com/layer5io/meshery/helpers"
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+	promModel "github.com/prometheus/common/model"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// recentQueryCacheMaxTTL bounds the time the results of the range queries reaching the present are cached, they
+	// are cached for a step otherwise
+	recentQueryCacheMaxTTL = 30 * time.Second
+	// pastQueryCacheAge is the age from which the end of a range query is past, its results not changing anymore
+	pastQueryCacheAge = 5 * time.Minute
+	// pastQueryCacheTTL is how long the results of the range queries in the past are cached
+	pastQueryCacheTTL = 10 * time.Minute
+)
+
+// QueryCacheStatsHandler returns metrics on the cache of the query results
+func (h *Handler) QueryCacheStatsHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, user *models.User) {
+	if req.Method != http.MethodGet {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if h.config.QueryCache == nil {
+		http.Error(w, "the query cache is not available", http.StatusNotImplemented)
+		return
+	}
+	if err := json.NewEncoder(w).Encode(h.config.QueryCache.Stats()); err != nil {
+		logrus.Errorf("error marshalling query cache stats: %v", err)
+		http.Error(w, "unable to marshal the query cache stats", http.StatusInternalServerError)
+	}
+}
+
+// cacheQueryRange returns the result of the range query of reqQuery from the query cache. The range is aligned to the
+// step first, so that the refreshes of a board share the cached results, the points after the aligned end being
+// fetched without cache and appended. backend identifies the backend along with its credentials, the results not
+// being shared among the users of different ones. fetch runs the query with reqQuery, without cache when the range
+// is not valid.
+func (h *Handler) cacheQueryRange(backend string, reqQuery url.Values, fetch func() ([]byte, error)) ([]byte, error) {
+	if h.config.QueryCache == nil {
+		return fetch()
+	}
+	start, errStart := parsePromTime(reqQuery.Get("start"))
+	end, errEnd := parsePromTime(reqQuery.Get("end"))
+	step, errStep := parsePromStep(reqQuery.Get("step"))
+	if errStart != nil || errEnd != nil || errStep != nil || step <= 0 || end.Before(start) {
+		return fetch()
+	}
+	alignedStart, alignedEnd := start.Truncate(step), end.Truncate(step)
+	reqQuery.Set("start", strconv.FormatInt(alignedStart.Unix(), 10))
+	reqQuery.Set("end", strconv.FormatInt(alignedEnd.Unix(), 10))
+	reqQuery.Set("step", strconv.FormatFloat(step.Seconds(), 'f', -1, 64))
+
+	ttl := pastQueryCacheTTL
+	if time.Since(alignedEnd) < pastQueryCacheAge {
+		ttl = step
+		if ttl > recentQueryCacheMaxTTL {
+			ttl = recentQueryCacheMaxTTL
+		}
+	}
+	backendHash := sha256.Sum256([]byte(backend))
+	key := strings.Join([]string{
+		hex.EncodeToString(backendHash[:]),
+		reqQuery.Get("ds"),
+		strings.TrimSpace(reqQuery.Get("query")),
+		reqQuery.Get("start"),
+		reqQuery.Get("end"),
+		reqQuery.Get("step"),
+	}, "\x00")
+	data, err := h.config.QueryCache.Get(key, ttl, fetch)
+	if err != nil || !end.After(alignedEnd) {
+		return data, err
+	}
+
+	// the point at the end, the freshest one, is between two steps: it changes with each refresh
+	reqQuery.Set("start", formatPromTime(end))
+	reqQuery.Set("end", formatPromTime(end))
+	tail, err := fetch()
+	if err != nil {
+		logrus.Warn(errors.Wrap(err, "unable to fetch the end of the range query, the cached results are returned without it"))
+		return data, nil
+	}
+	merged, err := helpers.AppendQueryRangeResponse(data, tail)
+	if err != nil {
+		logrus.Warn(errors.Wrap(err, "unable to append the end of the range query, the cached results are returned without it"))
+		return data, nil
+	}
+	return merged, nil
+}
+
+// formatPromTime formats a time of the prometheus API, in seconds since the epoch
+func formatPromTime(t time.Time) string {
+	return strconv.FormatFloat(float64(t.UnixNano())/float64(time.Second), 'f', -1, 64)
+}
+
+// prometheusBackend identifies the prometheus of the session along with its credentials for the query cache, the
+// kubeconfig being the credentials of the prometheus reached through the kubernetes API
+func prometheusBackend(sessObj *models.Session) string {
+	prom := sessObj.Prometheus
+	auth, _ := json.Marshal(prom.Auth)
+	if prom.KubernetesService != nil && sessObj.K8SConfig != nil {
+		auth = append(append(auth, sessObj.K8SConfig.Config...), sessObj.K8SConfig.ContextName...)
+	}
+	return fmt.Sprintf("prometheus\x00%s\x00%s", prom.PrometheusURL, auth)
+}
+
+// grafanaBackend identifies grafana along with its API key for the query cache
+func grafanaBackend(grafana *models.Grafana) string {
+	return fmt.Sprintf("grafana\x00%s\x00%s", grafana.GrafanaURL, grafana.GrafanaAPIKey)
+}
+
+// parsePromStep parses a step of the prometheus API, given in seconds or as a duration
+func parsePromStep(step string) (time.Duration, error) {
+	if s, err := strconv.ParseFloat(step, 64); err == nil {
+		if math.IsNaN(s) || math.IsInf(s, 0) {
+			return 0, fmt.Errorf("invalid step %s", step)
+		}
+		return time.Duration(s * float64(time.Second)), nil
+	}
+	d, err := promModel.ParseDuration(step)
+	return time.Duration(d), err
+}
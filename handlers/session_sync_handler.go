@@ -75,6 +75,13 @@ func (h *Handler) SessionSyncHandler(w http.ResponseWriter, req *http.Request, s
 		}
 	}
 
+	// masking the prometheus credentials, on a copy for the ones of the session to be left untouched
+	if sessObj.Prometheus != nil && sessObj.Prometheus.Auth != nil {
+		prom := *sessObj.Prometheus
+		prom.Auth = prom.Auth.Redacted()
+		sessObj.Prometheus = &prom
+	}
+
 	err = json.NewEncoder(w).Encode(sessObj)
 	if err != nil {
 		logrus.Errorf("error marshalling user config data: %v", err)
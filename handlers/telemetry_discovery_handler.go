@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/sessions"
+	"github.com/layer5io/meshery/helpers"
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// telemetryAccessProxy - the service is reached through the kubernetes API service proxy
+	telemetryAccessProxy = "proxy"
+	// telemetryAccessCluster - the service is reached with its in-cluster URL
+	telemetryAccessCluster = "cluster"
+)
+
+// TelemetryDiscoveryHandler finds the Prometheus and Grafana services of the cluster of the session: GET lists them
+// and POST configures the one of the given type, picking the only one found when name, namespace and port are not
+// given. Prometheus is reached through the kubernetes API service proxy by default, the requests being authenticated
+// with the kubeconfig of the session when they are sent. Grafana is only reached with its in-cluster URL, as the API
+// server would take its API key for its own credentials, so it is configured here only when Meshery runs in the
+// cluster. Port-forwards are not supported.
+func (h *Handler) TelemetryDiscoveryHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, user *models.User) {
+	if req.Method != http.MethodGet && req.Method != http.MethodPost {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	sessObj, err := h.config.SessionPersister.Read(user.UserID)
+	if err != nil {
+		logrus.Warn("unable to read session from the session persister, starting with a new one")
+	}
+
+	if sessObj == nil {
+		sessObj = &models.Session{}
+	}
+	if sessObj.K8SConfig == nil {
+		http.Error(w, "Kubernetes is not configured", http.StatusBadRequest)
+		return
+	}
+
+	candidates, err := helpers.DiscoverTelemetry(sessObj.K8SConfig.Config, sessObj.K8SConfig.ContextName)
+	if err != nil {
+		logrus.Error(errors.Wrap(err, "unable to discover the telemetry services"))
+		http.Error(w, "unable to scan kubernetes", http.StatusInternalServerError)
+		return
+	}
+	if req.Method == http.MethodGet {
+		if err = json.NewEncoder(w).Encode(candidates); err != nil {
+			logrus.Errorf("error marshalling telemetry candidates: %v", err)
+			http.Error(w, "unable to marshal the telemetry candidates", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	candidate, err := selectTelemetryCandidate(req, candidates)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	access := req.FormValue("access")
+	if access == "" {
+		access = telemetryAccessCluster
+		if candidate.Type == models.TelemetryPrometheus {
+			access = telemetryAccessProxy
+		}
+	}
+	if access != telemetryAccessProxy && access != telemetryAccessCluster {
+		http.Error(w, fmt.Sprintf("access has to be %s or %s", telemetryAccessProxy, telemetryAccessCluster), http.StatusBadRequest)
+		return
+	}
+	if access == telemetryAccessCluster && !helpers.RunsInCluster() {
+		http.Error(w, fmt.Sprintf("Meshery runs outside of the cluster, %s cannot be reached with its in-cluster URL", candidate.Type), http.StatusBadRequest)
+		return
+	}
+
+	switch candidate.Type {
+	case models.TelemetryPrometheus:
+		prom := &models.Prometheus{
+			PrometheusURL: candidate.URL,
+		}
+		if access == telemetryAccessProxy {
+			prom.KubernetesService = &models.KubernetesService{
+				Namespace: candidate.Namespace,
+				Name:      candidate.Name,
+				Port:      candidate.Port,
+			}
+			prom.PrometheusURL, err = helpers.KubernetesServiceProxyURL(sessObj.K8SConfig.Config, sessObj.K8SConfig.ContextName, prom.KubernetesService)
+			if err != nil {
+				http.Error(w, "unable to reach prometheus through the kubernetes API", http.StatusBadRequest)
+				return
+			}
+		}
+		if err = h.config.PrometheusClient.Validate(req.Context(), h.prometheus(&models.Session{
+			K8SConfig:  sessObj.K8SConfig,
+			Prometheus: prom,
+		})); err != nil {
+			logrus.Errorf("unable to connect to prometheus: %v", err)
+			http.Error(w, "unable to connect to prometheus", http.StatusInternalServerError)
+			return
+		}
+		sessObj.Prometheus = prom
+		logrus.Debugf("Prometheus URL %s successfully saved", prom.PrometheusURL)
+	case models.TelemetryGrafana:
+		if access == telemetryAccessProxy {
+			http.Error(w, "grafana can only be reached with its in-cluster URL", http.StatusBadRequest)
+			return
+		}
+		grafanaAPIKey := req.FormValue("grafanaAPIKey")
+		if err = h.config.GrafanaClient.Validate(req.Context(), candidate.URL, grafanaAPIKey); err != nil {
+			http.Error(w, "connection to grafana failed", http.StatusInternalServerError)
+			return
+		}
+		sessObj.Grafana = &models.Grafana{
+			GrafanaURL:    candidate.URL,
+			GrafanaAPIKey: grafanaAPIKey,
+		}
+		logrus.Debugf("connection to grafana @ %s succeeded", candidate.URL)
+	}
+
+	err = h.config.SessionPersister.Write(user.UserID, sessObj)
+	if err != nil {
+		logrus.Errorf("unable to save user config data: %v", err)
+		http.Error(w, "unable to save user config data", http.StatusInternalServerError)
+		return
+	}
+	if err = json.NewEncoder(w).Encode(candidate); err != nil {
+		logrus.Errorf("error marshalling telemetry candidate: %v", err)
+		http.Error(w, "unable to marshal the telemetry candidate", http.StatusInternalServerError)
+	}
+}
+
+// selectTelemetryCandidate returns the candidate matching the type, name, namespace and port of the form, the ones
+// not given matching any candidate. Exactly one candidate has to match.
+func selectTelemetryCandidate(req *http.Request, candidates []*models.TelemetryCandidate) (*models.TelemetryCandidate, error) {
+	telemetryType := models.TelemetryType(req.FormValue("type"))
+	if telemetryType != models.TelemetryPrometheus && telemetryType != models.TelemetryGrafana {
+		return nil, fmt.Errorf("type has to be %s or %s", models.TelemetryPrometheus, models.TelemetryGrafana)
+	}
+	name, namespace := req.FormValue("name"), req.FormValue("namespace")
+	var port int64
+	if p := req.FormValue("port"); p != "" {
+		var err error
+		if port, err = strconv.ParseInt(p, 10, 32); err != nil {
+			return nil, errors.New("port is not a valid port")
+		}
+	}
+
+	var matches []*models.TelemetryCandidate
+	for _, c := range candidates {
+		if c.Type == telemetryType && (name == "" || c.Name == name) && (namespace == "" || c.Namespace == namespace) &&
+			(port == 0 || int64(c.Port) == port) {
+			matches = append(matches, c)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no %s found in the cluster", telemetryType)
+	case 1:
+		return matches[0], nil
+	default:
+		return nil, fmt.Errorf("%d %s services found, pick one with name, namespace and port", len(matches), telemetryType)
+	}
+}
@@ -11,4 +11,12 @@ const (
 	// saasTokenName        = "meshery_saas"
 
 	loginCookieDuration = 1 * time.Hour
+
+	maxLoadTestPayloadSize = 32 << 20 // 32MB
+
+	defaultSnapshotInterval = 5 * time.Second
+
+	// firedAlertsTimeout bounds the time spent getting the alerts fired during a load test, once their grace period
+	// has elapsed
+	firedAlertsTimeout = 10 * time.Second
 )
@@ -0,0 +1,112 @@
+package helpers
+
+import (
+	"encoding/json"
+	"math"
+
+	"github.com/pkg/errors"
+	promModel "github.com/prometheus/common/model"
+)
+
+// DownsampleLTTB downsamples the points to threshold points with the Largest-Triangle-Three-Buckets algorithm, which
+// keeps the shape of the series. The points are returned as they are when there are not more than threshold of them.
+func DownsampleLTTB(points []promModel.SamplePair, threshold int) []promModel.SamplePair {
+	if threshold < 3 || len(points) <= threshold {
+		return points
+	}
+	sampled := make([]promModel.SamplePair, 0, threshold)
+	// the first and last points are always kept, the others are split in threshold-2 buckets
+	bucketSize := float64(len(points)-2) / float64(threshold-2)
+	a := 0
+	sampled = append(sampled, points[a])
+	for i := 0; i < threshold-2; i++ {
+		// the average of the next bucket is the third point of the triangles
+		nextStart := int(math.Floor(float64(i+1)*bucketSize)) + 1
+		nextEnd := int(math.Floor(float64(i+2)*bucketSize)) + 1
+		if nextEnd > len(points) {
+			nextEnd = len(points)
+		}
+		var avgX, avgY float64
+		for _, p := range points[nextStart:nextEnd] {
+			avgX += float64(p.Timestamp)
+			avgY += float64(p.Value)
+		}
+		n := float64(nextEnd - nextStart)
+		avgX /= n
+		avgY /= n
+
+		// the point of the bucket forming the largest triangle with the last point kept and the average is kept
+		start := int(math.Floor(float64(i)*bucketSize)) + 1
+		end := nextStart
+		ax, ay := float64(points[a].Timestamp), float64(points[a].Value)
+		maxArea, next := -1.0, start
+		for j := start; j < end; j++ {
+			area := math.Abs((ax-avgX)*(float64(points[j].Value)-ay)-(ax-float64(points[j].Timestamp))*(avgY-ay)) / 2
+			if area > maxArea {
+				maxArea, next = area, j
+			}
+		}
+		sampled = append(sampled, points[next])
+		a = next
+	}
+	return append(sampled, points[len(points)-1])
+}
+
+// DownsampleMatrix downsamples each of the series of the range query result to maxPoints points
+func DownsampleMatrix(value promModel.Value, maxPoints int) promModel.Value {
+	matrix, ok := value.(promModel.Matrix)
+	if !ok {
+		return value
+	}
+	for _, stream := range matrix {
+		stream.Values = DownsampleLTTB(stream.Values, maxPoints)
+	}
+	return matrix
+}
+
+// DownsampleQueryRangeResponse downsamples each of the series of the range query response of the prometheus API to
+// maxPoints points
+func DownsampleQueryRangeResponse(data []byte, maxPoints int) ([]byte, error) {
+	resp, matrix, err := parseQueryRangeResponse(data)
+	if err != nil {
+		return nil, err
+	}
+	// only matrices are downsampled
+	if matrix == nil {
+		return data, nil
+	}
+	return marshalQueryRangeResponse(resp, DownsampleMatrix(matrix, maxPoints).(promModel.Matrix))
+}
+
+// parseQueryRangeResponse parses the range query response of the prometheus API, the matrix being nil when the
+// response does not hold one
+func parseQueryRangeResponse(data []byte) (map[string]json.RawMessage, promModel.Matrix, error) {
+	resp := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, nil, errors.Wrap(err, "unable to parse the range query response")
+	}
+	respData := struct {
+		ResultType promModel.ValueType `json:"resultType"`
+		Result     promModel.Matrix    `json:"result"`
+	}{}
+	if len(resp["data"]) == 0 {
+		return resp, nil, nil
+	}
+	if err := json.Unmarshal(resp["data"], &respData); err != nil || respData.ResultType != promModel.ValMatrix {
+		return resp, nil, nil
+	}
+	return resp, respData.Result, nil
+}
+
+// marshalQueryRangeResponse marshals the range query response resp with matrix as its result
+func marshalQueryRangeResponse(resp map[string]json.RawMessage, matrix promModel.Matrix) ([]byte, error) {
+	d, err := json.Marshal(struct {
+		ResultType promModel.ValueType `json:"resultType"`
+		Result     promModel.Matrix    `json:"result"`
+	}{promModel.ValMatrix, matrix})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to marshal the range query response")
+	}
+	resp["data"] = d
+	return json.Marshal(resp)
+}
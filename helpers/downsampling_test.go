@@ -0,0 +1,203 @@
+package helpers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	promModel "github.com/prometheus/common/model"
+)
+
+// series returns the points of the values, one second apart
+func series(values ...float64) []promModel.SamplePair {
+	points := make([]promModel.SamplePair, 0, len(values))
+	for i, v := range values {
+		points = append(points, promModel.SamplePair{
+			Timestamp: promModel.Time(int64(i) * 1000),
+			Value:     promModel.SampleValue(v),
+		})
+	}
+	return points
+}
+
+// spike returns n points at 0 but the one at index i
+func spike(n, i int, value float64) []promModel.SamplePair {
+	values := make([]float64, n)
+	values[i] = value
+	return series(values...)
+}
+
+func TestDownsampleLTTB(t *testing.T) {
+	tests := []struct {
+		name      string
+		points    []promModel.SamplePair
+		threshold int
+		wantLen   int
+		// wantValues are values which have to be kept
+		wantValues []float64
+	}{
+		{
+			name:      "no points",
+			points:    nil,
+			threshold: 10,
+			wantLen:   0,
+		},
+		{
+			name:      "fewer points than the threshold",
+			points:    series(1, 2, 3),
+			threshold: 10,
+			wantLen:   3,
+		},
+		{
+			name:      "as many points as the threshold",
+			points:    series(1, 2, 3, 4),
+			threshold: 4,
+			wantLen:   4,
+		},
+		{
+			name:      "threshold too small",
+			points:    series(1, 2, 3, 4, 5),
+			threshold: 2,
+			wantLen:   5,
+		},
+		{
+			name:       "spike kept",
+			points:     spike(100, 50, 100),
+			threshold:  10,
+			wantLen:    10,
+			wantValues: []float64{100},
+		},
+		{
+			name:       "dip kept",
+			points:     series(5, 5, 5, 5, 5, 5, 5, 5, 5, -20, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5),
+			threshold:  4,
+			wantLen:    4,
+			wantValues: []float64{-20},
+		},
+		{
+			name:      "threshold of three",
+			points:    spike(20, 7, 3),
+			threshold: 3,
+			wantLen:   3,
+			// the largest triangle is the one with the spike
+			wantValues: []float64{3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DownsampleLTTB(tt.points, tt.threshold)
+			if len(got) != tt.wantLen {
+				t.Fatalf("points = %d, want %d", len(got), tt.wantLen)
+			}
+			if len(got) == 0 {
+				return
+			}
+			if got[0] != tt.points[0] || got[len(got)-1] != tt.points[len(tt.points)-1] {
+				t.Errorf("first and last points = %v, %v, want %v, %v", got[0], got[len(got)-1], tt.points[0], tt.points[len(tt.points)-1])
+			}
+			for i := 1; i < len(got); i++ {
+				if !got[i].Timestamp.After(got[i-1].Timestamp) {
+					t.Fatalf("points out of order: %v", got)
+				}
+			}
+			for _, want := range tt.wantValues {
+				found := false
+				for _, p := range got {
+					if float64(p.Value) == want {
+						found = true
+					}
+				}
+				if !found {
+					t.Errorf("points = %v, want the point at %g kept", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDownsampleQueryRangeResponse(t *testing.T) {
+	values := make([]string, 0, 50)
+	for i := 0; i < 50; i++ {
+		values = append(values, fmt.Sprintf(`[%d,"%d"]`, 1577836800+i, i%7))
+	}
+	matrix := `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"job":"a"},"values":[` +
+		strings.Join(values, ",") + `]}]}}`
+	vector := `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1577836800,"1"]}]}}`
+
+	tests := []struct {
+		name       string
+		data       string
+		maxPoints  int
+		wantPoints int
+		wantSame   bool
+		wantErr    bool
+	}{
+		{
+			name:       "matrix downsampled",
+			data:       matrix,
+			maxPoints:  10,
+			wantPoints: 10,
+		},
+		{
+			name:       "matrix below the max points",
+			data:       matrix,
+			maxPoints:  100,
+			wantPoints: 50,
+		},
+		{
+			name:      "vector unchanged",
+			data:      vector,
+			maxPoints: 10,
+			wantSame:  true,
+		},
+		{
+			name:      "error response unchanged",
+			data:      `{"status":"error","errorType":"bad_data","error":"parse error"}`,
+			maxPoints: 10,
+			wantSame:  true,
+		},
+		{
+			name:      "invalid JSON",
+			data:      `{"status":`,
+			maxPoints: 10,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DownsampleQueryRangeResponse([]byte(tt.data), tt.maxPoints)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %t", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if tt.wantSame {
+				if string(got) != tt.data {
+					t.Errorf("response = %s, want it unchanged", got)
+				}
+				return
+			}
+			resp := struct {
+				Status string `json:"status"`
+				Data   struct {
+					ResultType string           `json:"resultType"`
+					Result     promModel.Matrix `json:"result"`
+				} `json:"data"`
+			}{}
+			if err = json.Unmarshal(got, &resp); err != nil {
+				t.Fatalf("unable to parse the response %s: %v", got, err)
+			}
+			if resp.Status != "success" || resp.Data.ResultType != "matrix" || len(resp.Data.Result) != 1 {
+				t.Fatalf("response = %s, want a successful matrix of one series", got)
+			}
+			if n := len(resp.Data.Result[0].Values); n != tt.wantPoints {
+				t.Errorf("points = %d, want %d", n, tt.wantPoints)
+			}
+			if resp.Data.Result[0].Metric["job"] != "a" {
+				t.Errorf("labels = %v, want them kept", resp.Data.Result[0].Metric)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/layer5io/meshery/models"
+)
+
+func TestGetFiredAlerts(t *testing.T) {
+	// firing are the evaluations of the query range each alert fires at
+	firing := map[string][]int{
+		// firing before the window and throughout it
+		"Watchdog":    {0, 1, 2, 3, 4, 5},
+		"HighLatency": {2, 3},
+		// firing before the window, then again during it
+		"Flapping": {0, 1, 4},
+		// firing before the window only
+		"Resolved": {0},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/api/v1/query_range" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		start, _ := strconv.ParseFloat(req.FormValue("start"), 64)
+		step, _ := strconv.ParseFloat(req.FormValue("step"), 64)
+		var series []string
+		for name, evaluations := range firing {
+			var values []string
+			for _, i := range evaluations {
+				values = append(values, fmt.Sprintf(`[%.3f,"1"]`, start+float64(i)*step))
+			}
+			series = append(series, fmt.Sprintf(`{"metric":{"__name__":"ALERTS","alertname":%q,"alertstate":"firing"},"values":[%s]}`,
+				name, strings.Join(values, ",")))
+		}
+		fmt.Fprint(w, `{"status":"success","data":{"resultType":"matrix","result":[`+strings.Join(series, ",")+`]}}`)
+	}))
+	defer srv.Close()
+
+	end := time.Now().Add(-time.Hour)
+	start := end.Add(-10 * time.Minute)
+	alerts, err := models.NewPrometheusClient().GetFiredAlerts(context.Background(), &models.Prometheus{PrometheusURL: srv.URL}, start, end)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the evaluations are a minute apart without the rules
+	want := map[string]time.Time{
+		"HighLatency": start.Add(2 * time.Minute),
+		"Flapping":    start.Add(4 * time.Minute),
+	}
+	if len(alerts) != len(want) {
+		t.Fatalf("alerts = %d, want %d", len(alerts), len(want))
+	}
+	for _, alert := range alerts {
+		from, ok := want[alert.Name]
+		if !ok {
+			t.Errorf("alert %s fired, want it left out", alert.Name)
+			continue
+		}
+		if d := alert.FiringFrom.Sub(from); d < -time.Millisecond || d > time.Millisecond {
+			t.Errorf("alert %s firing from %v, want %v", alert.Name, alert.FiringFrom, from)
+		}
+	}
+}
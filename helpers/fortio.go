@@ -1,7 +1,11 @@
 package helpers
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
 	"os"
 	"strings"
 
@@ -15,7 +19,7 @@ import (
 
 // SharedHTTPOptions is the flag->httpoptions transfer code shared between
 // fortio_main and fcurl.
-func sharedHTTPOptions(opts *models.LoadTestOptions) *fhttp.HTTPOptions {
+func sharedHTTPOptions(opts *models.LoadTestOptions) (*fhttp.HTTPOptions, error) {
 	url := strings.TrimLeft(opts.URL, " \t\r\n")
 	httpOpts := fhttp.HTTPOptions{}
 	httpOpts.URL = url
@@ -25,23 +29,51 @@ func sharedHTTPOptions(opts *models.LoadTestOptions) *fhttp.HTTPOptions {
 	httpOpts.AllowHalfClose = false
 	httpOpts.Compression = false
 	httpOpts.HTTPReqTimeOut = fhttp.HTTPReqTimeOutDefaultValue
+	if opts.HTTPReqTimeout > 0 {
+		httpOpts.HTTPReqTimeOut = opts.HTTPReqTimeout
+	}
 	httpOpts.Insecure = opts.IsInsecure
-	httpOpts.UserCredentials = ""
-	httpOpts.ContentType = ""
+	httpOpts.UserCredentials = opts.HTTPUserCredentials
+	httpOpts.ContentType = opts.HTTPContentType
+	httpOpts.Payload = opts.HTTPBody
+	// Fortio derives the method from the payload: GET without one, POST with one
+	switch opts.HTTPMethod {
+	case "", http.MethodGet:
+		if !opts.IsGRPC && (len(httpOpts.Payload) > 0 || httpOpts.ContentType != "") {
+			return nil, errors.New("fortio can not send a body with a GET request")
+		}
+	case http.MethodPost:
+		if len(httpOpts.Payload) == 0 && httpOpts.ContentType == "" {
+			httpOpts.ContentType = "application/octet-stream"
+		}
+	default:
+		return nil, fmt.Errorf("fortio does not support the %s method, only GET and POST", opts.HTTPMethod)
+	}
+	for _, hdr := range opts.HTTPHeaders {
+		if err := httpOpts.AddAndValidateExtraHeader(hdr); err != nil {
+			return nil, err
+		}
+	}
 	// httpOpts.Payload = fnet.GeneratePayload(*PayloadFileFlag, *PayloadSizeFlag, *PayloadFlag)
 	// httpOpts.UnixDomainSocket = *unixDomainSocketFlag
 	// if false { // *followRedirectsFlag {
 	httpOpts.FollowRedirects = true
 	httpOpts.DisableFastClient = true
 	// }
-	return &httpOpts
+	return &httpOpts, nil
 }
 
-// FortioLoadTest is the actual code which invokes Fortio to run the load test
-func FortioLoadTest(opts *models.LoadTestOptions) (map[string]interface{}, *periodic.RunnerResults, error) {
+// FortioLoadTest is the actual code which invokes Fortio to run the load test.
+// The run is aborted when ctx is done, the results gathered until then are still returned.
+func FortioLoadTest(ctx context.Context, opts *models.LoadTestOptions) (map[string]interface{}, *periodic.RunnerResults, error) {
 	defaults := &periodic.DefaultRunnerOptions
 	// httpOpts := bincommon.SharedHTTPOptions()
-	httpOpts := sharedHTTPOptions(opts)
+	httpOpts, err := sharedHTTPOptions(opts)
+	if err != nil {
+		err = errors.Wrap(err, "invalid http options")
+		logrus.Error(err)
+		return nil, nil, err
+	}
 	if opts.IsInsecure {
 		httpOpts.Insecure = true
 	}
@@ -90,13 +122,32 @@ func FortioLoadTest(opts *models.LoadTestOptions) (map[string]interface{}, *peri
 		Labels:      labels,
 		Exactly:     0,
 	}
-	var res periodic.HasRunnerResult
-	var err error
+	var (
+		res         periodic.HasRunnerResult
+		abortReason string
+		snapshots   []*models.LoadTestSnapshot
+	)
 	if opts.IsGRPC {
+		if opts.Cert != "" || opts.Key != "" {
+			err = errors.New("client certificates are not supported by the fortio gRPC runner, only a CA certificate")
+			logrus.Error(err)
+			return nil, nil, err
+		}
+		// fortio expects a path to the CA certificate
+		var caCertFile string
+		if opts.CACert != "" {
+			caCertFile, err = writeTempFile("meshery-grpc-ca-*.pem", []byte(opts.CACert))
+			if err != nil {
+				return nil, nil, err
+			}
+			defer func() {
+				_ = os.Remove(caCertFile)
+			}()
+		}
 		o := fgrpc.GRPCRunnerOptions{
 			RunnerOptions:      ro,
 			Destination:        rURL,
-			CACert:             opts.CACert,
+			CACert:             caCertFile,
 			Service:            opts.GRPCHealthSvc,
 			Streams:            opts.GRPCStreamsCount,
 			AllowInitialErrors: opts.AllowInitialErrors,
@@ -105,16 +156,28 @@ func FortioLoadTest(opts *models.LoadTestOptions) (map[string]interface{}, *peri
 			UsePing:            opts.GRPCDoPing,
 			UnixDomainSocket:   httpOpts.UnixDomainSocket,
 		}
+		o.Stop = periodic.NewAborter()
+		monitor := newLoadTestMonitor(o.Stop)
+		done := make(chan struct{})
+		go monitor.watch(ctx, 0, done)
 		res, err = fgrpc.RunGRPCTest(&o)
+		close(done)
+		abortReason = monitor.reason()
 	} else {
 		o := fhttp.HTTPRunnerOptions{
 			HTTPOptions:        *httpOpts,
 			RunnerOptions:      ro,
 			Profiler:           "",
 			AllowInitialErrors: opts.AllowInitialErrors,
-			AbortOn:            0,
+			AbortOn:            opts.AbortOn,
+		}
+		o.Stop = periodic.NewAborter()
+		monitor := newRunMonitor(o.Stop, opts)
+		res, err = runHTTPTest(ctx, &o, monitor, opts.MaxErrorRate)
+		if err == nil {
+			snapshots = monitor.takeSnapshots()
+			abortReason = monitor.reason()
 		}
-		res, err = fhttp.RunHTTPTest(&o)
 	}
 	if err != nil {
 		err = errors.Wrap(err, "error while running tests")
@@ -147,6 +210,31 @@ func FortioLoadTest(opts *models.LoadTestOptions) (map[string]interface{}, *peri
 		logrus.Error(err)
 		return nil, nil, err
 	}
+	if abortReason != "" {
+		resultsMap["abort_reason"] = abortReason
+	}
+	if len(snapshots) > 0 {
+		resultsMap["snapshots"] = snapshots
+	}
 	logrus.Debugf("Mapped version of the test: %+#v", resultsMap)
 	return resultsMap, result, nil
 }
+
+// writeTempFile writes the data to a new temporary file and returns its path
+func writeTempFile(pattern string, data []byte) (string, error) {
+	f, err := ioutil.TempFile("", pattern)
+	if err != nil {
+		err = errors.Wrap(err, "unable to create a temporary file")
+		logrus.Error(err)
+		return "", err
+	}
+	_, err = f.Write(data)
+	_ = f.Close()
+	if err != nil {
+		_ = os.Remove(f.Name())
+		err = errors.Wrap(err, "unable to write to the temporary file")
+		logrus.Error(err)
+		return "", err
+	}
+	return f.Name(), nil
+}
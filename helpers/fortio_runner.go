@@ -0,0 +1,272 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"fortio.org/fortio/fhttp"
+	"fortio.org/fortio/periodic"
+	"fortio.org/fortio/stats"
+	"github.com/layer5io/meshery/models"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// errorRateCheckInterval is how often the error rate of a running load test is evaluated
+	errorRateCheckInterval = time.Second
+	// minRequestsForErrorRate is the number of requests needed before the error rate is considered meaningful
+	minRequestsForErrorRate = 100
+)
+
+// loadTestMonitor holds the live state shared by all the threads of a load test and aborts
+// the run when it is cancelled or when it goes wrong. It also takes the interim snapshots of the run.
+type loadTestMonitor struct {
+	aborter *periodic.Aborter
+	// finished is closed once watch returns
+	finished chan struct{}
+
+	requests int64
+	errors   int64
+
+	reasonLock  sync.Mutex
+	abortReason string
+
+	snapshotInterval time.Duration
+	onSnapshot       func(*models.LoadTestSnapshot)
+	// the window holds the requests recorded since the last snapshot
+	windowLock   sync.Mutex
+	window       *stats.Histogram
+	windowErrors int64
+	windowStart  time.Time
+	snapshots    []*models.LoadTestSnapshot
+}
+
+func newLoadTestMonitor(aborter *periodic.Aborter) *loadTestMonitor {
+	return &loadTestMonitor{
+		aborter:  aborter,
+		finished: make(chan struct{}),
+	}
+}
+
+// newRunMonitor returns a monitor taking snapshots as set in opts
+func newRunMonitor(aborter *periodic.Aborter, opts *models.LoadTestOptions) *loadTestMonitor {
+	m := newLoadTestMonitor(aborter)
+	if opts.SnapshotInterval > 0 {
+		m.snapshotInterval = opts.SnapshotInterval
+		m.onSnapshot = opts.OnSnapshot
+		m.window = stats.NewHistogram(0, periodic.DefaultRunnerOptions.Resolution)
+		m.windowStart = time.Now()
+	}
+	return m
+}
+
+// record accounts for a single request with the given status code, -1 being a socket error, which took latency seconds
+func (m *loadTestMonitor) record(code int, latency float64) {
+	atomic.AddInt64(&m.requests, 1)
+	failed := code < http.StatusOK || code >= http.StatusBadRequest
+	if failed {
+		atomic.AddInt64(&m.errors, 1)
+	}
+	if m.window == nil {
+		return
+	}
+	m.windowLock.Lock()
+	m.window.Record(latency)
+	if failed {
+		m.windowErrors++
+	}
+	m.windowLock.Unlock()
+}
+
+// snapshot takes a snapshot of the requests recorded since the previous one. The last snapshot of a run is
+// only kept when it holds requests, as it usually covers less than the interval.
+func (m *loadTestMonitor) snapshot(now time.Time, last bool) {
+	m.windowLock.Lock()
+	window, errors, start := m.window, m.windowErrors, m.windowStart
+	m.window = stats.NewHistogram(0, periodic.DefaultRunnerOptions.Resolution)
+	m.windowErrors = 0
+	m.windowStart = now
+	m.windowLock.Unlock()
+	if last && window.Count == 0 {
+		return
+	}
+
+	s := &models.LoadTestSnapshot{
+		Time:     now,
+		Requests: window.Count,
+		Errors:   errors,
+	}
+	if elapsed := now.Sub(start).Seconds(); elapsed > 0 {
+		s.QPS = float64(window.Count) / elapsed
+	}
+	if window.Count > 0 {
+		data := window.Export().CalcPercentiles([]float64{50, 90, 99})
+		s.Avg = data.Avg * 1000
+		s.P50 = data.Percentiles[0].Value * 1000
+		s.P90 = data.Percentiles[1].Value * 1000
+		s.P99 = data.Percentiles[2].Value * 1000
+	}
+	m.snapshots = append(m.snapshots, s)
+	if m.onSnapshot != nil {
+		m.onSnapshot(s)
+	}
+}
+
+// takeSnapshots waits for watch to return, takes the last snapshot and returns all the snapshots of the run
+func (m *loadTestMonitor) takeSnapshots() []*models.LoadTestSnapshot {
+	<-m.finished
+	if m.window == nil {
+		return nil
+	}
+	m.snapshot(time.Now(), true)
+	return m.snapshots
+}
+
+// errorRate returns the ratio of failed requests so far along with the number of requests
+func (m *loadTestMonitor) errorRate() (float64, int64) {
+	requests := atomic.LoadInt64(&m.requests)
+	if requests == 0 {
+		return 0, 0
+	}
+	return float64(atomic.LoadInt64(&m.errors)) / float64(requests), requests
+}
+
+// abort stops the run, only the first reason given is kept
+func (m *loadTestMonitor) abort(reason string) {
+	m.reasonLock.Lock()
+	if m.abortReason == "" {
+		m.abortReason = reason
+		logrus.Infof("aborting load test: %s", reason)
+	}
+	m.reasonLock.Unlock()
+	m.aborter.Abort()
+}
+
+// reason returns why the run was aborted, or an empty string if it was not
+func (m *loadTestMonitor) reason() string {
+	m.reasonLock.Lock()
+	defer m.reasonLock.Unlock()
+	return m.abortReason
+}
+
+// watch aborts the run when ctx is done or when the error rate goes over maxErrorRate, if set, and takes the
+// snapshots. It returns once done is closed.
+func (m *loadTestMonitor) watch(ctx context.Context, maxErrorRate float64, done <-chan struct{}) {
+	defer close(m.finished)
+	ticker := time.NewTicker(errorRateCheckInterval)
+	defer ticker.Stop()
+	var snapshots <-chan time.Time
+	if m.window != nil {
+		snapshotTicker := time.NewTicker(m.snapshotInterval)
+		defer snapshotTicker.Stop()
+		snapshots = snapshotTicker.C
+	}
+	for {
+		select {
+		case <-done:
+			return
+		case now := <-snapshots:
+			m.snapshot(now, false)
+		case <-ctx.Done():
+			m.abort("load test cancelled")
+			return
+		case <-ticker.C:
+			if maxErrorRate <= 0 {
+				continue
+			}
+			if rate, requests := m.errorRate(); requests >= minRequestsForErrorRate && rate > maxErrorRate {
+				m.abort(fmt.Sprintf("error rate of %.2f%% is over the threshold of %.2f%%", rate*100, maxErrorRate*100))
+				return
+			}
+		}
+	}
+}
+
+// monitoredHTTPRunner is the per thread fortio runnable, equivalent to the one of fhttp, which
+// also reports every request to the monitor
+type monitoredHTTPRunner struct {
+	client      fhttp.Fetcher
+	retCodes    map[int]int64
+	sizes       *stats.Histogram
+	headerSizes *stats.Histogram
+	abortOn     int
+	monitor     *loadTestMonitor
+}
+
+// Run sends a single request
+func (r *monitoredHTTPRunner) Run(t int) {
+	start := time.Now()
+	code, body, headerSize := r.client.Fetch()
+	r.monitor.record(code, time.Since(start).Seconds())
+	r.retCodes[code]++
+	r.sizes.Record(float64(len(body)))
+	r.headerSizes.Record(float64(headerSize))
+	if r.abortOn != 0 && r.abortOn == code {
+		r.monitor.abort(fmt.Sprintf("received the status code %d", code))
+	}
+}
+
+// runHTTPTest is the equivalent of fhttp.RunHTTPTest which can be cancelled through ctx and
+// aborted once the error rate goes over maxErrorRate. The monitor has to be created with o.Stop as its aborter,
+// the reason of an abort and the snapshots are then available from it.
+func runHTTPTest(ctx context.Context, o *fhttp.HTTPRunnerOptions, monitor *loadTestMonitor, maxErrorRate float64) (*fhttp.HTTPRunnerResults, error) {
+	o.RunType = "HTTP"
+	logrus.Infof("Starting http test for %s with %d threads at %.1f qps", o.URL, o.NumThreads, o.QPS)
+	r := periodic.NewPeriodicRunner(&o.RunnerOptions)
+	defer r.Options().Abort()
+	numThreads := r.Options().NumThreads
+	o.HTTPOptions.Init(o.URL)
+
+	sizes := stats.NewHistogram(0, 100)
+	headerSizes := stats.NewHistogram(0, 5)
+	runners := make([]*monitoredHTTPRunner, numThreads)
+	for i := 0; i < numThreads; i++ {
+		client := fhttp.NewClient(&o.HTTPOptions)
+		if client == nil {
+			return nil, fmt.Errorf("unable to create client %d for %s", i, o.URL)
+		}
+		runners[i] = &monitoredHTTPRunner{
+			client:      client,
+			retCodes:    map[int]int64{},
+			sizes:       sizes.Clone(),
+			headerSizes: headerSizes.Clone(),
+			abortOn:     o.AbortOn,
+			monitor:     monitor,
+		}
+		r.Options().Runners[i] = runners[i]
+		if o.Exactly <= 0 {
+			code, data, _ := client.Fetch()
+			if !o.AllowInitialErrors && code != http.StatusOK {
+				return nil, fmt.Errorf("error %d for %s: %q", code, o.URL, string(data))
+			}
+		}
+	}
+
+	done := make(chan struct{})
+	go monitor.watch(ctx, maxErrorRate, done)
+	runnerResults := r.Run()
+	close(done)
+
+	total := &fhttp.HTTPRunnerResults{
+		RunnerResults: runnerResults,
+		RetCodes:      map[int]int64{},
+		URL:           o.URL,
+		AbortOn:       o.AbortOn,
+	}
+	for _, runner := range runners {
+		total.SocketCount += runner.client.Close()
+		for code, count := range runner.retCodes {
+			total.RetCodes[code] += count
+		}
+		sizes.Transfer(runner.sizes)
+		headerSizes.Transfer(runner.headerSizes)
+	}
+	r.Options().ReleaseRunners()
+	total.Sizes = sizes.Export()
+	total.HeaderSizes = headerSizes.Export()
+	return total, nil
+}
@@ -1,6 +1,7 @@
 package helpers
 
 import (
+	"context"
 	"encoding/json"
 	"strings"
 
@@ -13,8 +14,9 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-// WRK2LoadTest is the actual code which invokes gowrk2 to run the load test
-func WRK2LoadTest(opts *models.LoadTestOptions) (map[string]interface{}, *periodic.RunnerResults, error) {
+// WRK2LoadTest is the actual code which invokes gowrk2 to run the load test.
+// The run is stopped when ctx is done, the results gathered until then are still returned.
+func WRK2LoadTest(ctx context.Context, opts *models.LoadTestOptions) (map[string]interface{}, *periodic.RunnerResults, error) {
 	qps := opts.HTTPQPS // TODO possibly use translated <=0 to "max" from results/options normalization in periodic/
 	if qps <= 0 {
 		qps = -1 // 0==unitialized struct == default duration, -1 (0 for flag) is max
@@ -38,8 +40,7 @@ func WRK2LoadTest(opts *models.LoadTestOptions) (map[string]interface{}, *period
 		logrus.Error(err)
 		return nil, nil, err
 	}
-	var gres *api.GoWRK2
-	gres, err = api.WRKRun(ro)
+	gres, abortReason, err := wrk2Run(ctx, ro, opts)
 	if err == nil {
 		logrus.Debugf("WRK Result: %+v", gres)
 		res, err = api.TransformWRKToFortio(gres, ro)
@@ -60,6 +61,7 @@ func WRK2LoadTest(opts *models.LoadTestOptions) (map[string]interface{}, *period
 		result = gres.Result()
 	} else {
 		hres, _ := res.(*fhttp.HTTPRunnerResults)
+		hres.DurationHistogram = NormalizeHistogram(hres.DurationHistogram)
 		bd, err = json.Marshal(hres)
 		result = hres.Result()
 	}
@@ -76,6 +78,11 @@ func WRK2LoadTest(opts *models.LoadTestOptions) (map[string]interface{}, *period
 		logrus.Error(err)
 		return nil, nil, err
 	}
+	// wrk2 only reports the number of failed requests, not their status codes
+	resultsMap["Errors"] = gres.Errors
+	if abortReason != "" {
+		resultsMap["abort_reason"] = abortReason
+	}
 	logrus.Debugf("Mapped version of the test: %+#v", resultsMap)
 	return resultsMap, result, nil
 }
@@ -0,0 +1,191 @@
+package helpers
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/layer5io/gowrk2/api"
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	wrk2DefaultLocation = "./wrk2/wrk"
+	// wrk2JSONScript is the script used by gowrk2 to get wrk2 to report the results in JSON
+	wrk2JSONScript = "./wrk2/scripts/multiple-endpoints_in_json.lua"
+)
+
+// hasCustomHTTPRequest returns true if the load test needs anything but a plain GET request
+func hasCustomHTTPRequest(opts *models.LoadTestOptions) bool {
+	return (opts.HTTPMethod != "" && opts.HTTPMethod != http.MethodGet) ||
+		len(opts.HTTPHeaders) > 0 ||
+		len(opts.HTTPBody) > 0 ||
+		opts.HTTPContentType != "" ||
+		opts.HTTPUserCredentials != "" ||
+		opts.HTTPReqTimeout > 0
+}
+
+// wrk2RequestScript generates a Lua script which loads the JSON reporting script and then sets up
+// the method, headers and body wrk2 uses for building the requests
+func wrk2RequestScript(opts *models.LoadTestOptions) (string, error) {
+	method := opts.HTTPMethod
+	if method == "" {
+		method = http.MethodGet
+		if len(opts.HTTPBody) > 0 {
+			method = http.MethodPost
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "dofile(%s)\n", luaString(wrk2JSONScript))
+	fmt.Fprintf(&sb, "wrk.method = %s\n", luaString(method))
+	if len(opts.HTTPBody) > 0 {
+		fmt.Fprintf(&sb, "wrk.body = %s\n", luaString(string(opts.HTTPBody)))
+	}
+	for _, hdr := range opts.HTTPHeaders {
+		kv := strings.SplitN(hdr, ":", 2)
+		if len(kv) != 2 {
+			return "", fmt.Errorf("invalid extra header '%s', expecting Key: Value", hdr)
+		}
+		fmt.Fprintf(&sb, "wrk.headers[%s] = %s\n", luaString(strings.TrimSpace(kv[0])), luaString(strings.TrimSpace(kv[1])))
+	}
+	if opts.HTTPContentType != "" {
+		fmt.Fprintf(&sb, "wrk.headers[\"Content-Type\"] = %s\n", luaString(opts.HTTPContentType))
+	}
+	if opts.HTTPUserCredentials != "" {
+		if !strings.Contains(opts.HTTPUserCredentials, ":") {
+			return "", errors.New("invalid user credentials, expecting user:password")
+		}
+		auth := "Basic " + base64.StdEncoding.EncodeToString([]byte(opts.HTTPUserCredentials))
+		fmt.Fprintf(&sb, "wrk.headers[\"Authorization\"] = %s\n", luaString(auth))
+	}
+	return sb.String(), nil
+}
+
+// luaString returns s as a quoted Lua string literal, escaping everything but printable ASCII
+func luaString(s string) string {
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		b := s[i]
+		switch {
+		case b == '\\' || b == '"':
+			sb.WriteByte('\\')
+			sb.WriteByte(b)
+		case b >= 0x20 && b < 0x7f:
+			sb.WriteByte(b)
+		default:
+			fmt.Fprintf(&sb, "\\%03d", b)
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
+
+// wrk2Run is the equivalent of api.WRKRun which can be cancelled through ctx and runs wrk2 with a
+// generated request script when the requests need customizing.
+// wrk2 stops gracefully on an interrupt, so the results gathered until a cancellation are still returned
+// along with the reason of the abort.
+func wrk2Run(ctx context.Context, config *api.GoWRK2Config, opts *models.LoadTestOptions) (*api.GoWRK2, string, error) {
+	wrkLoc := wrk2DefaultLocation
+	if wrkLocFromEnv := os.Getenv("WRK_LOCATION"); wrkLocFromEnv != "" {
+		wrkLoc = wrkLocFromEnv
+	}
+	rURLI, err := url.Parse(config.URL)
+	if err != nil || !rURLI.IsAbs() {
+		err = fmt.Errorf("given URL (%s) is not a valid URL", config.URL)
+		logrus.Error(err)
+		return nil, "", err
+	}
+	if rURLI.Port() == "" {
+		if rURLI.Scheme == "https" {
+			rURLI.Host += ":443"
+		} else {
+			rURLI.Host += ":80"
+		}
+	}
+
+	scriptFile := wrk2JSONScript
+	if hasCustomHTTPRequest(opts) {
+		script, err := wrk2RequestScript(opts)
+		if err != nil {
+			return nil, "", err
+		}
+		scriptFile, err = writeTempFile("meshery-wrk2-*.lua", []byte(script))
+		if err != nil {
+			return nil, "", err
+		}
+		defer func() {
+			_ = os.Remove(scriptFile)
+		}()
+	}
+
+	dur := strconv.FormatFloat(config.DurationInSeconds, 'f', -1, 64)
+	args := []string{"-t" + strconv.Itoa(config.Thread),
+		"-d" + dur + "s",
+		"-R" + strconv.FormatFloat(config.RQPS, 'f', -1, 64),
+		"-s", scriptFile}
+	if opts.HTTPReqTimeout > 0 {
+		args = append(args, "--timeout", strconv.FormatFloat(opts.HTTPReqTimeout.Seconds(), 'f', -1, 64)+"s")
+	}
+	args = append(args, rURLI.String())
+	logrus.Debugf("received command: wrk %v", args)
+
+	var (
+		stdout      bytes.Buffer
+		abortReason string
+	)
+	cmd := exec.Command(wrkLoc, args...)
+	cmd.Stdout = &stdout
+	startTime := time.Now()
+	if err = cmd.Start(); err != nil {
+		err = errors.Wrapf(err, "unable to execute the requested command")
+		logrus.Error(err)
+		return nil, "", err
+	}
+	waitChan := make(chan error, 1)
+	go func() {
+		waitChan <- cmd.Wait()
+	}()
+	select {
+	case err = <-waitChan:
+	case <-ctx.Done():
+		abortReason = "load test cancelled"
+		logrus.Infof("aborting load test: %s", abortReason)
+		_ = cmd.Process.Signal(os.Interrupt)
+		err = <-waitChan
+	}
+	if err != nil {
+		err = errors.Wrapf(err, "unable to execute the requested command")
+		logrus.Error(err)
+		return nil, "", err
+	}
+	out := stdout.Bytes()
+	logrus.Debugf("Received output: %s", out)
+
+	// wrk2 may print some text before the JSON results
+	if ind := bytes.IndexByte(out, '{'); ind > 0 {
+		out = out[ind:]
+	}
+	var raw *api.GoWRK2
+	if err := json.Unmarshal(out, &raw); err != nil {
+		err = errors.Wrapf(err, "unable to unmarshal the result")
+		logrus.Error(err)
+		return nil, "", err
+	}
+	raw.StartTime = startTime
+	raw.RequestedDuration = dur + "s"
+	raw.RequestedQPS = fmt.Sprintf("%f", config.RQPS)
+	return raw, abortReason, nil
+}
@@ -0,0 +1,141 @@
+package helpers
+
+import (
+	"time"
+
+	"fortio.org/fortio/periodic"
+	"fortio.org/fortio/stats"
+)
+
+// MergeRunnerResults merges the results of several runs into one.
+// The duration histograms are merged by re-recording every bucket at its mid point, so the merged
+// percentiles are approximations within the bucket resolution, while count, min, max and sum stay exact.
+func MergeRunnerResults(results []*periodic.RunnerResults) *periodic.RunnerResults {
+	if len(results) == 0 {
+		return nil
+	}
+	merged := *results[0]
+
+	var (
+		duration    time.Duration
+		percentiles []float64
+		histograms  []*stats.HistogramData
+	)
+	for _, r := range results {
+		if r.StartTime.Before(merged.StartTime) {
+			merged.StartTime = r.StartTime
+		}
+		if r.NumThreads > merged.NumThreads {
+			merged.NumThreads = r.NumThreads
+		}
+		duration += r.ActualDuration
+		if r.DurationHistogram != nil {
+			histograms = append(histograms, r.DurationHistogram)
+			if len(percentiles) == 0 {
+				for _, p := range r.DurationHistogram.Percentiles {
+					percentiles = append(percentiles, p.Percentile)
+				}
+			}
+		}
+	}
+	if len(percentiles) == 0 {
+		percentiles = periodic.DefaultRunnerOptions.Percentiles
+	}
+
+	merged.ActualDuration = duration
+	merged.RequestedDuration = duration.String()
+	merged.DurationHistogram = MergeHistogramData(percentiles, histograms...)
+	if duration > 0 {
+		merged.ActualQPS = float64(merged.DurationHistogram.Count) / duration.Seconds()
+	}
+	return &merged
+}
+
+// NormalizeHistogram returns the histogram in the fortio format: gowrk2 exports the cumulative count of the requests
+// up to each bucket and no sum of the durations, so its buckets are turned into per bucket counts and the sum is
+// derived from the average. The other histograms are returned as they are.
+func NormalizeHistogram(d *stats.HistogramData) *stats.HistogramData {
+	if d == nil || d.Count == 0 || d.Sum != 0 || d.Avg <= 0 {
+		return d
+	}
+	n := *d
+	n.Sum = d.Avg * float64(d.Count)
+	n.Data = make([]stats.Bucket, 0, len(d.Data))
+	var cumulated int64
+	for _, b := range d.Data {
+		if b.Count <= cumulated {
+			continue
+		}
+		b.Count, cumulated = b.Count-cumulated, b.Count
+		n.Data = append(n.Data, b)
+	}
+	return &n
+}
+
+// MergeHistogramData merges exported fortio histograms and calculates the given percentiles on the result
+func MergeHistogramData(percentiles []float64, data ...*stats.HistogramData) *stats.HistogramData {
+	h := stats.NewHistogram(0, periodic.DefaultRunnerOptions.Resolution)
+	var (
+		count    int64
+		min, max float64
+		sum      float64
+	)
+	for _, d := range data {
+		if d == nil || d.Count == 0 {
+			continue
+		}
+		if count == 0 || d.Min < min {
+			min = d.Min
+		}
+		if count == 0 || d.Max > max {
+			max = d.Max
+		}
+		d = NormalizeHistogram(d)
+		count += d.Count
+		sum += d.Sum
+		for _, b := range d.Data {
+			h.RecordN((b.Start+b.End)/2, int(b.Count))
+		}
+	}
+
+	res := h.Export()
+	if count == 0 {
+		return res
+	}
+	// the exact values are known, unlike the bucket mid points recorded above
+	res.Count = count
+	res.Min = min
+	res.Max = max
+	res.Sum = sum
+	res.Avg = sum / float64(count)
+	if len(res.Data) > 0 {
+		res.Data[0].Start = min
+		res.Data[len(res.Data)-1].End = max
+	}
+	return res.CalcPercentiles(percentiles)
+}
+
+// HistogramPercentiles returns the given percentiles of the histogram. The ones reported with the histogram are kept
+// as they are, wrk2 computing them from its own exact histogram, the others are calculated from its buckets.
+func HistogramPercentiles(d *stats.HistogramData, percentiles []float64) []stats.Percentile {
+	if d == nil || d.Count == 0 {
+		return nil
+	}
+	reported := map[float64]float64{}
+	for _, p := range d.Percentiles {
+		reported[p.Percentile] = p.Value
+	}
+	n := NormalizeHistogram(d)
+	res := make([]stats.Percentile, 0, len(percentiles))
+	for _, p := range percentiles {
+		v, ok := reported[p]
+		if !ok {
+			if len(n.Data) == 0 {
+				continue
+			}
+			v = n.CalcPercentile(p)
+		}
+		res = append(res, stats.Percentile{Percentile: p, Value: v})
+	}
+	return res
+}
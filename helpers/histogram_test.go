@@ -0,0 +1,215 @@
+package helpers
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"fortio.org/fortio/periodic"
+	"fortio.org/fortio/stats"
+)
+
+func fortioHistogram(values ...float64) *stats.HistogramData {
+	h := stats.NewHistogram(0, periodic.DefaultRunnerOptions.Resolution)
+	for _, v := range values {
+		h.Record(v)
+	}
+	return h.Export().CalcPercentiles([]float64{50, 99})
+}
+
+// wrk2Histogram returns a histogram the way gowrk2 exports it: cumulative bucket counts and no sum
+func wrk2Histogram(avg float64, count int64, buckets ...stats.Bucket) *stats.HistogramData {
+	return &stats.HistogramData{
+		Count: count,
+		Avg:   avg,
+		Min:   buckets[0].Start,
+		Max:   buckets[len(buckets)-1].End,
+		Data:  buckets,
+	}
+}
+
+func bucket(start, end float64, count int64) stats.Bucket {
+	return stats.Bucket{Interval: stats.Interval{Start: start, End: end}, Count: count}
+}
+
+func TestNormalizeHistogram(t *testing.T) {
+	tests := []struct {
+		name       string
+		in         *stats.HistogramData
+		wantCounts []int64
+		wantSum    float64
+	}{
+		{
+			name:       "nil",
+			in:         nil,
+			wantCounts: nil,
+		},
+		{
+			name:       "fortio histogram unchanged",
+			in:         fortioHistogram(0.001, 0.002, 0.002, 0.004),
+			wantCounts: []int64{1, 2, 1},
+			wantSum:    0.009,
+		},
+		{
+			name:       "wrk2 histogram de-accumulated",
+			in:         wrk2Histogram(0.002, 100, bucket(0.001, 0.002, 50), bucket(0.002, 0.003, 90), bucket(0.003, 0.01, 100)),
+			wantCounts: []int64{50, 40, 10},
+			wantSum:    0.2,
+		},
+		{
+			name:       "wrk2 empty buckets dropped",
+			in:         wrk2Histogram(0.002, 10, bucket(0.001, 0.002, 5), bucket(0.002, 0.002, 5), bucket(0.002, 0.01, 10)),
+			wantCounts: []int64{5, 5},
+			wantSum:    0.02,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NormalizeHistogram(tt.in)
+			if tt.in == nil {
+				if got != nil {
+					t.Fatalf("NormalizeHistogram(nil) = %v, want nil", got)
+				}
+				return
+			}
+			var counts []int64
+			for _, b := range got.Data {
+				counts = append(counts, b.Count)
+			}
+			if len(counts) != len(tt.wantCounts) {
+				t.Fatalf("bucket counts = %v, want %v", counts, tt.wantCounts)
+			}
+			for i := range counts {
+				if counts[i] != tt.wantCounts[i] {
+					t.Fatalf("bucket counts = %v, want %v", counts, tt.wantCounts)
+				}
+			}
+			if math.Abs(got.Sum-tt.wantSum) > 1e-9 {
+				t.Errorf("sum = %g, want %g", got.Sum, tt.wantSum)
+			}
+		})
+	}
+}
+
+func TestMergeHistogramData(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      []*stats.HistogramData
+		wantCount int64
+		wantAvg   float64
+		wantMin   float64
+		wantMax   float64
+	}{
+		{
+			name:      "fortio histograms",
+			data:      []*stats.HistogramData{fortioHistogram(0.001, 0.003), fortioHistogram(0.002, 0.006)},
+			wantCount: 4,
+			wantAvg:   0.003,
+			wantMin:   0.001,
+			wantMax:   0.006,
+		},
+		{
+			name: "wrk2 histograms",
+			data: []*stats.HistogramData{
+				wrk2Histogram(0.002, 100, bucket(0.001, 0.002, 50), bucket(0.002, 0.004, 100)),
+				wrk2Histogram(0.004, 100, bucket(0.002, 0.004, 50), bucket(0.004, 0.008, 100)),
+			},
+			wantCount: 200,
+			wantAvg:   0.003,
+			wantMin:   0.001,
+			wantMax:   0.008,
+		},
+		{
+			name:      "empty histograms skipped",
+			data:      []*stats.HistogramData{nil, {}, fortioHistogram(0.002)},
+			wantCount: 1,
+			wantAvg:   0.002,
+			wantMin:   0.002,
+			wantMax:   0.002,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MergeHistogramData([]float64{50}, tt.data...)
+			if got.Count != tt.wantCount {
+				t.Errorf("count = %d, want %d", got.Count, tt.wantCount)
+			}
+			var buckets int64
+			for _, b := range got.Data {
+				buckets += b.Count
+			}
+			if buckets != tt.wantCount {
+				t.Errorf("requests in the buckets = %d, want %d", buckets, tt.wantCount)
+			}
+			if math.Abs(got.Avg-tt.wantAvg) > 1e-9 {
+				t.Errorf("avg = %g, want %g", got.Avg, tt.wantAvg)
+			}
+			if got.Min != tt.wantMin || got.Max != tt.wantMax {
+				t.Errorf("min, max = %g, %g, want %g, %g", got.Min, got.Max, tt.wantMin, tt.wantMax)
+			}
+			if len(got.Percentiles) != 1 {
+				t.Errorf("percentiles = %v, want p50 only", got.Percentiles)
+			}
+		})
+	}
+}
+
+func TestMergeRunnerResults(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	results := []*periodic.RunnerResults{
+		{
+			StartTime:         start.Add(10 * time.Second),
+			ActualDuration:    10 * time.Second,
+			NumThreads:        4,
+			DurationHistogram: wrk2Histogram(0.002, 100, bucket(0.001, 0.002, 50), bucket(0.002, 0.004, 100)),
+		},
+		{
+			StartTime:         start,
+			ActualDuration:    10 * time.Second,
+			NumThreads:        8,
+			DurationHistogram: wrk2Histogram(0.002, 300, bucket(0.001, 0.002, 150), bucket(0.002, 0.004, 300)),
+		},
+	}
+	got := MergeRunnerResults(results)
+	if !got.StartTime.Equal(start) {
+		t.Errorf("start time = %v, want %v", got.StartTime, start)
+	}
+	if got.NumThreads != 8 {
+		t.Errorf("threads = %d, want 8", got.NumThreads)
+	}
+	if got.ActualDuration != 20*time.Second {
+		t.Errorf("duration = %v, want 20s", got.ActualDuration)
+	}
+	if got.ActualQPS != 20 {
+		t.Errorf("qps = %g, want 20", got.ActualQPS)
+	}
+	if got.DurationHistogram.Avg == 0 {
+		t.Error("avg = 0, want the average of the stages")
+	}
+}
+
+func TestHistogramPercentiles(t *testing.T) {
+	wrk2 := wrk2Histogram(0.003, 100,
+		stats.Bucket{Interval: stats.Interval{Start: 0.001, End: 0.002}, Count: 50, Percent: 50},
+		stats.Bucket{Interval: stats.Interval{Start: 0.002, End: 0.004}, Count: 90, Percent: 90},
+		stats.Bucket{Interval: stats.Interval{Start: 0.004, End: 0.01}, Count: 100, Percent: 100},
+	)
+	wrk2.Percentiles = []stats.Percentile{{Percentile: 99, Value: 0.0097}}
+
+	got := HistogramPercentiles(wrk2, []float64{75, 99})
+	want := []stats.Percentile{{Percentile: 75, Value: 0.00325}, {Percentile: 99, Value: 0.0097}}
+	if len(got) != len(want) {
+		t.Fatalf("percentiles = %v, want %v", got, want)
+	}
+	for i := range got {
+		if got[i].Percentile != want[i].Percentile || math.Abs(got[i].Value-want[i].Value) > 1e-9 {
+			t.Errorf("percentiles = %v, want %v", got, want)
+		}
+	}
+	if wrk2.Data[1].Count != 90 {
+		t.Error("the histogram should not be modified")
+	}
+	if got := HistogramPercentiles(nil, []float64{50}); got != nil {
+		t.Errorf("percentiles of no histogram = %v, want none", got)
+	}
+}
@@ -5,8 +5,10 @@ import (
 
 	"fmt"
 
+	"github.com/layer5io/meshery/models"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 )
@@ -84,6 +86,62 @@ func ScanPromGrafana(kubeconfig []byte, contextName string) (map[string][]string
 }
 
 func detectServiceForDeploymentImage(kubeconfig []byte, contextName string, imageNames []string) (map[string][]string, error) {
+	services, err := detectServicesForImages(kubeconfig, contextName, imageNames)
+	if err != nil {
+		return nil, err
+	}
+	result := map[string][]string{}
+	for _, sv := range services {
+		ports := []string{}
+		for _, spr := range sv.Spec.Ports {
+			logrus.Debugf("protocol: %s, port: %d", spr.Protocol, spr.Port)
+			ports = append(ports, fmt.Sprintf("%d", spr.Port))
+		}
+		result[sv.GetName()+"."+sv.GetNamespace()] = ports
+	}
+	logrus.Debugf("Derived tags: %s", result)
+	return result, nil
+}
+
+// DiscoverTelemetry - finds the Prometheus and Grafana services of the cluster, with the URLs reaching them
+func DiscoverTelemetry(kubeconfig []byte, contextName string) ([]*models.TelemetryCandidate, error) {
+	candidates := []*models.TelemetryCandidate{}
+	for _, telemetryType := range []models.TelemetryType{models.TelemetryPrometheus, models.TelemetryGrafana} {
+		services, err := detectServicesForImages(kubeconfig, contextName, []string{string(telemetryType)})
+		if err != nil {
+			return nil, err
+		}
+		for _, sv := range services {
+			for _, spr := range sv.Spec.Ports {
+				if spr.Protocol != "" && spr.Protocol != corev1.ProtocolTCP {
+					continue
+				}
+				candidate := &models.TelemetryCandidate{
+					Type:      telemetryType,
+					Name:      sv.GetName(),
+					Namespace: sv.GetNamespace(),
+					Port:      spr.Port,
+					URL:       fmt.Sprintf("http://%s.%s.svc:%d", sv.GetName(), sv.GetNamespace(), spr.Port),
+				}
+				// the service proxy is optional, the in-cluster URL works without it
+				candidate.ProxyURL, err = KubernetesServiceProxyURL(kubeconfig, contextName, &models.KubernetesService{
+					Namespace: sv.GetNamespace(),
+					Name:      sv.GetName(),
+					Port:      spr.Port,
+				})
+				if err != nil {
+					logrus.Warn(errors.Wrap(err, "unable to build the kubernetes service proxy URL"))
+				}
+				candidates = append(candidates, candidate)
+			}
+		}
+	}
+	return candidates, nil
+}
+
+// detectServicesForImages returns the services of the pods of the deployments and stateful sets running one of the
+// images
+func detectServicesForImages(kubeconfig []byte, contextName string, imageNames []string) ([]corev1.Service, error) {
 	clientset, err := getK8SClientSet(kubeconfig, contextName)
 	if err != nil {
 		return nil, err
@@ -94,61 +152,74 @@ func detectServiceForDeploymentImage(kubeconfig []byte, contextName string, imag
 		logrus.Error(err)
 		return nil, err
 	}
-	result := map[string][]string{}
+	result := []corev1.Service{}
 
 	for _, ns := range namespacelist.Items {
 		logrus.Debugf("Listing deployments in namespace %q", ns.GetName())
 
-		deploymentsClient := clientset.AppsV1().Deployments(ns.GetName())
-		deplist, err := deploymentsClient.List(metav1.ListOptions{})
+		deplist, err := clientset.AppsV1().Deployments(ns.GetName()).List(metav1.ListOptions{})
 		if err != nil {
 			err = errors.Wrapf(err, "unable to get deployments in the %s namespace", ns.GetName())
 			logrus.Error(err)
 			return nil, err
 		}
-
+		// prometheus operator runs prometheus in stateful sets
+		stslist, err := clientset.AppsV1().StatefulSets(ns.GetName()).List(metav1.ListOptions{})
+		if err != nil {
+			err = errors.Wrapf(err, "unable to get stateful sets in the %s namespace", ns.GetName())
+			logrus.Error(err)
+			return nil, err
+		}
+		templates := []corev1.PodTemplateSpec{}
 		for _, d := range deplist.Items {
-			logrus.Debugf(" * %s (%d replicas)", d.Name, *d.Spec.Replicas)
-			foundDeployment := false
-			for _, cont := range d.Spec.Template.Spec.Containers {
-				for _, imageName := range imageNames {
-					if strings.HasPrefix(cont.Image, imageName) || strings.Contains(cont.Image, imageName+":") {
-						foundDeployment = true
-						break
-					}
-				}
-				if foundDeployment {
-					break
-				}
+			templates = append(templates, d.Spec.Template)
+		}
+		for _, sts := range stslist.Items {
+			templates = append(templates, sts.Spec.Template)
+		}
+
+		var podLabels []labels.Set
+		for _, template := range templates {
+			if runsImage(template.Spec.Containers, imageNames) {
+				logrus.Debugf("found workload with labels: %v", template.ObjectMeta.GetLabels())
+				podLabels = append(podLabels, labels.Set(template.ObjectMeta.GetLabels()))
 			}
-			if foundDeployment {
-				logrus.Debugf("found deployment: %s", d.GetName())
-				lbls := d.Spec.Template.ObjectMeta.GetLabels()
-				svcClient := clientset.CoreV1().Services(ns.GetName())
-				svcList, err := svcClient.List(metav1.ListOptions{
-					LabelSelector: labels.SelectorFromSet(lbls).String(),
-				})
-				if err != nil {
-					err = errors.Wrapf(err, "unable to get deployments in the %s namespace", ns.GetName())
-					logrus.Error(err)
-					return nil, err
-				}
-				for _, sv := range svcList.Items {
+		}
+		if len(podLabels) == 0 {
+			continue
+		}
+
+		svcList, err := clientset.CoreV1().Services(ns.GetName()).List(metav1.ListOptions{})
+		if err != nil {
+			err = errors.Wrapf(err, "unable to get services in the %s namespace", ns.GetName())
+			logrus.Error(err)
+			return nil, err
+		}
+		for _, sv := range svcList.Items {
+			if len(sv.Spec.Selector) == 0 {
+				continue
+			}
+			selector := labels.SelectorFromSet(sv.Spec.Selector)
+			for _, lbls := range podLabels {
+				if selector.Matches(lbls) {
 					logrus.Debugf("Service Name: %s", sv.GetName())
 					logrus.Debugf("Service type: %s", sv.Spec.Type)
-					ports := []string{}
-					for _, spr := range sv.Spec.Ports {
-						logrus.Debugf("protocol: %s, port: %d", spr.Protocol, spr.Port)
-						ports = append(ports, fmt.Sprintf("%d", spr.Port))
-					}
-					result[sv.GetName()+"."+sv.GetNamespace()] = ports
+					result = append(result, sv)
+					break
 				}
 			}
 		}
 	}
-	logrus.Debugf("Derived tags: %s", result)
-
-	// use that to go thru services with the given tags
-	// from there get the ports and service type
 	return result, nil
 }
+
+func runsImage(containers []corev1.Container, imageNames []string) bool {
+	for _, cont := range containers {
+		for _, imageName := range imageNames {
+			if strings.HasPrefix(cont.Image, imageName) || strings.Contains(cont.Image, imageName+":") {
+				return true
+			}
+		}
+	}
+	return false
+}
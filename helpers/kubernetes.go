@@ -1,18 +1,41 @@
 package helpers
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/layer5io/meshery/models"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
 func getK8SClientSet(kubeconfig []byte, contextName string) (*kubernetes.Clientset, error) {
+	clientConfig, err := getK8SRestConfig(kubeconfig, contextName)
+	if err != nil {
+		return nil, err
+	}
+	clientConfig.Timeout = 2 * time.Second
+	clientset, err := kubernetes.NewForConfig(clientConfig)
+	if err != nil {
+		err = errors.Wrap(err, "unable to create client set")
+		logrus.Error(err)
+		return nil, err
+	}
+	return clientset, nil
+}
+
+func getK8SRestConfig(kubeconfig []byte, contextName string) (*rest.Config, error) {
 	var clientConfig *rest.Config
 	var err error
 	if len(kubeconfig) == 0 {
@@ -39,14 +62,43 @@ func getK8SClientSet(kubeconfig []byte, contextName string) (*kubernetes.Clients
 			return nil, err
 		}
 	}
-	clientConfig.Timeout = 2 * time.Second
-	clientset, err := kubernetes.NewForConfig(clientConfig)
+	return clientConfig, nil
+}
+
+// KubernetesServiceProxyURL returns the URL reaching the port of the service through the service proxy of the
+// kubernetes API server
+func KubernetesServiceProxyURL(kubeconfig []byte, contextName string, service *models.KubernetesService) (string, error) {
+	clientConfig, err := getK8SRestConfig(kubeconfig, contextName)
 	if err != nil {
-		err = errors.Wrap(err, "unable to create client set")
+		return "", err
+	}
+	host := strings.TrimSuffix(clientConfig.Host, "/")
+	if !strings.Contains(host, "://") {
+		host = "https://" + host
+	}
+	return fmt.Sprintf("%s/api/v1/namespaces/%s/services/%s:%d/proxy", host, service.Namespace, service.Name, service.Port), nil
+}
+
+// KubernetesTransport returns the RoundTripper authenticating the requests to the kubernetes API server with the
+// credentials of the kubeconfig, authentication plugins included
+func KubernetesTransport(kubeconfig []byte, contextName string) (http.RoundTripper, error) {
+	clientConfig, err := getK8SRestConfig(kubeconfig, contextName)
+	if err != nil {
+		return nil, err
+	}
+	rt, err := rest.TransportFor(clientConfig)
+	if err != nil {
+		err = errors.Wrap(err, "unable to create the kubernetes API transport")
 		logrus.Error(err)
 		return nil, err
 	}
-	return clientset, nil
+	return rt, nil
+}
+
+// RunsInCluster tells whether Meshery runs in a pod, the in-cluster URLs of the services being reachable
+func RunsInCluster() bool {
+	_, err := rest.InClusterConfig()
+	return err == nil
 }
 
 // FetchKubernetesNodes - function used to fetch nodes metadata
@@ -124,3 +176,166 @@ func FetchKubernetesVersion(kubeconfig []byte, contextName string) (string, erro
 	logrus.Debugf("Kubernetes API Server version: %s", serverVersion.String())
 	return serverVersion.String(), nil
 }
+
+// podsReadyCheckInterval is how often the pods are checked while waiting for them to be ready
+const podsReadyCheckInterval = 2 * time.Second
+
+// CreateNamespace creates the namespace, failing when it already exists so that the workloads of others are never
+// touched
+func CreateNamespace(kubeconfig []byte, contextName, namespace string) error {
+	clientset, err := getK8SClientSet(kubeconfig, contextName)
+	if err != nil {
+		return err
+	}
+	_, err = clientset.CoreV1().Namespaces().Create(&corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: namespace,
+		},
+	})
+	if apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("the namespace %s already exists, provide a namespace dedicated to the benchmark", namespace)
+	}
+	if err != nil {
+		err = errors.Wrapf(err, "unable to create the namespace %s", namespace)
+		logrus.Error(err)
+		return err
+	}
+	return nil
+}
+
+// DeleteNamespace deletes the namespace along with everything in it
+func DeleteNamespace(kubeconfig []byte, contextName, namespace string) error {
+	clientset, err := getK8SClientSet(kubeconfig, contextName)
+	if err != nil {
+		return err
+	}
+	if err = clientset.CoreV1().Namespaces().Delete(namespace, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		err = errors.Wrapf(err, "unable to delete the namespace %s", namespace)
+		logrus.Error(err)
+		return err
+	}
+	return nil
+}
+
+// EnableSidecarInjection sets the labels and annotations which turn on the sidecar injection of the mesh in the
+// namespace
+func EnableSidecarInjection(kubeconfig []byte, contextName, namespace string, labels, annotations map[string]string) error {
+	clientset, err := getK8SClientSet(kubeconfig, contextName)
+	if err != nil {
+		return err
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels":      labels,
+			"annotations": annotations,
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "unable to marshal the namespace patch")
+	}
+	if _, err = clientset.CoreV1().Namespaces().Patch(namespace, types.MergePatchType, patch); err != nil {
+		err = errors.Wrapf(err, "unable to enable the sidecar injection of the namespace %s", namespace)
+		logrus.Error(err)
+		return err
+	}
+	return nil
+}
+
+// RestartDeployments restarts the pods of the deployments of the namespace matching the label selector, like
+// kubectl rollout restart does
+func RestartDeployments(kubeconfig []byte, contextName, namespace, selector string) error {
+	clientset, err := getK8SClientSet(kubeconfig, contextName)
+	if err != nil {
+		return err
+	}
+	deploymentsClient := clientset.AppsV1().Deployments(namespace)
+	deployments, err := deploymentsClient.List(metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		err = errors.Wrapf(err, "unable to get the list of deployments of %s", namespace)
+		logrus.Error(err)
+		return err
+	}
+	if len(deployments.Items) == 0 {
+		return fmt.Errorf("no deployment of %s matches %s", namespace, selector)
+	}
+	patch := fmt.Sprintf(`{"spec":{"template":{"metadata":{"annotations":{"kubectl.kubernetes.io/restartedAt":%q}}}}}`, time.Now().Format(time.RFC3339))
+	for _, d := range deployments.Items {
+		if _, err = deploymentsClient.Patch(d.Name, types.StrategicMergePatchType, []byte(patch)); err != nil {
+			err = errors.Wrapf(err, "unable to restart the deployment %s", d.Name)
+			logrus.Error(err)
+			return err
+		}
+	}
+	return nil
+}
+
+// WaitForPodsReady waits until the deployments of the namespace matching the label selector are rolled out and their
+// pods are ready, or until ctx is done. It returns the average number of containers per pod, which tells whether
+// sidecars were injected.
+func WaitForPodsReady(ctx context.Context, kubeconfig []byte, contextName, namespace, selector string) (float64, error) {
+	clientset, err := getK8SClientSet(kubeconfig, contextName)
+	if err != nil {
+		return 0, err
+	}
+	ticker := time.NewTicker(podsReadyCheckInterval)
+	defer ticker.Stop()
+	for {
+		containers, ready, err := podsReady(clientset, namespace, selector)
+		if err != nil {
+			err = errors.Wrapf(err, "unable to check the pods of %s", namespace)
+			logrus.Error(err)
+			return 0, err
+		}
+		if ready {
+			return containers, nil
+		}
+		select {
+		case <-ctx.Done():
+			return 0, fmt.Errorf("the pods of %s are not ready: %v", namespace, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+func podsReady(clientset *kubernetes.Clientset, namespace, selector string) (float64, bool, error) {
+	listOptions := metav1.ListOptions{LabelSelector: selector}
+	deployments, err := clientset.AppsV1().Deployments(namespace).List(listOptions)
+	if err != nil {
+		return 0, false, err
+	}
+	for _, d := range deployments.Items {
+		replicas := int32(1)
+		if d.Spec.Replicas != nil {
+			replicas = *d.Spec.Replicas
+		}
+		if d.Status.ObservedGeneration < d.Generation || d.Status.UpdatedReplicas != replicas ||
+			d.Status.AvailableReplicas != replicas || d.Status.Replicas != replicas {
+			return 0, false, nil
+		}
+	}
+
+	pods, err := clientset.CoreV1().Pods(namespace).List(listOptions)
+	if err != nil {
+		return 0, false, err
+	}
+	var count, containers int
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == corev1.PodSucceeded {
+			continue
+		}
+		if pod.DeletionTimestamp != nil || pod.Status.Phase != corev1.PodRunning {
+			return 0, false, nil
+		}
+		for _, c := range pod.Status.ContainerStatuses {
+			if !c.Ready {
+				return 0, false, nil
+			}
+		}
+		count++
+		containers += len(pod.Spec.Containers)
+	}
+	if count == 0 {
+		return 0, false, nil
+	}
+	return float64(containers) / float64(count), true, nil
+}
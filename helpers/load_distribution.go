@@ -0,0 +1,230 @@
+package helpers
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"fortio.org/fortio/periodic"
+	"fortio.org/fortio/stats"
+	"github.com/layer5io/meshery/models"
+)
+
+// SplitLoadTestOptions splits the load test in n equal shares, one for each worker.
+// The rate and the threads of the load test and of each of its stages are divided among the shares,
+// every share getting at least one thread.
+func SplitLoadTestOptions(opts *models.LoadTestOptions, n int) []*models.LoadTestOptions {
+	shares := make([]*models.LoadTestOptions, n)
+	for i := 0; i < n; i++ {
+		share := *opts
+		share.HTTPQPS = opts.HTTPQPS / float64(n)
+		share.HTTPNumThreads = splitThreads(opts.HTTPNumThreads, n, i)
+		share.Stages = nil
+		for _, stage := range opts.Stages {
+			shareStage := *stage
+			shareStage.StartQPS = stage.StartQPS / float64(n)
+			shareStage.QPS = stage.QPS / float64(n)
+			if stage.NumThreads > 0 {
+				shareStage.NumThreads = splitThreads(stage.NumThreads, n, i)
+			}
+			share.Stages = append(share.Stages, &shareStage)
+		}
+		shares[i] = &share
+	}
+	return shares
+}
+
+// splitThreads returns the number of threads of the i-th of n shares
+func splitThreads(threads, n, i int) int {
+	t := threads / n
+	if i < threads%n {
+		t++
+	}
+	if t < 1 {
+		t = 1
+	}
+	return t
+}
+
+// MergeConcurrentRunnerResults merges the results of runs which happened at the same time, like the ones of
+// several workers sharing a load test. Unlike MergeRunnerResults the durations overlap, so the merged run
+// lasts as long as the longest one, and the threads add up.
+func MergeConcurrentRunnerResults(results []*periodic.RunnerResults) *periodic.RunnerResults {
+	if len(results) == 0 {
+		return nil
+	}
+	merged := *results[0]
+	merged.NumThreads = 0
+
+	var (
+		percentiles []float64
+		histograms  []*stats.HistogramData
+	)
+	for _, r := range results {
+		if r.StartTime.Before(merged.StartTime) {
+			merged.StartTime = r.StartTime
+		}
+		if r.ActualDuration > merged.ActualDuration {
+			merged.ActualDuration = r.ActualDuration
+		}
+		merged.NumThreads += r.NumThreads
+		if r.DurationHistogram != nil {
+			histograms = append(histograms, r.DurationHistogram)
+			if len(percentiles) == 0 {
+				for _, p := range r.DurationHistogram.Percentiles {
+					percentiles = append(percentiles, p.Percentile)
+				}
+			}
+		}
+	}
+	if len(percentiles) == 0 {
+		percentiles = periodic.DefaultRunnerOptions.Percentiles
+	}
+
+	merged.DurationHistogram = MergeHistogramData(percentiles, histograms...)
+	if merged.ActualDuration > 0 {
+		merged.ActualQPS = float64(merged.DurationHistogram.Count) / merged.ActualDuration.Seconds()
+	}
+	return &merged
+}
+
+// MergeWorkerResults merges the results of the workers which shared the load test described by opts.
+// The results of each worker are kept under the "workers" key, by worker address.
+func MergeWorkerResults(opts *models.LoadTestOptions, addresses []string, resultsMaps []map[string]interface{}, results []*periodic.RunnerResults) (map[string]interface{}, *periodic.RunnerResults, error) {
+	result := MergeConcurrentRunnerResults(results)
+	if result == nil {
+		return nil, nil, fmt.Errorf("no results received from the workers")
+	}
+	switch {
+	case len(opts.Stages) > 0:
+		result.RequestedQPS = "profile"
+	case opts.HTTPQPS > 0:
+		result.RequestedQPS = fmt.Sprintf("%.9g", opts.HTTPQPS)
+	default:
+		result.RequestedQPS = "max"
+	}
+	resultsMap, err := runnerResultsToMap(result)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		retCodes           = map[string]float64{}
+		socketCount        float64
+		sizes, headerSizes []*stats.HistogramData
+		workers            = map[string]interface{}{}
+		abortReasons       []string
+		snapshots          [][]*models.LoadTestSnapshot
+	)
+	for i, rm := range resultsMaps {
+		addRetCodes(retCodes, rm)
+		sc, _ := rm["SocketCount"].(float64)
+		socketCount += sc
+		if h := histogramDataFromMap(rm["Sizes"]); h != nil {
+			sizes = append(sizes, h)
+		}
+		if h := histogramDataFromMap(rm["HeaderSizes"]); h != nil {
+			headerSizes = append(headerSizes, h)
+		}
+		if reason, _ := rm["abort_reason"].(string); reason != "" {
+			abortReasons = append(abortReasons, fmt.Sprintf("%s on worker %s", reason, addresses[i]))
+		}
+		if s := snapshotsFromMap(rm["snapshots"]); len(s) > 0 {
+			snapshots = append(snapshots, s)
+		}
+		if _, ok := resultsMap["URL"]; !ok && rm["URL"] != nil {
+			resultsMap["URL"] = rm["URL"]
+		}
+		workers[addresses[i]] = rm
+	}
+	resultsMap["RetCodes"] = retCodes
+	if errs, ok := sumErrors(resultsMaps); ok {
+		resultsMap["Errors"] = errs
+	}
+	resultsMap["SocketCount"] = socketCount
+	if len(sizes) > 0 {
+		resultsMap["Sizes"] = MergeHistogramData(nil, sizes...)
+	}
+	if len(headerSizes) > 0 {
+		resultsMap["HeaderSizes"] = MergeHistogramData(nil, headerSizes...)
+	}
+	if len(abortReasons) > 0 {
+		resultsMap["abort_reason"] = strings.Join(abortReasons, "; ")
+	}
+	if len(snapshots) > 0 {
+		resultsMap["snapshots"] = mergeWorkerSnapshots(snapshots)
+	}
+	resultsMap["workers"] = workers
+	return resultsMap, result, nil
+}
+
+// mergeWorkerSnapshots merges the snapshots the workers took at the same time, the workers starting together.
+// The counts and rates are summed while the latencies are the highest ones, as the histograms of the snapshots
+// are not kept.
+func mergeWorkerSnapshots(snapshots [][]*models.LoadTestSnapshot) []*models.LoadTestSnapshot {
+	merged := []*models.LoadTestSnapshot{}
+	for i := 0; ; i++ {
+		var m *models.LoadTestSnapshot
+		for _, worker := range snapshots {
+			if i >= len(worker) {
+				continue
+			}
+			s := worker[i]
+			if m == nil {
+				m = &models.LoadTestSnapshot{Time: s.Time}
+			}
+			if s.Time.After(m.Time) {
+				m.Time = s.Time
+			}
+			if m.Requests+s.Requests > 0 {
+				m.Avg = (m.Avg*float64(m.Requests) + s.Avg*float64(s.Requests)) / float64(m.Requests+s.Requests)
+			}
+			m.Requests += s.Requests
+			m.Errors += s.Errors
+			m.QPS += s.QPS
+			m.P50 = math.Max(m.P50, s.P50)
+			m.P90 = math.Max(m.P90, s.P90)
+			m.P99 = math.Max(m.P99, s.P99)
+		}
+		if m == nil {
+			return merged
+		}
+		merged = append(merged, m)
+	}
+}
+
+// snapshotsFromMap returns the snapshots of a results map, whether they went through JSON or not
+func snapshotsFromMap(v interface{}) []*models.LoadTestSnapshot {
+	if snapshots, ok := v.([]*models.LoadTestSnapshot); ok {
+		return snapshots
+	}
+	if v == nil {
+		return nil
+	}
+	bd, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	snapshots := []*models.LoadTestSnapshot{}
+	if err := json.Unmarshal(bd, &snapshots); err != nil {
+		return nil
+	}
+	return snapshots
+}
+
+// histogramDataFromMap converts a histogram found in a results map back to its fortio type
+func histogramDataFromMap(v interface{}) *stats.HistogramData {
+	if v == nil {
+		return nil
+	}
+	bd, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	h := &stats.HistogramData{}
+	if err := json.Unmarshal(bd, h); err != nil {
+		return nil
+	}
+	return h
+}
@@ -0,0 +1,55 @@
+package helpers
+
+import (
+	"testing"
+	"time"
+
+	"fortio.org/fortio/periodic"
+)
+
+func TestMergeConcurrentRunnerResults(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	results := []*periodic.RunnerResults{
+		{
+			StartTime:         start.Add(time.Second),
+			ActualDuration:    10 * time.Second,
+			NumThreads:        4,
+			DurationHistogram: wrk2Histogram(0.002, 100, bucket(0.001, 0.002, 50), bucket(0.002, 0.004, 100)),
+		},
+		{
+			StartTime:         start,
+			ActualDuration:    8 * time.Second,
+			NumThreads:        4,
+			DurationHistogram: wrk2Histogram(0.004, 100, bucket(0.002, 0.004, 50), bucket(0.004, 0.008, 100)),
+		},
+	}
+	got := MergeConcurrentRunnerResults(results)
+	if !got.StartTime.Equal(start) {
+		t.Errorf("start time = %v, want %v", got.StartTime, start)
+	}
+	if got.NumThreads != 8 {
+		t.Errorf("threads = %d, want 8", got.NumThreads)
+	}
+	if got.ActualDuration != 10*time.Second {
+		t.Errorf("duration = %v, want 10s", got.ActualDuration)
+	}
+	if got.DurationHistogram.Count != 200 {
+		t.Errorf("count = %d, want 200", got.DurationHistogram.Count)
+	}
+	var requests int64
+	for _, b := range got.DurationHistogram.Data {
+		requests += b.Count
+	}
+	if requests != 200 {
+		t.Errorf("requests in the buckets = %d, want 200, the cumulative wrk2 counts must not be summed", requests)
+	}
+	if got.ActualQPS != 20 {
+		t.Errorf("qps = %g, want 20", got.ActualQPS)
+	}
+	if got.DurationHistogram.Max != 0.008 {
+		t.Errorf("max = %g, want 0.008", got.DurationHistogram.Max)
+	}
+	if MergeConcurrentRunnerResults(nil) != nil {
+		t.Error("merging no results should return nil")
+	}
+}
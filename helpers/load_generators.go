@@ -0,0 +1,151 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	"fortio.org/fortio/periodic"
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+)
+
+var (
+	loadGenerators     = map[models.LoadGenerator]models.LoadGeneratorInterface{}
+	loadGeneratorsLock = &sync.RWMutex{}
+)
+
+func init() {
+	RegisterLoadGenerator(&fortioLoadGenerator{})
+	RegisterLoadGenerator(&wrk2LoadGenerator{})
+	RegisterLoadGenerator(&nativeLoadGenerator{})
+}
+
+// RegisterLoadGenerator makes the load generator available under its name, replacing any with the same name
+func RegisterLoadGenerator(lg models.LoadGeneratorInterface) {
+	loadGeneratorsLock.Lock()
+	defer loadGeneratorsLock.Unlock()
+	loadGenerators[lg.Name()] = lg
+}
+
+// GetLoadGenerator returns the load generator registered with the given name, fortio being the default
+func GetLoadGenerator(name models.LoadGenerator) (models.LoadGeneratorInterface, error) {
+	if name == "" {
+		name = models.FortioLG
+	}
+	loadGeneratorsLock.RLock()
+	defer loadGeneratorsLock.RUnlock()
+	lg, ok := loadGenerators[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown load generator: %s", name)
+	}
+	return lg, nil
+}
+
+// GetLoadGenerators returns all the registered load generators, sorted by name
+func GetLoadGenerators() []models.LoadGeneratorInterface {
+	loadGeneratorsLock.RLock()
+	defer loadGeneratorsLock.RUnlock()
+	lgs := make([]models.LoadGeneratorInterface, 0, len(loadGenerators))
+	for _, lg := range loadGenerators {
+		lgs = append(lgs, lg)
+	}
+	sort.Slice(lgs, func(i, j int) bool {
+		return lgs[i].Name() < lgs[j].Name()
+	})
+	return lgs
+}
+
+// validateCapabilities checks the load test options only use features the load generator supports
+func validateCapabilities(name models.LoadGenerator, caps *models.LoadGeneratorCapabilities, opts *models.LoadTestOptions) error {
+	if opts.IsGRPC {
+		if !caps.GRPC {
+			return fmt.Errorf("gRPC load tests are not supported by %s", name)
+		}
+	} else {
+		method := opts.HTTPMethod
+		if method == "" {
+			method = http.MethodGet
+		}
+		supported := false
+		for _, m := range caps.HTTPMethods {
+			supported = supported || m == method
+		}
+		if !supported {
+			return fmt.Errorf("%s does not support the %s method, only %v", name, method, caps.HTTPMethods)
+		}
+		if (opts.Cert != "" || opts.CACert != "") && !caps.ClientCerts {
+			return fmt.Errorf("certificates are not supported by %s for http load tests", name)
+		}
+	}
+	if opts.HTTP2 && !caps.HTTP2 {
+		return fmt.Errorf("HTTP/2 is not supported by %s", name)
+	}
+	if opts.OpenModel && !caps.OpenModel {
+		return fmt.Errorf("the open model is not supported by %s", name)
+	}
+	if (opts.AbortOn != 0 || opts.MaxErrorRate > 0) && !caps.AbortConditions {
+		return fmt.Errorf("abortOn and maxErrorRate are not supported by %s", name)
+	}
+	// the snapshots would silently never come
+	if opts.SnapshotInterval > 0 && (!caps.Snapshots || opts.IsGRPC) {
+		return fmt.Errorf("snapshots are only supported by %s for http load tests, set snapshotInterval to 0", name)
+	}
+	return nil
+}
+
+type fortioLoadGenerator struct{}
+
+func (lg *fortioLoadGenerator) Name() models.LoadGenerator {
+	return models.FortioLG
+}
+
+func (lg *fortioLoadGenerator) Capabilities() *models.LoadGeneratorCapabilities {
+	return &models.LoadGeneratorCapabilities{
+		GRPC:            true,
+		HTTPMethods:     []string{http.MethodGet, http.MethodPost},
+		AbortConditions: true,
+		Snapshots:       true,
+	}
+}
+
+func (lg *fortioLoadGenerator) Validate(opts *models.LoadTestOptions) error {
+	if err := validateCapabilities(lg.Name(), lg.Capabilities(), opts); err != nil {
+		return err
+	}
+	if opts.IsGRPC {
+		if opts.Cert != "" || opts.Key != "" {
+			return errors.New("client certificates are not supported by the fortio gRPC runner, only a CA certificate")
+		}
+		if opts.AbortOn != 0 || opts.MaxErrorRate > 0 {
+			return errors.New("abortOn and maxErrorRate are only supported for http load tests")
+		}
+	}
+	return nil
+}
+
+func (lg *fortioLoadGenerator) Run(ctx context.Context, opts *models.LoadTestOptions) (map[string]interface{}, *periodic.RunnerResults, error) {
+	return FortioLoadTest(ctx, opts)
+}
+
+type wrk2LoadGenerator struct{}
+
+func (lg *wrk2LoadGenerator) Name() models.LoadGenerator {
+	return models.Wrk2LG
+}
+
+func (lg *wrk2LoadGenerator) Capabilities() *models.LoadGeneratorCapabilities {
+	return &models.LoadGeneratorCapabilities{
+		HTTPMethods: []string{http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodDelete, http.MethodPost, http.MethodPut, http.MethodPatch},
+	}
+}
+
+func (lg *wrk2LoadGenerator) Validate(opts *models.LoadTestOptions) error {
+	return validateCapabilities(lg.Name(), lg.Capabilities(), opts)
+}
+
+func (lg *wrk2LoadGenerator) Run(ctx context.Context, opts *models.LoadTestOptions) (map[string]interface{}, *periodic.RunnerResults, error) {
+	return WRK2LoadTest(ctx, opts)
+}
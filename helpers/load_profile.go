@@ -0,0 +1,202 @@
+package helpers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"fortio.org/fortio/periodic"
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// rampStepDuration is the length of each of the constant rate steps a ramp stage is broken into
+const rampStepDuration = 10 * time.Second
+
+// LoadTestFunc is the signature shared by the load generator specific load test functions
+type LoadTestFunc func(ctx context.Context, opts *models.LoadTestOptions) (map[string]interface{}, *periodic.RunnerResults, error)
+
+// StagedLoadTest runs the stages of the load profile in opts sequentially using the given load test function.
+// progress, if not nil, is called before each stage with a human readable message.
+// The returned results aggregate all the stages; the per stage results are available under the "stages" key.
+// When a step is aborted the remaining ones are skipped and the results of the steps run so far are returned.
+func StagedLoadTest(ctx context.Context, opts *models.LoadTestOptions, loadTest LoadTestFunc, progress func(string)) (map[string]interface{}, *periodic.RunnerResults, error) {
+	if len(opts.Stages) == 0 {
+		return loadTest(ctx, opts)
+	}
+
+	var (
+		allResults   []*periodic.RunnerResults
+		stageResults []map[string]interface{}
+		retCodes     = map[string]float64{}
+		abortReason  string
+		snapshots    []*models.LoadTestSnapshot
+	)
+	for i, stage := range opts.Stages {
+		if abortReason != "" {
+			break
+		}
+		if progress != nil {
+			progress(fmt.Sprintf("Running stage %d of %d: %s", i+1, len(opts.Stages), describeStage(stage)))
+		}
+		var (
+			stepResults    []*periodic.RunnerResults
+			stepMaps       []map[string]interface{}
+			stageSnapshots []*models.LoadTestSnapshot
+		)
+		stageRetCodes := map[string]float64{}
+		for _, stepOpts := range stageSteps(opts, stage) {
+			resultsMap, result, err := loadTest(ctx, stepOpts)
+			if err != nil {
+				err = errors.Wrapf(err, "error while running stage %d", i+1)
+				logrus.Error(err)
+				return nil, nil, err
+			}
+			stepResults = append(stepResults, result)
+			stepMaps = append(stepMaps, resultsMap)
+			addRetCodes(stageRetCodes, resultsMap)
+			stageSnapshots = append(stageSnapshots, snapshotsFromMap(resultsMap["snapshots"])...)
+			if reason, _ := resultsMap["abort_reason"].(string); reason != "" {
+				abortReason = fmt.Sprintf("%s, during stage %d", reason, i+1)
+				break
+			}
+		}
+		stageResult := MergeRunnerResults(stepResults)
+		allResults = append(allResults, stepResults...)
+		for code, count := range stageRetCodes {
+			retCodes[code] += count
+		}
+
+		stageMap, err := runnerResultsToMap(stageResult)
+		if err != nil {
+			return nil, nil, err
+		}
+		stageMap["Stage"] = stage
+		stageMap["RetCodes"] = stageRetCodes
+		if errs, ok := sumErrors(stepMaps); ok {
+			stageMap["Errors"] = errs
+		}
+		if len(stageSnapshots) > 0 {
+			stageMap["snapshots"] = stageSnapshots
+			snapshots = append(snapshots, stageSnapshots...)
+		}
+		stageResults = append(stageResults, stageMap)
+	}
+
+	result := MergeRunnerResults(allResults)
+	result.RequestedQPS = "profile"
+	resultsMap, err := runnerResultsToMap(result)
+	if err != nil {
+		return nil, nil, err
+	}
+	resultsMap["RetCodes"] = retCodes
+	if errs, ok := sumErrors(stageResults); ok {
+		resultsMap["Errors"] = errs
+	}
+	resultsMap["stages"] = stageResults
+	if len(snapshots) > 0 {
+		resultsMap["snapshots"] = snapshots
+	}
+	if abortReason != "" {
+		resultsMap["abort_reason"] = abortReason
+	}
+	return resultsMap, result, nil
+}
+
+// stageSteps returns the load test options for the constant rate steps making up the given stage
+func stageSteps(opts *models.LoadTestOptions, stage *models.LoadTestStage) []*models.LoadTestOptions {
+	newStep := func(qps float64, duration time.Duration) *models.LoadTestOptions {
+		stepOpts := *opts
+		stepOpts.Stages = nil
+		stepOpts.HTTPQPS = qps
+		stepOpts.Duration = duration
+		if stage.NumThreads > 0 {
+			stepOpts.HTTPNumThreads = stage.NumThreads
+		}
+		return &stepOpts
+	}
+
+	if !stage.Ramp || stage.StartQPS == stage.QPS {
+		return []*models.LoadTestOptions{newStep(stage.QPS, stage.Duration)}
+	}
+
+	n := int(math.Ceil(float64(stage.Duration) / float64(rampStepDuration)))
+	if n < 1 {
+		n = 1
+	}
+	stepDuration := stage.Duration / time.Duration(n)
+	steps := make([]*models.LoadTestOptions, n)
+	for i := 0; i < n; i++ {
+		// using the mid point of each step keeps the average rate of the ramp intact
+		qps := stage.StartQPS + (stage.QPS-stage.StartQPS)*(float64(i)+0.5)/float64(n)
+		steps[i] = newStep(qps, stepDuration)
+	}
+	return steps
+}
+
+func describeStage(stage *models.LoadTestStage) string {
+	name := stage.Name
+	if name == "" {
+		name = "unnamed"
+	}
+	if stage.Ramp {
+		return fmt.Sprintf("%s, ramping from %g to %g qps over %v", name, stage.StartQPS, stage.QPS, stage.Duration)
+	}
+	return fmt.Sprintf("%s, %g qps for %v", name, stage.QPS, stage.Duration)
+}
+
+func addRetCodes(retCodes map[string]float64, resultsMap map[string]interface{}) {
+	for code, count := range retCodesFromMap(resultsMap["RetCodes"]) {
+		retCodes[code] += count
+	}
+}
+
+// retCodesFromMap returns the counts of the status codes, which are a map[string]float64 in the results aggregated
+// by Meshery and a map[string]interface{} in the ones decoded from JSON
+func retCodesFromMap(v interface{}) map[string]float64 {
+	switch codes := v.(type) {
+	case map[string]float64:
+		return codes
+	case map[string]interface{}:
+		retCodes := make(map[string]float64, len(codes))
+		for code, countI := range codes {
+			count, _ := countI.(float64)
+			retCodes[code] = count
+		}
+		return retCodes
+	}
+	return nil
+}
+
+// sumErrors returns the total of the failed requests wrk2 reported in place of the status codes, and false when
+// none of the results are wrk2 ones
+func sumErrors(resultsMaps []map[string]interface{}) (float64, bool) {
+	var total float64
+	found := false
+	for _, rm := range resultsMaps {
+		if errs, ok := rm["Errors"].(float64); ok {
+			total += errs
+			found = true
+		}
+	}
+	return total, found
+}
+
+func runnerResultsToMap(result *periodic.RunnerResults) (map[string]interface{}, error) {
+	bd, err := json.Marshal(result)
+	if err != nil {
+		err = errors.Wrap(err, "error while converting results to map")
+		logrus.Error(err)
+		return nil, err
+	}
+	resultsMap := map[string]interface{}{}
+	if err = json.Unmarshal(bd, &resultsMap); err != nil {
+		err = errors.Wrap(err, "error while unmarshaling data to map")
+		logrus.Error(err)
+		return nil, err
+	}
+	return resultsMap, nil
+}
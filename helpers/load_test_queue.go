@@ -0,0 +1,246 @@
+package helpers
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/layer5io/meshery/models"
+	"github.com/sirupsen/logrus"
+)
+
+// maxFinishedLoadTestJobs is the number of finished jobs kept for inspection
+const maxFinishedLoadTestJobs = 100
+
+type loadTestQueueJob struct {
+	job        *models.LoadTestJob
+	run        func()
+	onPosition func(int)
+}
+
+// LoadTestQueue limits the load tests running concurrently on this host and against the same target.
+// When a slot frees up, the queued job of the user with the fewest running jobs goes first, then the oldest one.
+// It is not built on the taskq queue of the handlers: taskq only carries msgpack encoded arguments, so neither the
+// load test nor the channel reporting its progress can be handed to it, and it consumes the messages in order,
+// without a way to pick the next job by user and target, to tell the position of a job or to remove a queued one.
+type LoadTestQueue struct {
+	maxRunning   int
+	maxPerTarget int
+
+	jobs     map[string]*loadTestQueueJob
+	queued   []*loadTestQueueJob
+	running  []*loadTestQueueJob
+	finished []*loadTestQueueJob
+	lock     *sync.Mutex
+}
+
+// NewLoadTestQueue creates a new instance of LoadTestQueue.
+// maxRunning is the limit of load tests running concurrently, maxPerTarget the one of load tests running against
+// the same target, 0 meaning no limit.
+func NewLoadTestQueue(maxRunning, maxPerTarget int) *LoadTestQueue {
+	return &LoadTestQueue{
+		maxRunning:   maxRunning,
+		maxPerTarget: maxPerTarget,
+		jobs:         map[string]*loadTestQueueJob{},
+		lock:         &sync.Mutex{},
+	}
+}
+
+// Enqueue adds the job to the queue, the id of the job has to be unique among the jobs not finished.
+// onPosition is called with the lock of the queue held, so it must not block.
+func (q *LoadTestQueue) Enqueue(job *models.LoadTestJob, run func(), onPosition func(int)) error {
+	q.lock.Lock()
+	if j, ok := q.jobs[job.ID]; ok && (j.job.Status == models.LoadTestJobQueued || j.job.Status == models.LoadTestJobRunning) {
+		q.lock.Unlock()
+		return fmt.Errorf("a load test with the id %s is already queued", job.ID)
+	}
+	job.Status = models.LoadTestJobQueued
+	job.QueuedAt = time.Now()
+	j := &loadTestQueueJob{
+		job:        job,
+		run:        run,
+		onPosition: onPosition,
+	}
+	q.jobs[job.ID] = j
+	q.queued = append(q.queued, j)
+	dispatched := q.schedule()
+	q.lock.Unlock()
+
+	q.dispatchJobs(dispatched)
+	return nil
+}
+
+// run runs the dispatched job with the given id and dispatches the next jobs once it is done
+func (q *LoadTestQueue) run(id string) error {
+	q.lock.Lock()
+	j, ok := q.jobs[id]
+	if !ok || j.job.Status != models.LoadTestJobRunning || !j.job.StartedAt.IsZero() {
+		q.lock.Unlock()
+		return fmt.Errorf("no dispatched load test found for the id %s", id)
+	}
+	j.job.StartedAt = time.Now()
+	q.lock.Unlock()
+
+	defer func() {
+		q.lock.Lock()
+		j.job.Status = models.LoadTestJobDone
+		j.job.FinishedAt = time.Now()
+		q.running = removeLoadTestJob(q.running, j)
+		q.finish(j)
+		dispatched := q.schedule()
+		q.lock.Unlock()
+
+		q.dispatchJobs(dispatched)
+	}()
+	j.run()
+	return nil
+}
+
+// Remove removes the job with the given id if it is still queued
+func (q *LoadTestQueue) Remove(id string) bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	j, ok := q.jobs[id]
+	if !ok || j.job.Status != models.LoadTestJobQueued {
+		return false
+	}
+	j.job.Status = models.LoadTestJobCancelled
+	j.job.Position = 0
+	j.job.FinishedAt = time.Now()
+	q.queued = removeLoadTestJob(q.queued, j)
+	q.finish(j)
+	q.updatePositions()
+	return true
+}
+
+// GetJob returns the job with the given id
+func (q *LoadTestQueue) GetJob(id string) (*models.LoadTestJob, bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	j, ok := q.jobs[id]
+	if !ok {
+		return nil, false
+	}
+	job := *j.job
+	return &job, true
+}
+
+// GetJobs returns the queued jobs of the user in the order they are expected to run, then the running ones and the
+// latest finished ones, most recent last. The jobs of all the users are returned when userID is empty.
+func (q *LoadTestQueue) GetJobs(userID string) []*models.LoadTestJob {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	jobs := make([]*models.LoadTestJob, 0, len(q.queued)+len(q.running)+len(q.finished))
+	for _, list := range [][]*loadTestQueueJob{q.queued, q.running, q.finished} {
+		for _, j := range list {
+			if userID != "" && j.job.UserID != userID {
+				continue
+			}
+			job := *j.job
+			jobs = append(jobs, &job)
+		}
+	}
+	return jobs
+}
+
+// schedule marks the jobs which can be started as running and returns their ids, it needs the lock
+func (q *LoadTestQueue) schedule() []string {
+	var dispatched []string
+	for {
+		q.sortQueued()
+		var next *loadTestQueueJob
+		for _, j := range q.queued {
+			if q.canRun(j) {
+				next = j
+				break
+			}
+		}
+		if next == nil {
+			break
+		}
+		next.job.Status = models.LoadTestJobRunning
+		next.job.Position = 0
+		q.queued = removeLoadTestJob(q.queued, next)
+		q.running = append(q.running, next)
+		dispatched = append(dispatched, next.job.ID)
+	}
+	q.updatePositions()
+	return dispatched
+}
+
+func (q *LoadTestQueue) canRun(j *loadTestQueueJob) bool {
+	if q.maxRunning > 0 && len(q.running) >= q.maxRunning {
+		return false
+	}
+	if q.maxPerTarget > 0 {
+		n := 0
+		for _, r := range q.running {
+			if r.job.Target == j.job.Target {
+				n++
+			}
+		}
+		if n >= q.maxPerTarget {
+			return false
+		}
+	}
+	return true
+}
+
+// sortQueued orders the queued jobs by the number of running jobs of their user, then by age
+func (q *LoadTestQueue) sortQueued() {
+	userRuns := map[string]int{}
+	for _, r := range q.running {
+		userRuns[r.job.UserID]++
+	}
+	sort.SliceStable(q.queued, func(i, k int) bool {
+		ri, rk := userRuns[q.queued[i].job.UserID], userRuns[q.queued[k].job.UserID]
+		if ri != rk {
+			return ri < rk
+		}
+		return q.queued[i].job.QueuedAt.Before(q.queued[k].job.QueuedAt)
+	})
+}
+
+func (q *LoadTestQueue) updatePositions() {
+	for i, j := range q.queued {
+		if j.job.Position == i+1 {
+			continue
+		}
+		j.job.Position = i + 1
+		if j.onPosition != nil {
+			j.onPosition(j.job.Position)
+		}
+	}
+}
+
+func (q *LoadTestQueue) finish(j *loadTestQueueJob) {
+	q.finished = append(q.finished, j)
+	if len(q.finished) > maxFinishedLoadTestJobs {
+		oldest := q.finished[0]
+		q.finished = q.finished[1:]
+		if q.jobs[oldest.job.ID] == oldest {
+			delete(q.jobs, oldest.job.ID)
+		}
+	}
+}
+
+// dispatchJobs runs each of the jobs in a goroutine of its own, the load tests lasting as long as they were asked to
+func (q *LoadTestQueue) dispatchJobs(ids []string) {
+	for _, id := range ids {
+		go func(id string) {
+			if err := q.run(id); err != nil {
+				logrus.Error(err)
+			}
+		}(id)
+	}
+}
+
+func removeLoadTestJob(jobs []*loadTestQueueJob, j *loadTestQueueJob) []*loadTestQueueJob {
+	for i, job := range jobs {
+		if job == j {
+			return append(jobs[:i], jobs[i+1:]...)
+		}
+	}
+	return jobs
+}
@@ -0,0 +1,44 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/layer5io/meshery/models"
+)
+
+func TestLoadTestQueueGetJobs(t *testing.T) {
+	q := NewLoadTestQueue(1, 0)
+	release := make(chan struct{})
+	defer close(release)
+	for _, job := range []*models.LoadTestJob{
+		{ID: "a-1", UserID: "a"},
+		{ID: "b-1", UserID: "b"},
+		{ID: "a-2", UserID: "a"},
+	} {
+		if err := q.Enqueue(job, func() { <-release }, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		userID  string
+		wantIDs []string
+	}{
+		{userID: "a", wantIDs: []string{"a-2", "a-1"}},
+		{userID: "b", wantIDs: []string{"b-1"}},
+		{userID: "c"},
+		{userID: "", wantIDs: []string{"b-1", "a-2", "a-1"}},
+	}
+	for _, tt := range tests {
+		jobs := q.GetJobs(tt.userID)
+		if len(jobs) != len(tt.wantIDs) {
+			t.Errorf("jobs of %q = %d, want %d", tt.userID, len(jobs), len(tt.wantIDs))
+			continue
+		}
+		for i, job := range jobs {
+			if job.ID != tt.wantIDs[i] {
+				t.Errorf("job %d of %q = %s, want %s", i, tt.userID, job.ID, tt.wantIDs[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+type loadTestRun struct {
+	userID string
+	cancel context.CancelFunc
+}
+
+// LoadTestTracker tracks the running load tests so they can be cancelled
+type LoadTestTracker struct {
+	runs     map[string]*loadTestRun
+	runsLock *sync.Mutex
+}
+
+// NewLoadTestTracker creates a new instance of LoadTestTracker
+func NewLoadTestTracker() *LoadTestTracker {
+	return &LoadTestTracker{
+		runs:     map[string]*loadTestRun{},
+		runsLock: &sync.Mutex{},
+	}
+}
+
+// AddRun registers a running load test, the id has to be unique among the running load tests
+func (l *LoadTestTracker) AddRun(ctx context.Context, id, userID string, cancel context.CancelFunc) error {
+	l.runsLock.Lock()
+	defer l.runsLock.Unlock()
+	if _, ok := l.runs[id]; ok {
+		return fmt.Errorf("a load test with the id %s is already running", id)
+	}
+	l.runs[id] = &loadTestRun{
+		userID: userID,
+		cancel: cancel,
+	}
+	return nil
+}
+
+// CancelRun cancels the load test with the given id if it was started by the user
+func (l *LoadTestTracker) CancelRun(ctx context.Context, id, userID string) bool {
+	l.runsLock.Lock()
+	defer l.runsLock.Unlock()
+	run, ok := l.runs[id]
+	if !ok || run.userID != userID {
+		return false
+	}
+	run.cancel()
+	return true
+}
+
+// RemoveRun removes a load test which is done
+func (l *LoadTestTracker) RemoveRun(ctx context.Context, id string) {
+	l.runsLock.Lock()
+	defer l.runsLock.Unlock()
+	delete(l.runs, id)
+}
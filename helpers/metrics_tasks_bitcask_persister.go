@@ -0,0 +1,202 @@
+package helpers
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"sort"
+
+	"github.com/gofrs/uuid"
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+	"github.com/prologic/bitcask"
+	"github.com/sirupsen/logrus"
+)
+
+// metricsTasksMaxValueSize leaves room for the queries tracked for the load test of a task
+const metricsTasksMaxValueSize = 1 << 22 // 4MB
+
+// BitCaskMetricsTasksPersister assists with persisting the server metrics collection tasks in a Bitcask store
+type BitCaskMetricsTasksPersister struct {
+	fileName string
+	db       *bitcask.Bitcask
+}
+
+// NewBitCaskMetricsTasksPersister creates a new BitCaskMetricsTasksPersister instance
+func NewBitCaskMetricsTasksPersister(folderName string) (*BitCaskMetricsTasksPersister, error) {
+	_, err := os.Stat(folderName)
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = os.MkdirAll(folderName, os.ModePerm)
+			if err != nil {
+				logrus.Errorf("Unable to create the directory '%s' due to error: %v.", folderName, err)
+				return nil, err
+			}
+		} else {
+			logrus.Errorf("Unable to find/stat the folder '%s': %v,", folderName, err)
+			return nil, err
+		}
+	}
+
+	fileName := path.Join(folderName, "metrics_tasks.db")
+	db, err := bitcask.Open(fileName,
+		bitcask.WithSync(true),
+		bitcask.WithMaxValueSize(metricsTasksMaxValueSize),
+		bitcask.WithMaxDatafileSize(metricsTasksMaxValueSize),
+	)
+	if err != nil {
+		logrus.Errorf("Unable to open database: %v.", err)
+		return nil, err
+	}
+	bd := &BitCaskMetricsTasksPersister{
+		fileName: fileName,
+		db:       db,
+	}
+	return bd, nil
+}
+
+// GetTasks - gets all the tasks, oldest first
+func (s *BitCaskMetricsTasksPersister) GetTasks() ([]*models.MetricsTask, error) {
+	if s.db == nil {
+		return nil, errors.New("Connection to DB does not exist.")
+	}
+
+RETRY:
+	locked, err := s.db.TryRLock()
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to obtain read lock from bitcask store")
+		logrus.Error(err)
+	}
+	if !locked {
+		goto RETRY
+	}
+	defer func() {
+		_ = s.db.Unlock()
+	}()
+
+	tasks := []*models.MetricsTask{}
+	for key := range s.db.Keys() {
+		dataB, err := s.db.Get(key)
+		if err != nil {
+			err = errors.Wrapf(err, "Unable to read data from bitcask store")
+			logrus.Error(err)
+			return nil, err
+		}
+		task := &models.MetricsTask{}
+		if err := json.Unmarshal(dataB, task); err != nil {
+			err = errors.Wrapf(err, "Unable to unmarshal data.")
+			logrus.Error(err)
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].CreatedAt.Before(tasks[j].CreatedAt)
+	})
+	return tasks, nil
+}
+
+// GetTask - gets a single task
+func (s *BitCaskMetricsTasksPersister) GetTask(key uuid.UUID) (*models.MetricsTask, error) {
+	if s.db == nil {
+		return nil, errors.New("Connection to DB does not exist.")
+	}
+
+RETRY:
+	locked, err := s.db.TryRLock()
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to obtain read lock from bitcask store")
+		logrus.Error(err)
+	}
+	if !locked {
+		goto RETRY
+	}
+	defer func() {
+		_ = s.db.Unlock()
+	}()
+
+	dataB, err := s.db.Get(key.Bytes())
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to read data from bitcask store")
+		logrus.Error(err)
+		return nil, err
+	}
+	task := &models.MetricsTask{}
+	if err := json.Unmarshal(dataB, task); err != nil {
+		err = errors.Wrapf(err, "Unable to unmarshal data.")
+		logrus.Error(err)
+		return nil, err
+	}
+	return task, nil
+}
+
+// WriteTask persists the task
+func (s *BitCaskMetricsTasksPersister) WriteTask(key uuid.UUID, task *models.MetricsTask) error {
+	if s.db == nil {
+		return errors.New("Connection to DB does not exist.")
+	}
+
+	if task == nil {
+		return errors.New("Given task data is nil.")
+	}
+
+RETRY:
+	locked, err := s.db.TryLock()
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to obtain write lock from bitcask store")
+		logrus.Error(err)
+	}
+	if !locked {
+		goto RETRY
+	}
+	defer func() {
+		_ = s.db.Unlock()
+	}()
+
+	dataB, err := json.Marshal(task)
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to marshal the task data.")
+		logrus.Error(err)
+		return err
+	}
+
+	if err := s.db.Put(key.Bytes(), dataB); err != nil {
+		err = errors.Wrapf(err, "Unable to persist task data.")
+		return err
+	}
+	return nil
+}
+
+// DeleteTask removes the task
+func (s *BitCaskMetricsTasksPersister) DeleteTask(key uuid.UUID) error {
+	if s.db == nil {
+		return errors.New("Connection to DB does not exist.")
+	}
+
+RETRY:
+	locked, err := s.db.TryLock()
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to obtain write lock from bitcask store")
+		logrus.Error(err)
+	}
+	if !locked {
+		goto RETRY
+	}
+	defer func() {
+		_ = s.db.Unlock()
+	}()
+
+	if err := s.db.Delete(key.Bytes()); err != nil {
+		err = errors.Wrapf(err, "Unable to delete task data for the id: %s.", key)
+		return err
+	}
+	return nil
+}
+
+// Close closes the bitcask store
+func (s *BitCaskMetricsTasksPersister) Close() {
+	if s.db == nil {
+		return
+	}
+	_ = s.db.Close()
+}
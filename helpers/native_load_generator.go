@@ -0,0 +1,414 @@
+package helpers
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"fortio.org/fortio/fhttp"
+	"fortio.org/fortio/periodic"
+	"fortio.org/fortio/stats"
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/http2"
+)
+
+type nativeLoadGenerator struct{}
+
+func (lg *nativeLoadGenerator) Name() models.LoadGenerator {
+	return models.NativeLG
+}
+
+func (lg *nativeLoadGenerator) Capabilities() *models.LoadGeneratorCapabilities {
+	return &models.LoadGeneratorCapabilities{
+		HTTPMethods:     []string{http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodDelete, http.MethodPost, http.MethodPut, http.MethodPatch},
+		HTTP2:           true,
+		OpenModel:       true,
+		ClientCerts:     true,
+		AbortConditions: true,
+		Snapshots:       true,
+	}
+}
+
+func (lg *nativeLoadGenerator) Validate(opts *models.LoadTestOptions) error {
+	if err := validateCapabilities(lg.Name(), lg.Capabilities(), opts); err != nil {
+		return err
+	}
+	if opts.OpenModel {
+		if len(opts.Stages) == 0 && opts.HTTPQPS <= 0 {
+			return errors.New("the open model needs a rate, please provide the qps")
+		}
+		for i, stage := range opts.Stages {
+			if stage.QPS <= 0 {
+				return fmt.Errorf("the open model needs a rate, please provide the qps of stage %d", i+1)
+			}
+		}
+	}
+	return nil
+}
+
+func (lg *nativeLoadGenerator) Run(ctx context.Context, opts *models.LoadTestOptions) (map[string]interface{}, *periodic.RunnerResults, error) {
+	return NativeLoadTest(ctx, opts)
+}
+
+// nativeRunner holds the state of a load test run by the native load generator
+type nativeRunner struct {
+	client  *http.Client
+	opts    *models.LoadTestOptions
+	monitor *loadTestMonitor
+
+	statsLock   *sync.Mutex
+	durations   *stats.Histogram
+	sizes       *stats.Histogram
+	headerSizes *stats.Histogram
+	retCodes    map[int]int64
+	dropped     int64
+	dials       int64
+}
+
+// NativeLoadTest runs the load test with the load generator built into Meshery.
+// In the default closed model each thread sends its requests one after the other, at HTTPQPS/threads if a rate is
+// given. In the open model the requests are sent at a constant arrival rate and their latency is measured from the
+// time they were due, so a slow server can not hide its slowness by holding the load generator back.
+func NativeLoadTest(ctx context.Context, opts *models.LoadTestOptions) (map[string]interface{}, *periodic.RunnerResults, error) {
+	r := &nativeRunner{
+		opts:        opts,
+		statsLock:   &sync.Mutex{},
+		durations:   stats.NewHistogram(0, periodic.DefaultRunnerOptions.Resolution),
+		sizes:       stats.NewHistogram(0, 100),
+		headerSizes: stats.NewHistogram(0, 5),
+		retCodes:    map[int]int64{},
+	}
+	rURL := strings.TrimLeft(opts.URL, " \t\r\n")
+	var err error
+	if r.client, err = r.newClient(rURL); err != nil {
+		err = errors.Wrap(err, "invalid http options")
+		logrus.Error(err)
+		return nil, nil, err
+	}
+	if _, err = r.newRequest(rURL); err != nil {
+		err = errors.Wrap(err, "invalid http request")
+		logrus.Error(err)
+		return nil, nil, err
+	}
+
+	threads := opts.HTTPNumThreads
+	if threads < 1 {
+		threads = 1
+	}
+	aborter := periodic.NewAborter()
+	stop := aborter.StopChan
+	r.monitor = newRunMonitor(aborter, opts)
+	done := make(chan struct{})
+	go r.monitor.watch(ctx, opts.MaxErrorRate, done)
+
+	logrus.Infof("Starting native http test for %s with %d threads at %.1f qps, open model: %v, http2: %v", rURL, threads, opts.HTTPQPS, opts.OpenModel, opts.HTTP2)
+	start := time.Now()
+	deadline := start.Add(opts.Duration)
+	if opts.OpenModel {
+		r.runOpenModel(rURL, threads, start, deadline, stop)
+	} else {
+		r.runClosedModel(rURL, threads, start, deadline, stop)
+	}
+	elapsed := time.Since(start)
+	close(done)
+	snapshots := r.monitor.takeSnapshots()
+	r.client.CloseIdleConnections()
+
+	requestedQPS := "max"
+	if opts.HTTPQPS > 0 {
+		requestedQPS = fmt.Sprintf("%.9g", opts.HTTPQPS)
+	}
+	durations := r.durations.Export().CalcPercentiles([]float64{50, 75, 90, 99, 99.9})
+	result := &fhttp.HTTPRunnerResults{
+		RunnerResults: periodic.RunnerResults{
+			RunType:           "HTTP",
+			Labels:            opts.Name + " -_- " + rURL,
+			StartTime:         start,
+			RequestedQPS:      requestedQPS,
+			RequestedDuration: fmt.Sprint(opts.Duration),
+			ActualQPS:         float64(durations.Count) / elapsed.Seconds(),
+			ActualDuration:    elapsed,
+			NumThreads:        threads,
+			Version:           string(models.NativeLG),
+			DurationHistogram: durations,
+		},
+		RetCodes:    r.retCodes,
+		Sizes:       r.sizes.Export(),
+		HeaderSizes: r.headerSizes.Export(),
+		URL:         rURL,
+		SocketCount: int(atomic.LoadInt64(&r.dials)),
+		AbortOn:     opts.AbortOn,
+	}
+
+	bd, err := json.Marshal(result)
+	if err != nil {
+		err = errors.Wrap(err, "error while converting results to map")
+		logrus.Error(err)
+		return nil, nil, err
+	}
+	resultsMap := map[string]interface{}{}
+	if err = json.Unmarshal(bd, &resultsMap); err != nil {
+		err = errors.Wrap(err, "error while unmarshaling data to map")
+		logrus.Error(err)
+		return nil, nil, err
+	}
+	if opts.OpenModel {
+		resultsMap["dropped_requests"] = atomic.LoadInt64(&r.dropped)
+	}
+	if reason := r.monitor.reason(); reason != "" {
+		resultsMap["abort_reason"] = reason
+	}
+	if len(snapshots) > 0 {
+		resultsMap["snapshots"] = snapshots
+	}
+	return resultsMap, &result.RunnerResults, nil
+}
+
+// runClosedModel runs threads which each send a request after the other, paced if a rate is given
+func (r *nativeRunner) runClosedModel(rURL string, threads int, start, deadline time.Time, stop <-chan struct{}) {
+	var interval time.Duration
+	if r.opts.HTTPQPS > 0 {
+		interval = time.Duration(float64(threads) / r.opts.HTTPQPS * float64(time.Second))
+	}
+	wg := &sync.WaitGroup{}
+	for t := 0; t < threads; t++ {
+		wg.Add(1)
+		go func(t int) {
+			defer wg.Done()
+			// the threads are spread over the interval
+			next := start.Add(time.Duration(t) * interval / time.Duration(threads))
+			for waitUntil(next, deadline, stop) {
+				r.do(rURL, time.Now())
+				if interval > 0 {
+					next = next.Add(interval)
+				} else {
+					next = time.Now()
+				}
+			}
+		}(t)
+	}
+	wg.Wait()
+}
+
+// runOpenModel sends requests at a constant arrival rate, with at most threads requests in flight
+func (r *nativeRunner) runOpenModel(rURL string, threads int, start, deadline time.Time, stop <-chan struct{}) {
+	interval := time.Duration(float64(time.Second) / r.opts.HTTPQPS)
+	inFlight := make(chan struct{}, threads)
+	wg := &sync.WaitGroup{}
+	for i := int64(0); ; i++ {
+		due := start.Add(time.Duration(i) * interval)
+		if !waitUntil(due, deadline, stop) {
+			break
+		}
+		select {
+		case inFlight <- struct{}{}:
+			wg.Add(1)
+			go func(due time.Time) {
+				defer func() {
+					<-inFlight
+					wg.Done()
+				}()
+				r.do(rURL, due)
+			}(due)
+		default:
+			atomic.AddInt64(&r.dropped, 1)
+		}
+	}
+	wg.Wait()
+}
+
+// waitUntil waits until t and returns true, unless the run is over by then
+func waitUntil(t, deadline time.Time, stop <-chan struct{}) bool {
+	if !t.Before(deadline) {
+		return false
+	}
+	wait := time.Until(t)
+	if wait <= 0 {
+		select {
+		case <-stop:
+			return false
+		default:
+			return true
+		}
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-stop:
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// do sends a request and records its outcome, the latency being measured from since
+func (r *nativeRunner) do(rURL string, since time.Time) {
+	code, size, headerSize := -1, 0, 0
+	req, err := r.newRequest(rURL)
+	if err == nil {
+		var resp *http.Response
+		resp, err = r.client.Do(req)
+		if err == nil {
+			n, _ := io.Copy(ioutil.Discard, resp.Body)
+			_ = resp.Body.Close()
+			code, size, headerSize = resp.StatusCode, int(n), responseHeaderSize(resp)
+		}
+	}
+	if err != nil {
+		logrus.Debugf("error sending the request: %v", err)
+	}
+	latency := time.Since(since).Seconds()
+
+	r.statsLock.Lock()
+	r.durations.Record(latency)
+	r.sizes.Record(float64(size))
+	r.headerSizes.Record(float64(headerSize))
+	r.retCodes[code]++
+	r.statsLock.Unlock()
+
+	r.monitor.record(code, latency)
+	if r.opts.AbortOn != 0 && r.opts.AbortOn == code {
+		r.monitor.abort(fmt.Sprintf("received the status code %d", code))
+	}
+}
+
+// responseHeaderSize returns the size of the status line and headers of resp as they would be sent over HTTP/1.1
+func responseHeaderSize(resp *http.Response) int {
+	size := len(resp.Proto) + len(resp.Status) + 4
+	for key, values := range resp.Header {
+		for _, value := range values {
+			size += len(key) + len(value) + 4
+		}
+	}
+	return size + 2
+}
+
+func (r *nativeRunner) newRequest(rURL string) (*http.Request, error) {
+	method := r.opts.HTTPMethod
+	if method == "" {
+		method = http.MethodGet
+		if len(r.opts.HTTPBody) > 0 {
+			method = http.MethodPost
+		}
+	}
+	var body io.Reader
+	if len(r.opts.HTTPBody) > 0 {
+		body = bytes.NewReader(r.opts.HTTPBody)
+	}
+	req, err := http.NewRequest(method, rURL, body)
+	if err != nil {
+		return nil, err
+	}
+	for _, hdr := range r.opts.HTTPHeaders {
+		kv := strings.SplitN(hdr, ":", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid extra header '%s', expecting Key: Value", hdr)
+		}
+		key, value := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+		if strings.EqualFold(key, "Host") {
+			req.Host = value
+			continue
+		}
+		req.Header.Add(key, value)
+	}
+	if r.opts.HTTPContentType != "" {
+		req.Header.Set("Content-Type", r.opts.HTTPContentType)
+	}
+	if r.opts.HTTPUserCredentials != "" {
+		creds := strings.SplitN(r.opts.HTTPUserCredentials, ":", 2)
+		if len(creds) != 2 {
+			return nil, errors.New("invalid user credentials, expecting user:password")
+		}
+		req.SetBasicAuth(creds[0], creds[1])
+	}
+	return req, nil
+}
+
+// newClient creates the http client shared by all the requests of the load test
+func (r *nativeRunner) newClient(rURL string) (*http.Client, error) {
+	u, err := url.Parse(rURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("given URL (%s) is not a valid http URL", rURL)
+	}
+	timeout := fhttp.HTTPReqTimeOutDefaultValue
+	if r.opts.HTTPReqTimeout > 0 {
+		timeout = r.opts.HTTPReqTimeout
+	}
+
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: r.opts.IsInsecure,
+	}
+	if r.opts.CACert != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(r.opts.CACert)) {
+			return nil, errors.New("unable to parse the CA certificate")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if r.opts.Cert != "" {
+		cert, err := tls.X509KeyPair([]byte(r.opts.Cert), []byte(r.opts.Key))
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid client certificate and key")
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   timeout,
+		KeepAlive: 30 * time.Second,
+	}
+	dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		atomic.AddInt64(&r.dials, 1)
+		return dialer.DialContext(ctx, network, addr)
+	}
+
+	var transport http.RoundTripper
+	if r.opts.HTTP2 {
+		transport = &http2.Transport{
+			TLSClientConfig: tlsConfig,
+			// plain http URLs use HTTP/2 with prior knowledge
+			AllowHTTP: true,
+			DialTLS: func(network, addr string, cfg *tls.Config) (net.Conn, error) {
+				conn, err := dial(context.Background(), network, addr)
+				if err != nil || u.Scheme == "http" {
+					return conn, err
+				}
+				tlsConn := tls.Client(conn, cfg)
+				if err = tlsConn.Handshake(); err != nil {
+					_ = conn.Close()
+					return nil, err
+				}
+				if p := tlsConn.ConnectionState().NegotiatedProtocol; p != http2.NextProtoTLS {
+					_ = conn.Close()
+					return nil, fmt.Errorf("the server does not support HTTP/2, negotiated protocol: %q", p)
+				}
+				return tlsConn, nil
+			},
+		}
+	} else {
+		transport = &http.Transport{
+			DialContext:         dial,
+			TLSClientConfig:     tlsConfig,
+			MaxIdleConnsPerHost: r.opts.HTTPNumThreads,
+			DisableCompression:  true,
+		}
+	}
+	return &http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+	}, nil
+}
@@ -0,0 +1,160 @@
+package helpers
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/layer5io/meshery/models"
+	promModel "github.com/prometheus/common/model"
+)
+
+type queryCacheEntry struct {
+	key       string
+	data      []byte
+	expiresAt time.Time
+}
+
+// queryCall is a fetch in flight, shared by the concurrent calls for the same key
+type queryCall struct {
+	done chan struct{}
+	data []byte
+	err  error
+}
+
+// QueryCache caches the query results in memory, evicting the least recently used ones beyond maxEntries or maxBytes
+type QueryCache struct {
+	maxEntries int
+	maxBytes   int64
+
+	entries  map[string]*list.Element
+	lru      *list.List
+	bytes    int64
+	inFlight map[string]*queryCall
+	stats    models.QueryCacheStats
+	lock     *sync.Mutex
+}
+
+// NewQueryCache creates a new instance of QueryCache, maxEntries being the number of results kept and maxBytes the
+// size of their keys and data
+func NewQueryCache(maxEntries int, maxBytes int64) *QueryCache {
+	return &QueryCache{
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		entries:    map[string]*list.Element{},
+		lru:        list.New(),
+		inFlight:   map[string]*queryCall{},
+		lock:       &sync.Mutex{},
+	}
+}
+
+// Get returns the cached result for key, or the one of fetch which is cached for ttl when it succeeds
+func (c *QueryCache) Get(key string, ttl time.Duration, fetch func() ([]byte, error)) ([]byte, error) {
+	c.lock.Lock()
+	if el, ok := c.entries[key]; ok {
+		entry := el.Value.(*queryCacheEntry)
+		if time.Now().Before(entry.expiresAt) {
+			c.lru.MoveToFront(el)
+			c.stats.Hits++
+			c.lock.Unlock()
+			return entry.data, nil
+		}
+		c.remove(el)
+	}
+	if call, ok := c.inFlight[key]; ok {
+		c.stats.Coalesced++
+		c.lock.Unlock()
+		<-call.done
+		return call.data, call.err
+	}
+	c.stats.Misses++
+	call := &queryCall{
+		done: make(chan struct{}),
+	}
+	c.inFlight[key] = call
+	c.lock.Unlock()
+
+	call.data, call.err = fetch()
+
+	c.lock.Lock()
+	delete(c.inFlight, key)
+	if call.err != nil {
+		c.stats.Errors++
+	} else if ttl > 0 && c.maxEntries > 0 && entrySize(key, call.data) <= c.maxBytes {
+		c.add(key, call.data, ttl)
+	}
+	c.lock.Unlock()
+	close(call.done)
+	return call.data, call.err
+}
+
+// Stats returns metrics on the cache
+func (c *QueryCache) Stats() *models.QueryCacheStats {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	stats := c.stats
+	stats.Entries = c.lru.Len()
+	stats.Bytes = c.bytes
+	return &stats
+}
+
+// add caches the result, it needs the lock
+func (c *QueryCache) add(key string, data []byte, ttl time.Duration) {
+	if el, ok := c.entries[key]; ok {
+		c.remove(el)
+	}
+	c.entries[key] = c.lru.PushFront(&queryCacheEntry{
+		key:       key,
+		data:      data,
+		expiresAt: time.Now().Add(ttl),
+	})
+	c.bytes += entrySize(key, data)
+	for c.lru.Len() > c.maxEntries || c.bytes > c.maxBytes {
+		c.remove(c.lru.Back())
+		c.stats.Evictions++
+	}
+}
+
+// remove removes the result from the cache, it needs the lock
+func (c *QueryCache) remove(el *list.Element) {
+	c.lru.Remove(el)
+	entry := el.Value.(*queryCacheEntry)
+	delete(c.entries, entry.key)
+	c.bytes -= entrySize(entry.key, entry.data)
+}
+
+// entrySize is the size counted against the byte budget of the cache
+func entrySize(key string, data []byte) int64 {
+	return int64(len(key) + len(data))
+}
+
+// AppendQueryRangeResponse appends the points of the series of the range query response tail of the prometheus API
+// which are after the ones of data, the series only in tail being added. data is returned as it is when one of the
+// responses is not a matrix.
+func AppendQueryRangeResponse(data, tail []byte) ([]byte, error) {
+	resp, matrix, err := parseQueryRangeResponse(data)
+	if err != nil || matrix == nil {
+		return data, err
+	}
+	_, tailMatrix, err := parseQueryRangeResponse(tail)
+	if err != nil || tailMatrix == nil {
+		return data, err
+	}
+	streams := map[promModel.Fingerprint]*promModel.SampleStream{}
+	for _, stream := range matrix {
+		streams[stream.Metric.Fingerprint()] = stream
+	}
+	for _, tailStream := range tailMatrix {
+		stream, ok := streams[tailStream.Metric.Fingerprint()]
+		if !ok {
+			matrix = append(matrix, tailStream)
+			continue
+		}
+		for _, p := range tailStream.Values {
+			if n := len(stream.Values); n == 0 || p.Timestamp.After(stream.Values[n-1].Timestamp) {
+				stream.Values = append(stream.Values, p)
+			}
+		}
+	}
+	return marshalQueryRangeResponse(resp, matrix)
+}
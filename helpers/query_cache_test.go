@@ -0,0 +1,288 @@
+package helpers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	promModel "github.com/prometheus/common/model"
+)
+
+func TestQueryCacheCoalescing(t *testing.T) {
+	const callers = 10
+	c := NewQueryCache(10, 1<<20)
+	var fetches int32
+	release := make(chan struct{})
+	fetch := func() ([]byte, error) {
+		atomic.AddInt32(&fetches, 1)
+		<-release
+		return []byte("result"), nil
+	}
+
+	wg := &sync.WaitGroup{}
+	results := make([][]byte, callers)
+	errs := make([]error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = c.Get("key", time.Minute, fetch)
+		}(i)
+	}
+	// all the callers but the fetching one wait for the fetch in flight
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		stats := c.Stats()
+		if stats.Misses+stats.Coalesced == callers {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("stats = %+v, want the %d callers waiting", stats, callers)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&fetches); n != 1 {
+		t.Errorf("fetches = %d, want 1", n)
+	}
+	for i := range results {
+		if errs[i] != nil || string(results[i]) != "result" {
+			t.Errorf("caller %d got %q, %v, want the shared result", i, results[i], errs[i])
+		}
+	}
+	stats := c.Stats()
+	if stats.Misses != 1 || stats.Coalesced != callers-1 {
+		t.Errorf("misses, coalesced = %d, %d, want 1, %d", stats.Misses, stats.Coalesced, callers-1)
+	}
+
+	if _, err := c.Get("key", time.Minute, fetch); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&fetches); n != 1 {
+		t.Errorf("fetches = %d, want the result cached", n)
+	}
+	if stats := c.Stats(); stats.Hits != 1 {
+		t.Errorf("hits = %d, want 1", stats.Hits)
+	}
+}
+
+func TestQueryCacheCoalescedErrors(t *testing.T) {
+	c := NewQueryCache(10, 1<<20)
+	release := make(chan struct{})
+	var fetches int32
+	fetch := func() ([]byte, error) {
+		if atomic.AddInt32(&fetches, 1) == 1 {
+			<-release
+		}
+		return nil, errors.New("backend down")
+	}
+
+	wg := &sync.WaitGroup{}
+	errs := make([]error, 3)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = c.Get("key", time.Minute, fetch)
+		}(i)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for stats := c.Stats(); stats.Misses+stats.Coalesced != int64(len(errs)); stats = c.Stats() {
+		if time.Now().After(deadline) {
+			t.Fatalf("stats = %+v, want the callers waiting", stats)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	for i, err := range errs {
+		if err == nil {
+			t.Errorf("caller %d got no error, want the one of the shared fetch", i)
+		}
+	}
+	// the errors are not cached
+	if _, err := c.Get("key", time.Minute, fetch); err == nil {
+		t.Error("got no error, want the fetch to run again")
+	}
+	if n := atomic.LoadInt32(&fetches); n != 2 {
+		t.Errorf("fetches = %d, want 2", n)
+	}
+	if stats := c.Stats(); stats.Errors != 2 || stats.Entries != 0 {
+		t.Errorf("errors, entries = %d, %d, want 2, 0", stats.Errors, stats.Entries)
+	}
+}
+
+func TestQueryCacheEviction(t *testing.T) {
+	data := func(n int) func() ([]byte, error) {
+		return func() ([]byte, error) {
+			return make([]byte, n), nil
+		}
+	}
+	tests := []struct {
+		name       string
+		maxEntries int
+		maxBytes   int64
+		sizes      []int
+		// wantKeys are the keys still cached, the ones being k0, k1...
+		wantKeys  []string
+		wantBytes int64
+	}{
+		{
+			name:       "entries bound",
+			maxEntries: 2,
+			maxBytes:   1 << 20,
+			sizes:      []int{8, 8, 8},
+			wantKeys:   []string{"k1", "k2"},
+			wantBytes:  20,
+		},
+		{
+			name:       "bytes bound",
+			maxEntries: 10,
+			maxBytes:   50,
+			sizes:      []int{18, 18, 18},
+			wantKeys:   []string{"k1", "k2"},
+			wantBytes:  40,
+		},
+		{
+			name:       "result larger than the budget not cached",
+			maxEntries: 10,
+			maxBytes:   50,
+			sizes:      []int{8, 100},
+			wantKeys:   []string{"k0"},
+			wantBytes:  10,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewQueryCache(tt.maxEntries, tt.maxBytes)
+			for i, size := range tt.sizes {
+				if _, err := c.Get(fmt.Sprintf("k%d", i), time.Minute, data(size)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if stats := c.Stats(); stats.Entries != len(tt.wantKeys) || stats.Bytes != tt.wantBytes {
+				t.Errorf("entries, bytes = %d, %d, want %d, %d", stats.Entries, stats.Bytes, len(tt.wantKeys), tt.wantBytes)
+			}
+			for _, key := range tt.wantKeys {
+				if _, ok := c.entries[key]; !ok {
+					t.Errorf("%s evicted, want it cached", key)
+				}
+			}
+		})
+	}
+}
+
+func TestQueryCacheExpiry(t *testing.T) {
+	c := NewQueryCache(10, 1<<20)
+	var fetches int
+	fetch := func() ([]byte, error) {
+		fetches++
+		return []byte("result"), nil
+	}
+	if _, err := c.Get("key", time.Millisecond, fetch); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if _, err := c.Get("key", time.Minute, fetch); err != nil {
+		t.Fatal(err)
+	}
+	if fetches != 2 {
+		t.Errorf("fetches = %d, want the expired result fetched again", fetches)
+	}
+	if stats := c.Stats(); stats.Entries != 1 || stats.Bytes != int64(len("key")+len("result")) {
+		t.Errorf("entries, bytes = %d, %d, want the expired result replaced", stats.Entries, stats.Bytes)
+	}
+}
+
+// matrixResponse returns a range query response of the prometheus API with a series of the points of each job
+func matrixResponse(points map[string][]int64) string {
+	var series []string
+	for job, timestamps := range points {
+		var values []string
+		for _, ts := range timestamps {
+			values = append(values, fmt.Sprintf(`[%d,"1"]`, ts))
+		}
+		series = append(series, fmt.Sprintf(`{"metric":{"job":%q},"values":[%s]}`, job, strings.Join(values, ",")))
+	}
+	return `{"status":"success","data":{"resultType":"matrix","result":[` + strings.Join(series, ",") + `]}}`
+}
+
+func TestAppendQueryRangeResponse(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		tail string
+		// want are the timestamps of the points of each job
+		want     map[string][]int64
+		wantSame bool
+	}{
+		{
+			name: "tail appended",
+			data: matrixResponse(map[string][]int64{"a": {60, 120}, "b": {60, 120}}),
+			tail: matrixResponse(map[string][]int64{"a": {150}, "b": {150}}),
+			want: map[string][]int64{"a": {60, 120, 150}, "b": {60, 120, 150}},
+		},
+		{
+			name: "points already there skipped",
+			data: matrixResponse(map[string][]int64{"a": {60, 120}}),
+			tail: matrixResponse(map[string][]int64{"a": {120}}),
+			want: map[string][]int64{"a": {60, 120}},
+		},
+		{
+			name: "new series added",
+			data: matrixResponse(map[string][]int64{"a": {60}}),
+			tail: matrixResponse(map[string][]int64{"a": {90}, "b": {90}}),
+			want: map[string][]int64{"a": {60, 90}, "b": {90}},
+		},
+		{
+			name:     "tail not a matrix",
+			data:     matrixResponse(map[string][]int64{"a": {60}}),
+			tail:     `{"status":"error","errorType":"timeout","error":"query timed out"}`,
+			wantSame: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := AppendQueryRangeResponse([]byte(tt.data), []byte(tt.tail))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantSame {
+				if string(got) != tt.data {
+					t.Errorf("response = %s, want it unchanged", got)
+				}
+				return
+			}
+			resp := struct {
+				Status string `json:"status"`
+				Data   struct {
+					Result promModel.Matrix `json:"result"`
+				} `json:"data"`
+			}{}
+			if err = json.Unmarshal(got, &resp); err != nil {
+				t.Fatalf("unable to parse the response %s: %v", got, err)
+			}
+			if resp.Status != "success" || len(resp.Data.Result) != len(tt.want) {
+				t.Fatalf("response = %s, want %d series", got, len(tt.want))
+			}
+			for _, stream := range resp.Data.Result {
+				job := string(stream.Metric["job"])
+				var timestamps []int64
+				for _, p := range stream.Values {
+					timestamps = append(timestamps, p.Timestamp.Unix())
+				}
+				if fmt.Sprint(timestamps) != fmt.Sprint(tt.want[job]) {
+					t.Errorf("points of %s at %v, want %v", job, timestamps, tt.want[job])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package helpers
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+	"github.com/prologic/bitcask"
+	"github.com/sirupsen/logrus"
+)
+
+// queryTrackerMaxValueSize leaves room for the queries of a UUID
+const queryTrackerMaxValueSize = 1 << 22 // 4MB
+
+type trackedQueries struct {
+	Queries map[string]bool `json:"queries"`
+	Updated time.Time       `json:"updated"`
+}
+
+// BitCaskQueryTracker tracks queries for a load test UUID in a Bitcask store, so they outlive a restart.
+// A UUID expires when it is not updated for the ttl and the number of queries tracked per UUID is capped.
+type BitCaskQueryTracker struct {
+	db         *bitcask.Bitcask
+	ttl        time.Duration
+	maxQueries int
+
+	expired int64
+	dropped int64
+	// lock makes the updates of the queries of a UUID atomic
+	lock *sync.Mutex
+}
+
+// NewBitCaskQueryTracker creates a new BitCaskQueryTracker instance.
+// maxQueries is the number of queries tracked per UUID, 0 meaning no limit.
+func NewBitCaskQueryTracker(folderName string, ttl time.Duration, maxQueries int) (*BitCaskQueryTracker, error) {
+	_, err := os.Stat(folderName)
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = os.MkdirAll(folderName, os.ModePerm)
+			if err != nil {
+				logrus.Errorf("Unable to create the directory '%s' due to error: %v.", folderName, err)
+				return nil, err
+			}
+		} else {
+			logrus.Errorf("Unable to find/stat the folder '%s': %v,", folderName, err)
+			return nil, err
+		}
+	}
+
+	fileName := path.Join(folderName, "queries.db")
+	// the queries are tracked on each query of the UI, syncing each of them would slow it down
+	db, err := bitcask.Open(fileName,
+		bitcask.WithMaxValueSize(queryTrackerMaxValueSize),
+	)
+	if err != nil {
+		logrus.Errorf("Unable to open database: %v.", err)
+		return nil, err
+	}
+	return &BitCaskQueryTracker{
+		db:         db,
+		ttl:        ttl,
+		maxQueries: maxQueries,
+		lock:       &sync.Mutex{},
+	}, nil
+}
+
+// AddOrFlagQuery either adds a new query or flags an existing one
+func (a *BitCaskQueryTracker) AddOrFlagQuery(ctx context.Context, uuid, query string, flag bool) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	tracked := a.read(uuid)
+	if tracked == nil {
+		tracked = &trackedQueries{
+			Queries: map[string]bool{},
+		}
+	}
+	if _, ok := tracked.Queries[query]; !ok && a.maxQueries > 0 && len(tracked.Queries) >= a.maxQueries {
+		a.dropped++
+		logrus.Warnf("not tracking the query %s, %d queries are tracked for the load test %s already", query, a.maxQueries, uuid)
+		return
+	}
+	tracked.Queries[query] = flag
+	tracked.Updated = time.Now()
+
+	dataB, err := json.Marshal(tracked)
+	if err != nil {
+		logrus.Error(errors.Wrap(err, "Unable to marshal the tracked queries."))
+		return
+	}
+	if err = a.db.Put([]byte(uuid), dataB); err != nil {
+		logrus.Error(errors.Wrapf(err, "Unable to persist the queries of the load test %s.", uuid))
+	}
+}
+
+// RemoveUUID removes an existing UUID from the collection
+func (a *BitCaskQueryTracker) RemoveUUID(ctx context.Context, uuid string) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.delete(uuid)
+}
+
+// GetQueriesForUUID retrieves queries for UUID
+func (a *BitCaskQueryTracker) GetQueriesForUUID(ctx context.Context, uuid string) map[string]bool {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	tracked := a.read(uuid)
+	if tracked == nil {
+		return map[string]bool{}
+	}
+	return tracked.Queries
+}
+
+// Stats returns metrics on the tracked queries
+func (a *BitCaskQueryTracker) Stats(ctx context.Context) *models.QueryTrackerStats {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	stats := &models.QueryTrackerStats{}
+	for _, key := range a.keys() {
+		if tracked := a.read(key); tracked != nil {
+			stats.TrackedUUIDs++
+			stats.TrackedQueries += len(tracked.Queries)
+		}
+	}
+	// reading removes the expired UUIDs
+	stats.ExpiredUUIDs = a.expired
+	stats.DroppedQueries = a.dropped
+	return stats
+}
+
+// RemoveStaleUUIDs periodically removes the expired UUIDs until ctx is done
+func (a *BitCaskQueryTracker) RemoveStaleUUIDs(ctx context.Context) {
+	if a.ttl <= 0 {
+		return
+	}
+	ticker := time.NewTicker(a.ttl / 4)
+	defer ticker.Stop()
+	for {
+		a.lock.Lock()
+		for _, key := range a.keys() {
+			// reading removes the UUID when it is expired
+			_ = a.read(key)
+		}
+		a.lock.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Close closes the bitcask store
+func (a *BitCaskQueryTracker) Close() {
+	_ = a.db.Close()
+}
+
+// keys returns the tracked UUIDs. They can not be read while ranging over the keys of the store, as reading deletes
+// the expired ones and the store is locked until the range is over.
+func (a *BitCaskQueryTracker) keys() []string {
+	var keys []string
+	for key := range a.db.Keys() {
+		keys = append(keys, string(key))
+	}
+	return keys
+}
+
+// read returns the queries of the UUID, nil when there are none or they expired, it needs the lock
+func (a *BitCaskQueryTracker) read(uuid string) *trackedQueries {
+	dataB, err := a.db.Get([]byte(uuid))
+	if err != nil {
+		if err != bitcask.ErrKeyNotFound {
+			logrus.Error(errors.Wrapf(err, "Unable to read the queries of the load test %s.", uuid))
+		}
+		return nil
+	}
+	tracked := &trackedQueries{}
+	if err = json.Unmarshal(dataB, tracked); err != nil || tracked.Queries == nil {
+		logrus.Errorf("Unable to unmarshal the queries of the load test %s, discarding them.", uuid)
+		a.delete(uuid)
+		return nil
+	}
+	if a.ttl > 0 && time.Since(tracked.Updated) > a.ttl {
+		logrus.Debugf("removing the stale queries of the load test %s", uuid)
+		a.expired++
+		a.delete(uuid)
+		return nil
+	}
+	return tracked
+}
+
+func (a *BitCaskQueryTracker) delete(uuid string) {
+	if err := a.db.Delete([]byte(uuid)); err != nil {
+		logrus.Error(errors.Wrapf(err, "Unable to delete the queries of the load test %s.", uuid))
+	}
+}
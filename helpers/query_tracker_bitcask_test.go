@@ -0,0 +1,91 @@
+package helpers
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+// newTestQueryTracker returns a tracker stored in a temporary folder and the function removing it
+func newTestQueryTracker(t *testing.T, ttl time.Duration, maxQueries int) (*BitCaskQueryTracker, func()) {
+	dir, err := ioutil.TempDir("", "query-tracker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracker, err := NewBitCaskQueryTracker(dir, ttl, maxQueries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tracker, func() {
+		tracker.Close()
+		_ = os.RemoveAll(dir)
+	}
+}
+
+func TestBitCaskQueryTracker(t *testing.T) {
+	ctx := context.Background()
+	tracker, cleanup := newTestQueryTracker(t, time.Hour, 2)
+	defer cleanup()
+
+	tracker.AddOrFlagQuery(ctx, "test-1", "up", false)
+	tracker.AddOrFlagQuery(ctx, "test-1", "up", true)
+	tracker.AddOrFlagQuery(ctx, "test-1", "rate(requests[1m])", false)
+	// over the limit of queries per UUID
+	tracker.AddOrFlagQuery(ctx, "test-1", "rate(errors[1m])", false)
+	tracker.AddOrFlagQuery(ctx, "test-2", "up", false)
+
+	queries := tracker.GetQueriesForUUID(ctx, "test-1")
+	if len(queries) != 2 || !queries["up"] || queries["rate(requests[1m])"] {
+		t.Errorf("queries of test-1 = %v, want up flagged and rate(requests[1m]) not", queries)
+	}
+
+	stats := tracker.Stats(ctx)
+	if stats.TrackedUUIDs != 2 || stats.TrackedQueries != 3 || stats.DroppedQueries != 1 || stats.ExpiredUUIDs != 0 {
+		t.Errorf("stats = %+v, want 2 UUIDs, 3 queries and 1 dropped query", stats)
+	}
+
+	tracker.RemoveUUID(ctx, "test-1")
+	if queries := tracker.GetQueriesForUUID(ctx, "test-1"); len(queries) != 0 {
+		t.Errorf("queries of a removed UUID = %v, want none", queries)
+	}
+}
+
+func TestBitCaskQueryTrackerExpiry(t *testing.T) {
+	ctx := context.Background()
+	tracker, cleanup := newTestQueryTracker(t, 50*time.Millisecond, 0)
+	defer cleanup()
+
+	tracker.AddOrFlagQuery(ctx, "stale-1", "up", false)
+	tracker.AddOrFlagQuery(ctx, "stale-2", "up", false)
+	time.Sleep(100 * time.Millisecond)
+	tracker.AddOrFlagQuery(ctx, "fresh", "up", false)
+
+	// Stats reads and so deletes the expired UUIDs while going through them
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		stats := tracker.Stats(ctx)
+		if stats.TrackedUUIDs != 1 || stats.TrackedQueries != 1 || stats.ExpiredUUIDs != 2 {
+			t.Errorf("stats = %+v, want the fresh UUID only and 2 expired ones", stats)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stats did not return, the store is deadlocked")
+	}
+	if queries := tracker.GetQueriesForUUID(ctx, "stale-1"); len(queries) != 0 {
+		t.Errorf("queries of an expired UUID = %v, want none", queries)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	// the first pass runs before ctx is checked
+	tracker.RemoveStaleUUIDs(ctx)
+	if stats := tracker.Stats(context.Background()); stats.TrackedUUIDs != 0 || stats.ExpiredUUIDs != 3 {
+		t.Errorf("stats = %+v, want no UUID left and 3 expired ones", stats)
+	}
+}
@@ -0,0 +1,204 @@
+package helpers
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+
+	"github.com/gofrs/uuid"
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+	"github.com/prologic/bitcask"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	resultsMaxValueSize    = 1 << 24 // 16MB
+	resultsMaxDatafileSize = 1 << 26 // 64MB
+)
+
+// BitCaskResultsPersister assists with persisting load test results in a Bitcask store
+type BitCaskResultsPersister struct {
+	fileName string
+	db       *bitcask.Bitcask
+}
+
+// NewBitCaskResultsPersister creates a new BitCaskResultsPersister instance
+func NewBitCaskResultsPersister(folderName string) (*BitCaskResultsPersister, error) {
+	_, err := os.Stat(folderName)
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = os.MkdirAll(folderName, os.ModePerm)
+			if err != nil {
+				logrus.Errorf("Unable to create the directory '%s' due to error: %v.", folderName, err)
+				return nil, err
+			}
+		} else {
+			logrus.Errorf("Unable to find/stat the folder '%s': %v,", folderName, err)
+			return nil, err
+		}
+	}
+
+	fileName := path.Join(folderName, "results.db")
+	db, err := bitcask.Open(fileName,
+		bitcask.WithSync(true),
+		bitcask.WithMaxValueSize(resultsMaxValueSize),
+		bitcask.WithMaxDatafileSize(resultsMaxDatafileSize),
+	)
+	if err != nil {
+		logrus.Errorf("Unable to open database: %v.", err)
+		return nil, err
+	}
+	bd := &BitCaskResultsPersister{
+		fileName: fileName,
+		db:       db,
+	}
+	return bd, nil
+}
+
+// GetResults - gets the results of the user for the page and pageSize
+func (s *BitCaskResultsPersister) GetResults(userID string, page, pageSize uint64, search, order string) (*models.ResultsPage, error) {
+	if s.db == nil {
+		return nil, errors.New("Connection to DB does not exist.")
+	}
+
+RETRY:
+	locked, err := s.db.TryRLock()
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to obtain read lock from bitcask store")
+		logrus.Error(err)
+	}
+	if !locked {
+		goto RETRY
+	}
+	defer func() {
+		_ = s.db.Unlock()
+	}()
+
+	results := []*models.MesheryResult{}
+	for key := range s.db.Keys() {
+		dataB, err := s.db.Get(key)
+		if err != nil {
+			err = errors.Wrapf(err, "Unable to read data from bitcask store")
+			logrus.Error(err)
+			return nil, err
+		}
+		result := &models.MesheryResult{}
+		if err := json.Unmarshal(dataB, result); err != nil {
+			err = errors.Wrapf(err, "Unable to unmarshal data.")
+			logrus.Error(err)
+			return nil, err
+		}
+		if result.UserID != userID {
+			continue
+		}
+		results = append(results, result)
+	}
+
+	return paginateResults(results, page, pageSize, search, order), nil
+}
+
+// GetResult - gets a single result
+func (s *BitCaskResultsPersister) GetResult(key uuid.UUID) (*models.MesheryResult, error) {
+	if s.db == nil {
+		return nil, errors.New("Connection to DB does not exist.")
+	}
+
+RETRY:
+	locked, err := s.db.TryRLock()
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to obtain read lock from bitcask store")
+		logrus.Error(err)
+	}
+	if !locked {
+		goto RETRY
+	}
+	defer func() {
+		_ = s.db.Unlock()
+	}()
+
+	dataB, err := s.db.Get(key.Bytes())
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to read data from bitcask store")
+		logrus.Error(err)
+		return nil, err
+	}
+	result := &models.MesheryResult{}
+	if err := json.Unmarshal(dataB, result); err != nil {
+		err = errors.Wrapf(err, "Unable to unmarshal data.")
+		logrus.Error(err)
+		return nil, err
+	}
+	return result, nil
+}
+
+// WriteResult persists the result
+func (s *BitCaskResultsPersister) WriteResult(key uuid.UUID, result *models.MesheryResult) error {
+	if s.db == nil {
+		return errors.New("Connection to DB does not exist.")
+	}
+
+	if result == nil {
+		return errors.New("Given result data is nil.")
+	}
+
+RETRY:
+	locked, err := s.db.TryLock()
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to obtain write lock from bitcask store")
+		logrus.Error(err)
+	}
+	if !locked {
+		goto RETRY
+	}
+	defer func() {
+		_ = s.db.Unlock()
+	}()
+
+	dataB, err := json.Marshal(result)
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to marshal the result data.")
+		logrus.Error(err)
+		return err
+	}
+
+	if err := s.db.Put(key.Bytes(), dataB); err != nil {
+		err = errors.Wrapf(err, "Unable to persist result data.")
+		return err
+	}
+	return nil
+}
+
+// DeleteResult removes the result
+func (s *BitCaskResultsPersister) DeleteResult(key uuid.UUID) error {
+	if s.db == nil {
+		return errors.New("Connection to DB does not exist.")
+	}
+
+RETRY:
+	locked, err := s.db.TryLock()
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to obtain write lock from bitcask store")
+		logrus.Error(err)
+	}
+	if !locked {
+		goto RETRY
+	}
+	defer func() {
+		_ = s.db.Unlock()
+	}()
+
+	if err := s.db.Delete(key.Bytes()); err != nil {
+		err = errors.Wrapf(err, "Unable to delete result data for the id: %s.", key)
+		return err
+	}
+	return nil
+}
+
+// Close closes the bitcask store
+func (s *BitCaskResultsPersister) Close() {
+	if s.db == nil {
+		return
+	}
+	_ = s.db.Close()
+}
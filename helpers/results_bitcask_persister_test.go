@@ -0,0 +1,61 @@
+package helpers
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/gofrs/uuid"
+	"github.com/layer5io/meshery/models"
+)
+
+func TestBitCaskResultsPersisterOwner(t *testing.T) {
+	dir, err := ioutil.TempDir("", "results")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	persister, err := NewBitCaskResultsPersister(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer persister.Close()
+
+	for _, r := range []*models.MesheryResult{
+		{Name: "a-1", UserID: "a"},
+		{Name: "a-2", UserID: "a"},
+		{Name: "b-1", UserID: "b"},
+		// the results stored before the owner was recorded
+		{Name: "legacy"},
+	} {
+		r.ID = uuid.Must(uuid.NewV4())
+		if err = persister.WriteResult(r.ID, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		userID    string
+		wantCount int
+	}{
+		{userID: "a", wantCount: 2},
+		{userID: "b", wantCount: 1},
+		{userID: "c", wantCount: 0},
+	}
+	for _, tt := range tests {
+		page, err := persister.GetResults(tt.userID, 0, 10, "", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page.TotalCount != tt.wantCount || len(page.Results) != tt.wantCount {
+			t.Errorf("results of %s = %d, want %d", tt.userID, page.TotalCount, tt.wantCount)
+		}
+		for _, r := range page.Results {
+			if r.UserID != tt.userID {
+				t.Errorf("results of %s hold %s of %q", tt.userID, r.Name, r.UserID)
+			}
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package helpers
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/layer5io/meshery/models"
+)
+
+// significanceLevel is the p-value under which a difference is considered significant
+const significanceLevel = 0.05
+
+// comparisonPercentiles are the latency percentiles compared
+var comparisonPercentiles = []float64{50, 75, 90, 99, 99.9}
+
+// CompareResults compares the results with the first one, the baseline
+func CompareResults(results []*models.MesheryResult) *models.ResultsComparison {
+	comparison := &models.ResultsComparison{
+		Results: []*models.ResultSummary{},
+		Deltas:  []*models.ResultDelta{},
+	}
+	for _, r := range results {
+		comparison.Results = append(comparison.Results, SummarizeResult(r))
+	}
+	if len(comparison.Results) == 0 {
+		return comparison
+	}
+	baseline := comparison.Results[0]
+	for _, s := range comparison.Results[1:] {
+		comparison.Deltas = append(comparison.Deltas, compareSummaries(baseline, s))
+	}
+	return comparison
+}
+
+// MeshOverhead compares the result of a load test run with sidecars to the one of the same load test run without
+func MeshOverhead(baseline, mesh *models.MesheryResult) *models.MeshOverhead {
+	o := &models.MeshOverhead{
+		Baseline: SummarizeResult(baseline),
+		Mesh:     SummarizeResult(mesh),
+	}
+	o.Overhead = compareSummaries(o.Baseline, o.Mesh)
+	return o
+}
+
+// SummarizeResult normalizes the metrics of a result.
+// The percentiles reported by the load generator are used when available, the missing ones being computed from the
+// latency histogram, its wrk2 cumulative buckets de-accumulated first.
+func SummarizeResult(r *models.MesheryResult) *models.ResultSummary {
+	s := &models.ResultSummary{
+		ID:        r.ID,
+		Name:      r.Name,
+		Mesh:      r.Mesh,
+		Latencies: map[string]float64{},
+	}
+	if r.Result != nil {
+		s.RunType, _ = r.Result["RunType"].(string)
+		st, _ := r.Result["StartTime"].(string)
+		s.StartTime, _ = time.Parse(time.RFC3339Nano, st)
+		s.RequestedQPS, _ = r.Result["RequestedQPS"].(string)
+		s.QPS, _ = r.Result["ActualQPS"].(float64)
+		if rate, err := errorRate(r.Result); err == nil {
+			s.ErrorRate = &rate
+		}
+
+		if hist := histogramDataFromMap(r.Result["DurationHistogram"]); hist != nil && hist.Count > 0 {
+			s.Requests = hist.Count
+			s.LatencyStdDev = hist.StdDev * 1000
+			s.Latencies["avg"] = hist.Avg * 1000
+			s.Latencies["min"] = hist.Min * 1000
+			s.Latencies["max"] = hist.Max * 1000
+			for _, p := range HistogramPercentiles(hist, comparisonPercentiles) {
+				s.Latencies[percentileName(p.Percentile)] = p.Value * 1000
+			}
+		}
+	}
+	s.ServerMetrics = serverMetricsAverages(r.ServerMetrics)
+	return s
+}
+
+// percentileName returns the name of the percentile used by the UI, like p99_9
+func percentileName(p float64) string {
+	return "p" + strings.Replace(strconv.FormatFloat(p, 'f', -1, 64), ".", "_", 1)
+}
+
+func compareSummaries(baseline, s *models.ResultSummary) *models.ResultDelta {
+	d := &models.ResultDelta{
+		ID:        s.ID,
+		Latencies: map[string]*models.MetricDelta{},
+		QPS:       newMetricDelta(baseline.QPS, s.QPS),
+	}
+	// an unknown error rate is not compared
+	errorRatesKnown := baseline.ErrorRate != nil && s.ErrorRate != nil
+	if errorRatesKnown {
+		d.ErrorRate = newMetricDelta(*baseline.ErrorRate, *s.ErrorRate)
+	}
+	for name, b := range baseline.Latencies {
+		if v, ok := s.Latencies[name]; ok {
+			d.Latencies[name] = newMetricDelta(b, v)
+		}
+	}
+	if len(baseline.ServerMetrics) > 0 && len(s.ServerMetrics) > 0 {
+		d.ServerMetrics = map[string]*models.MetricDelta{}
+		for query, b := range baseline.ServerMetrics {
+			if v, ok := s.ServerMetrics[query]; ok {
+				d.ServerMetrics[query] = newMetricDelta(b, v)
+			}
+		}
+	}
+	if baseline.Requests > 1 && s.Requests > 1 {
+		d.LatencySignificance = welchTTest(baseline.Latencies["avg"], baseline.LatencyStdDev, baseline.Requests,
+			s.Latencies["avg"], s.LatencyStdDev, s.Requests)
+		if errorRatesKnown {
+			d.ErrorRateSignificance = twoProportionZTest(*baseline.ErrorRate/100, baseline.Requests, *s.ErrorRate/100, s.Requests)
+		}
+	}
+	return d
+}
+
+func newMetricDelta(baseline, value float64) *models.MetricDelta {
+	d := &models.MetricDelta{
+		Baseline: baseline,
+		Value:    value,
+		Delta:    value - baseline,
+	}
+	if baseline != 0 {
+		p := d.Delta / baseline * 100
+		d.DeltaPercent = &p
+	}
+	return d
+}
+
+// welchTTest tests whether two means differ. With the number of requests of a load test the t distribution
+// is close enough to the normal one to use the latter for the p-value.
+func welchTTest(mean1, stdDev1 float64, n1 int64, mean2, stdDev2 float64, n2 int64) *models.Significance {
+	se := math.Sqrt(stdDev1*stdDev1/float64(n1) + stdDev2*stdDev2/float64(n2))
+	return zSignificance("welch t-test", mean2-mean1, se)
+}
+
+// twoProportionZTest tests whether two proportions differ
+func twoProportionZTest(p1 float64, n1 int64, p2 float64, n2 int64) *models.Significance {
+	pooled := (p1*float64(n1) + p2*float64(n2)) / float64(n1+n2)
+	se := math.Sqrt(pooled * (1 - pooled) * (1/float64(n1) + 1/float64(n2)))
+	return zSignificance("two-proportion z-test", p2-p1, se)
+}
+
+func zSignificance(test string, diff, se float64) *models.Significance {
+	s := &models.Significance{
+		Test:   test,
+		PValue: 1,
+	}
+	switch {
+	case se > 0:
+		s.Statistic = diff / se
+		// two tailed p-value of the standard normal distribution
+		s.PValue = math.Erfc(math.Abs(s.Statistic) / math.Sqrt2)
+	case diff != 0:
+		// no variance at all, any difference is significant
+		s.Statistic = math.Copysign(math.MaxFloat64, diff)
+		s.PValue = 0
+	}
+	s.Significant = s.PValue < significanceLevel
+	return s
+}
+
+// flattenServerMetrics returns the results of the server metrics keyed by query, the queries of the nodes and of the
+// meshes being prefixed with the node or the mesh
+func flattenServerMetrics(serverMetrics interface{}) map[string]interface{} {
+	queries, _ := serverMetrics.(map[string]interface{})
+	flattened := map[string]interface{}{}
+	for query, resp := range queries {
+		if query != models.ServerMetricsNodesKey && query != models.ServerMetricsMeshesKey {
+			flattened[query] = resp
+		}
+	}
+	for _, key := range []string{models.ServerMetricsNodesKey, models.ServerMetricsMeshesKey} {
+		boards, _ := queries[key].(map[string]interface{})
+		for board, boardQueriesI := range boards {
+			boardQueries, _ := boardQueriesI.(map[string]interface{})
+			for query, resp := range boardQueries {
+				flattened[fmt.Sprintf("[%s] %s", board, query)] = resp
+			}
+		}
+	}
+	return flattened
+}
+
+// serverMetricsAverages returns the average of all the samples of each of the Prometheus queries of the server
+// metrics, which are stored as Prometheus query range responses
+func serverMetricsAverages(serverMetrics interface{}) map[string]float64 {
+	queries := flattenServerMetrics(serverMetrics)
+	if len(queries) == 0 {
+		return nil
+	}
+	averages := map[string]float64{}
+	for query, respI := range queries {
+		resp, _ := respI.(map[string]interface{})
+		data, _ := resp["data"].(map[string]interface{})
+		series, _ := data["result"].([]interface{})
+		var sum, count float64
+		for _, seriesI := range series {
+			s, _ := seriesI.(map[string]interface{})
+			values, _ := s["values"].([]interface{})
+			for _, valueI := range values {
+				// each value is a [timestamp, "value"] pair
+				pair, _ := valueI.([]interface{})
+				if len(pair) != 2 {
+					continue
+				}
+				v, err := strconv.ParseFloat(fmt.Sprint(pair[1]), 64)
+				if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+					continue
+				}
+				sum += v
+				count++
+			}
+		}
+		if count > 0 {
+			averages[query] = sum / count
+		}
+	}
+	return averages
+}
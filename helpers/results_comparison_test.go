@@ -0,0 +1,166 @@
+package helpers
+
+import (
+	"math"
+	"testing"
+
+	"github.com/layer5io/meshery/models"
+)
+
+func TestSummarizeResult(t *testing.T) {
+	s := SummarizeResult(&models.MesheryResult{Name: "wrk2", Result: wrk2Results()})
+	want := map[string]float64{
+		"avg": 3,
+		"min": 1,
+		"max": 10,
+		// reported by wrk2
+		"p99": 9.7,
+		// computed from the de-accumulated buckets
+		"p50":   2,
+		"p75":   3.25,
+		"p90":   4,
+		"p99_9": 9.94,
+	}
+	for name, v := range want {
+		if math.Abs(s.Latencies[name]-v) > 1e-9 {
+			t.Errorf("%s = %g, want %g", name, s.Latencies[name], v)
+		}
+	}
+	if s.Requests != 100 {
+		t.Errorf("requests = %d, want 100", s.Requests)
+	}
+	if s.ErrorRate == nil || *s.ErrorRate != 2 {
+		t.Errorf("error rate = %v, want 2", s.ErrorRate)
+	}
+	if s.QPS != 95 || s.RequestedQPS != "100" {
+		t.Errorf("qps = %g of %s, want 95 of 100", s.QPS, s.RequestedQPS)
+	}
+
+	empty := SummarizeResult(&models.MesheryResult{Name: "empty"})
+	if len(empty.Latencies) != 0 {
+		t.Errorf("latencies of a result without data = %v, want none", empty.Latencies)
+	}
+	if empty.ErrorRate != nil {
+		t.Errorf("error rate of a result without data = %g, want it unknown", *empty.ErrorRate)
+	}
+}
+
+func TestCompareResults(t *testing.T) {
+	baseline := wrk2Results()
+	slower := wrk2Results()
+	slower["ActualQPS"] = 80.0
+	comparison := CompareResults([]*models.MesheryResult{
+		{Name: "baseline", Result: baseline},
+		{Name: "slower", Result: slower},
+	})
+	if len(comparison.Results) != 2 || len(comparison.Deltas) != 1 {
+		t.Fatalf("got %d summaries and %d deltas, want 2 and 1", len(comparison.Results), len(comparison.Deltas))
+	}
+	d := comparison.Deltas[0]
+	if d.QPS.Delta != -15 || d.QPS.DeltaPercent == nil || math.Abs(*d.QPS.DeltaPercent+15.0/95*100) > 1e-9 {
+		t.Errorf("qps delta = %+v, want -15", d.QPS)
+	}
+	if p99 := d.Latencies["p99"]; p99 == nil || p99.Delta != 0 {
+		t.Errorf("p99 delta = %+v, want 0", p99)
+	}
+	if d.LatencySignificance == nil || d.LatencySignificance.Significant {
+		t.Errorf("latency significance = %+v, want not significant", d.LatencySignificance)
+	}
+	if d.ErrorRate == nil || d.ErrorRate.Delta != 0 || d.ErrorRateSignificance == nil {
+		t.Errorf("error rate delta = %+v, want 0 along with its significance", d.ErrorRate)
+	}
+
+	// without any request counts the error rate is unknown rather than 0
+	noCounts := wrk2Results()
+	delete(noCounts, "Errors")
+	comparison = CompareResults([]*models.MesheryResult{
+		{Name: "baseline", Result: baseline},
+		{Name: "no counts", Result: noCounts},
+	})
+	if d = comparison.Deltas[0]; d.ErrorRate != nil || d.ErrorRateSignificance != nil {
+		t.Errorf("error rate delta = %+v, significance = %+v, want none", d.ErrorRate, d.ErrorRateSignificance)
+	}
+	if d.LatencySignificance == nil {
+		t.Error("no latency significance, want it along with the unknown error rate")
+	}
+
+	if c := CompareResults(nil); len(c.Results) != 0 || len(c.Deltas) != 0 {
+		t.Errorf("comparison of no results = %+v, want an empty one", c)
+	}
+}
+
+func TestNewMetricDelta(t *testing.T) {
+	d := newMetricDelta(0, 5)
+	if d.Delta != 5 || d.DeltaPercent != nil {
+		t.Errorf("delta from 0 = %+v, want 5 without a percentage", d)
+	}
+	d = newMetricDelta(4, 5)
+	if d.Delta != 1 || d.DeltaPercent == nil || *d.DeltaPercent != 25 {
+		t.Errorf("delta from 4 = %+v, want 1, 25%%", d)
+	}
+}
+
+func TestSignificance(t *testing.T) {
+	zero, one := 0.0, 1.0
+	tests := []struct {
+		name            string
+		s               *models.Significance
+		wantSignificant bool
+		// wantPValue is only checked when the p-value is exact
+		wantPValue *float64
+	}{
+		{name: "same means", s: welchTTest(10, 2, 100, 10, 2, 100), wantPValue: &one},
+		{name: "different means", s: welchTTest(10, 1, 10000, 11, 1, 10000), wantSignificant: true},
+		{name: "close means", s: welchTTest(10, 5, 10, 10.1, 5, 10), wantSignificant: false},
+		{name: "no variance", s: welchTTest(10, 0, 100, 11, 0, 100), wantSignificant: true, wantPValue: &zero},
+		{name: "same error rates", s: twoProportionZTest(0.01, 1000, 0.01, 1000), wantPValue: &one},
+		{name: "different error rates", s: twoProportionZTest(0.01, 10000, 0.05, 10000), wantSignificant: true},
+		{name: "no errors", s: twoProportionZTest(0, 1000, 0, 1000), wantPValue: &one},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.s.Significant != tt.wantSignificant {
+				t.Errorf("significant = %v (p-value %g), want %v", tt.s.Significant, tt.s.PValue, tt.wantSignificant)
+			}
+			if tt.wantPValue != nil && tt.s.PValue != *tt.wantPValue {
+				t.Errorf("p-value = %g, want %g", tt.s.PValue, *tt.wantPValue)
+			}
+		})
+	}
+}
+
+func TestPercentileName(t *testing.T) {
+	for p, want := range map[float64]string{50: "p50", 99: "p99", 99.9: "p99_9"} {
+		if got := percentileName(p); got != want {
+			t.Errorf("percentileName(%g) = %s, want %s", p, got, want)
+		}
+	}
+}
+
+func TestServerMetricsAverages(t *testing.T) {
+	resp := func(values ...interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"data": map[string]interface{}{
+				"result": []interface{}{
+					map[string]interface{}{"values": values},
+				},
+			},
+		}
+	}
+	metrics := map[string]interface{}{
+		"cpu": resp([]interface{}{1.0, "1"}, []interface{}{2.0, "3"}, []interface{}{3.0, "NaN"}),
+		models.ServerMetricsNodesKey: map[string]interface{}{
+			"node-1": map[string]interface{}{"memory": resp([]interface{}{1.0, "10"})},
+		},
+	}
+	got := serverMetricsAverages(metrics)
+	if got["cpu"] != 2 {
+		t.Errorf("cpu = %g, want 2, NaN samples being skipped", got["cpu"])
+	}
+	if got["[node-1] memory"] != 10 {
+		t.Errorf("node memory = %g, want 10", got["[node-1] memory"])
+	}
+	if serverMetricsAverages(nil) != nil {
+		t.Error("no server metrics should have no averages")
+	}
+}
@@ -0,0 +1,474 @@
+package helpers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// latencyOrder is the order the latencies are exported in, other percentiles come after
+var latencyOrder = []string{"min", "avg", "p50", "p75", "p90", "p99", "p99_9", "max"}
+
+// resultReport holds what is exported of a result, in a form shared by all the export formats
+type resultReport struct {
+	Result      *models.MesheryResult
+	Summary     *models.ResultSummary
+	Duration    time.Duration
+	AbortReason string
+	Latencies   []*namedValue
+	Histogram   []*histogramBucket
+	RetCodes    []*retCodeCount
+
+	KubernetesVersion string
+	Nodes             []*models.K8SNode
+	Meshes            []*namedValue
+
+	ServerMetrics []*serverMetric
+}
+
+type namedValue struct {
+	Name  string
+	Value string
+}
+
+// histogramBucket is a bucket of the latency histogram, in milliseconds
+type histogramBucket struct {
+	Start   float64
+	End     float64
+	Count   int64
+	Percent float64
+}
+
+type retCodeCount struct {
+	Code  string
+	Count int64
+}
+
+// serverMetric holds the samples of a Prometheus query collected during the load test
+type serverMetric struct {
+	Query   string
+	Average float64
+	Series  []*metricSeries
+}
+
+type metricSeries struct {
+	Labels string
+	Points []*metricPoint
+}
+
+type metricPoint struct {
+	Time  time.Time
+	Value float64
+}
+
+// ExportResult writes the result in the given format
+func ExportResult(w io.Writer, result *models.MesheryResult, format models.ResultExportFormat) error {
+	report := newResultReport(result)
+	var err error
+	switch format {
+	case models.CSVExport:
+		err = exportResultCSV(w, report)
+	case models.JUnitExport:
+		err = exportResultJUnit(w, report)
+	case models.HTMLExport:
+		err = exportResultHTML(w, report)
+	default:
+		err = fmt.Errorf("unsupported export format: %s", format)
+	}
+	if err != nil {
+		err = errors.Wrapf(err, "unable to export the result to %s", format)
+		logrus.Error(err)
+	}
+	return err
+}
+
+func newResultReport(r *models.MesheryResult) *resultReport {
+	report := &resultReport{
+		Result:  r,
+		Summary: SummarizeResult(r),
+	}
+	for _, name := range sortedLatencyNames(report.Summary.Latencies) {
+		report.Latencies = append(report.Latencies, &namedValue{
+			Name:  name,
+			Value: formatFloat(report.Summary.Latencies[name]),
+		})
+	}
+	if r.Result == nil {
+		return report
+	}
+
+	d, _ := r.Result["ActualDuration"].(float64)
+	report.Duration = time.Duration(d)
+	report.AbortReason, _ = r.Result["abort_reason"].(string)
+
+	if hist := histogramDataFromMap(r.Result["DurationHistogram"]); hist != nil {
+		for _, b := range hist.Data {
+			report.Histogram = append(report.Histogram, &histogramBucket{
+				Start:   b.Start * 1000,
+				End:     b.End * 1000,
+				Count:   b.Count,
+				Percent: b.Percent,
+			})
+		}
+	}
+
+	for code, count := range retCodesFromMap(r.Result["RetCodes"]) {
+		report.RetCodes = append(report.RetCodes, &retCodeCount{
+			Code:  code,
+			Count: int64(count),
+		})
+	}
+	sort.Slice(report.RetCodes, func(i, j int) bool {
+		return report.RetCodes[i].Code < report.RetCodes[j].Code
+	})
+
+	if k8s, ok := r.Result["kubernetes"].(map[string]interface{}); ok {
+		report.KubernetesVersion, _ = k8s["server_version"].(string)
+		if bd, err := json.Marshal(k8s["nodes"]); err == nil {
+			_ = json.Unmarshal(bd, &report.Nodes)
+		}
+	}
+	meshes, _ := r.Result["detected-meshes"].(map[string]interface{})
+	for name, version := range meshes {
+		report.Meshes = append(report.Meshes, &namedValue{
+			Name:  name,
+			Value: fmt.Sprint(version),
+		})
+	}
+	sort.Slice(report.Meshes, func(i, j int) bool {
+		return report.Meshes[i].Name < report.Meshes[j].Name
+	})
+
+	report.ServerMetrics = serverMetricsSeries(r.ServerMetrics, report.Summary.ServerMetrics)
+	return report
+}
+
+// serverMetricsSeries extracts the series of each of the Prometheus queries of the server metrics, which are
+// stored as Prometheus query range responses
+func serverMetricsSeries(serverMetrics interface{}, averages map[string]float64) []*serverMetric {
+	queries := flattenServerMetrics(serverMetrics)
+	metrics := []*serverMetric{}
+	for query, respI := range queries {
+		m := &serverMetric{
+			Query:   query,
+			Average: averages[query],
+		}
+		resp, _ := respI.(map[string]interface{})
+		data, _ := resp["data"].(map[string]interface{})
+		results, _ := data["result"].([]interface{})
+		for _, resultI := range results {
+			result, _ := resultI.(map[string]interface{})
+			series := &metricSeries{
+				Labels: seriesLabels(result["metric"]),
+			}
+			values, _ := result["values"].([]interface{})
+			for _, valueI := range values {
+				// each value is a [timestamp, "value"] pair
+				pair, _ := valueI.([]interface{})
+				if len(pair) != 2 {
+					continue
+				}
+				ts, _ := pair[0].(float64)
+				s, _ := pair[1].(string)
+				v, err := strconv.ParseFloat(s, 64)
+				if err != nil {
+					continue
+				}
+				series.Points = append(series.Points, &metricPoint{
+					Time:  time.Unix(0, int64(ts*float64(time.Second))),
+					Value: v,
+				})
+			}
+			if len(series.Points) > 0 {
+				m.Series = append(m.Series, series)
+			}
+		}
+		metrics = append(metrics, m)
+	}
+	sort.Slice(metrics, func(i, j int) bool {
+		return metrics[i].Query < metrics[j].Query
+	})
+	return metrics
+}
+
+// seriesLabels formats the labels of a Prometheus series like {a="b", c="d"}
+func seriesLabels(metric interface{}) string {
+	labels, _ := metric.(map[string]interface{})
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s=%q", name, fmt.Sprint(labels[name])))
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
+
+func sortedLatencyNames(latencies map[string]float64) []string {
+	names := []string{}
+	for _, name := range latencyOrder {
+		if _, ok := latencies[name]; ok {
+			names = append(names, name)
+		}
+	}
+	others := []string{}
+	for name := range latencies {
+		known := false
+		for _, n := range latencyOrder {
+			known = known || n == name
+		}
+		if !known {
+			others = append(others, name)
+		}
+	}
+	sort.Strings(others)
+	return append(names, others...)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// formatErrorRate formats the error rate of a summary, which is unknown when the results hold no request counts
+func formatErrorRate(v *float64) string {
+	if v == nil {
+		return "unknown"
+	}
+	return formatFloat(*v)
+}
+
+// exportResultCSV writes the result as rows of section, name, value, unit and status, the status being only set
+// for the SLO assertions
+func exportResultCSV(w io.Writer, report *resultReport) error {
+	cw := csv.NewWriter(w)
+	rows := [][]string{{"section", "name", "value", "unit", "status"}}
+	add := func(section, name, value, unit string) {
+		rows = append(rows, []string{section, name, value, unit, ""})
+	}
+
+	s := report.Summary
+	add("summary", "name", s.Name, "")
+	add("summary", "mesh", s.Mesh, "")
+	add("summary", "run_type", s.RunType, "")
+	if !s.StartTime.IsZero() {
+		add("summary", "start_time", s.StartTime.Format(time.RFC3339), "")
+	}
+	add("summary", "duration", formatFloat(report.Duration.Seconds()), "s")
+	add("summary", "requested_qps", s.RequestedQPS, "req/s")
+	add("summary", "qps", formatFloat(s.QPS), "req/s")
+	add("summary", "requests", strconv.FormatInt(s.Requests, 10), "")
+	add("summary", "error_rate", formatErrorRate(s.ErrorRate), "%")
+	if report.AbortReason != "" {
+		add("summary", "abort_reason", report.AbortReason, "")
+	}
+	if report.Result.Verdict != "" {
+		add("summary", "verdict", string(report.Result.Verdict), "")
+	}
+
+	for _, l := range report.Latencies {
+		add("latency", l.Name, l.Value, "ms")
+	}
+	for _, b := range report.Histogram {
+		add("histogram", fmt.Sprintf("%s-%s", formatFloat(b.Start), formatFloat(b.End)), strconv.FormatInt(b.Count, 10), "ms")
+	}
+	for _, c := range report.RetCodes {
+		add("status_code", c.Code, strconv.FormatInt(c.Count, 10), "")
+	}
+
+	if report.KubernetesVersion != "" {
+		add("kubernetes", "server_version", report.KubernetesVersion, "")
+	}
+	for _, n := range report.Nodes {
+		for _, field := range nodeFields(n) {
+			add("kubernetes_node", n.HostName+" "+field.Name, field.Value, "")
+		}
+	}
+	for _, m := range report.Meshes {
+		add("mesh", m.Name, m.Value, "")
+	}
+	for _, m := range report.ServerMetrics {
+		add("server_metric", m.Query, formatFloat(m.Average), "")
+	}
+
+	for _, a := range report.Result.Assertions {
+		status := string(models.SLOPass)
+		if a.Error != "" {
+			status = string(models.SLOError) + ": " + a.Error
+		} else if !a.Passed {
+			status = string(models.SLOFail)
+		}
+		rows = append(rows, []string{"slo_assertion", assertionName(a), formatFloat(a.Actual), "", status})
+	}
+
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+func nodeFields(n *models.K8SNode) []*namedValue {
+	fields := []*namedValue{
+		{"internal_ip", n.InternalIP},
+		{"allocatable_cpu", n.AllocatableCPU},
+		{"allocatable_memory", n.AllocatableMemory},
+		{"capacity_cpu", n.CapacityCPU},
+		{"capacity_memory", n.CapacityMemory},
+		{"os_image", n.OSImage},
+		{"operating_system", n.OperatingSystem},
+		{"architecture", n.Architecture},
+		{"kubelet_version", n.KubeletVersion},
+		{"kubeproxy_version", n.KubeProxyVersion},
+		{"container_runtime_version", n.ContainerRuntimeVersion},
+	}
+	set := fields[:0]
+	for _, f := range fields {
+		if f.Value != "" {
+			set = append(set, f)
+		}
+	}
+	return set
+}
+
+func assertionName(a *models.SLOAssertionResult) string {
+	if a.Assertion == nil {
+		return ""
+	}
+	if a.Assertion.Name != "" {
+		return a.Assertion.Name
+	}
+	return a.Assertion.String()
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name          `xml:"testsuites"`
+	Suites  []*junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string           `xml:"name,attr"`
+	Tests      int              `xml:"tests,attr"`
+	Failures   int              `xml:"failures,attr"`
+	Errors     int              `xml:"errors,attr"`
+	Time       string           `xml:"time,attr"`
+	Timestamp  string           `xml:"timestamp,attr,omitempty"`
+	Properties []*junitProperty `xml:"properties>property,omitempty"`
+	TestCases  []*junitTestCase `xml:"testcase"`
+	SystemOut  string           `xml:"system-out,omitempty"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Error     *junitFailure `xml:"error,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// exportResultJUnit writes the result as a JUnit test suite, for CI systems to fail a build on a bad load test.
+// The completion of the load test is a test case, failing when it was aborted, and so is each SLO assertion.
+func exportResultJUnit(w io.Writer, report *resultReport) error {
+	s := report.Summary
+	classname := "meshery.load-test"
+	if s.Mesh != "" {
+		classname = "meshery." + s.Mesh
+	}
+	suite := &junitTestSuite{
+		Name: s.Name,
+		Time: formatFloat(report.Duration.Seconds()),
+		Properties: []*junitProperty{
+			{"meshery_id", s.ID.String()},
+			{"mesh", s.Mesh},
+			{"run_type", s.RunType},
+			{"requested_qps", s.RequestedQPS},
+			{"qps", formatFloat(s.QPS)},
+			{"requests", strconv.FormatInt(s.Requests, 10)},
+			{"error_rate", formatErrorRate(s.ErrorRate)},
+		},
+	}
+	if !s.StartTime.IsZero() {
+		suite.Timestamp = s.StartTime.Format("2006-01-02T15:04:05")
+	}
+	for _, l := range report.Latencies {
+		suite.Properties = append(suite.Properties, &junitProperty{"latency_" + l.Name + "_ms", l.Value})
+	}
+
+	completed := &junitTestCase{
+		Name:      "load test completed",
+		Classname: classname,
+		Time:      suite.Time,
+	}
+	if report.AbortReason != "" {
+		completed.Failure = &junitFailure{
+			Message: "the load test was aborted",
+			Type:    "aborted",
+			Text:    report.AbortReason,
+		}
+		suite.Failures++
+	}
+	suite.TestCases = append(suite.TestCases, completed)
+
+	for _, a := range report.Result.Assertions {
+		tc := &junitTestCase{
+			Name:      assertionName(a),
+			Classname: classname + ".slo",
+			Time:      "0",
+		}
+		switch {
+		case a.Error != "":
+			tc.Error = &junitFailure{
+				Message: a.Error,
+				Type:    string(models.SLOError),
+			}
+			suite.Errors++
+		case !a.Passed:
+			tc.Failure = &junitFailure{
+				Message: fmt.Sprintf("%s, actual value: %s", a.Assertion, formatFloat(a.Actual)),
+				Type:    string(models.SLOFail),
+			}
+			suite.Failures++
+		}
+		suite.TestCases = append(suite.TestCases, tc)
+	}
+	suite.Tests = len(suite.TestCases)
+
+	out := &strings.Builder{}
+	fmt.Fprintf(out, "%s: %s qps for %v, %d requests, error rate %s%%\n", s.Name, formatFloat(s.QPS), report.Duration, s.Requests, formatErrorRate(s.ErrorRate))
+	for _, l := range report.Latencies {
+		fmt.Fprintf(out, "%s: %s ms\n", l.Name, l.Value)
+	}
+	suite.SystemOut = out.String()
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(&junitTestSuites{Suites: []*junitTestSuite{suite}}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
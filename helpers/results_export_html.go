@@ -0,0 +1,253 @@
+package helpers
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	chartWidth  = 760.0
+	chartHeight = 220.0
+)
+
+// chartColors are used in turn for the series of a chart
+var chartColors = []string{"#00b39f", "#3c494f", "#ebc017", "#477e96", "#f05a28", "#8e44ad", "#00d3a9", "#c0392b"}
+
+type svgBar struct {
+	X, Y, Width, Height float64
+	Title               string
+}
+
+type svgLine struct {
+	Points string
+	Color  string
+	Labels string
+}
+
+type lineChart struct {
+	Query    string
+	Average  string
+	Lines    []*svgLine
+	MinValue string
+	MaxValue string
+	Start    string
+	End      string
+}
+
+type htmlReport struct {
+	*resultReport
+	Width, Height float64
+	Bars          []*svgBar
+	MinLatency    string
+	MaxLatency    string
+	Charts        []*lineChart
+}
+
+var resultHTMLTemplate = template.Must(template.New("result").Funcs(template.FuncMap{
+	"nodeFields": nodeFields,
+	"assertion":  assertionName,
+	"float":      formatFloat,
+	"errorRate": func(v *float64) string {
+		if v == nil {
+			return "unknown"
+		}
+		return fmt.Sprintf("%.2f %%", *v)
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Summary.Name}} - Meshery load test report</title>
+<style>
+body { font-family: sans-serif; color: #3c494f; margin: 2em; }
+h1 { color: #00b39f; }
+table { border-collapse: collapse; margin-bottom: 1.5em; }
+th, td { border: 1px solid #ddd; padding: 4px 10px; text-align: left; }
+th { background: #f3f3f3; }
+svg { border: 1px solid #ddd; margin-bottom: 1.5em; }
+.pass { color: #00b39f; }
+.fail, .error { color: #c0392b; }
+.legend { font-size: 0.85em; }
+</style>
+</head>
+<body>
+<h1>{{.Summary.Name}}</h1>
+<table>
+<tr><th>Result id</th><td>{{.Summary.ID}}</td></tr>
+{{if .Summary.Mesh}}<tr><th>Mesh</th><td>{{.Summary.Mesh}}</td></tr>{{end}}
+{{if .Summary.RunType}}<tr><th>Run type</th><td>{{.Summary.RunType}}</td></tr>{{end}}
+{{if not .Summary.StartTime.IsZero}}<tr><th>Start time</th><td>{{.Summary.StartTime.Format "2006-01-02 15:04:05 MST"}}</td></tr>{{end}}
+<tr><th>Duration</th><td>{{.Duration}}</td></tr>
+<tr><th>Requested QPS</th><td>{{.Summary.RequestedQPS}}</td></tr>
+<tr><th>Actual QPS</th><td>{{printf "%.2f" .Summary.QPS}}</td></tr>
+<tr><th>Requests</th><td>{{.Summary.Requests}}</td></tr>
+<tr><th>Error rate</th><td>{{errorRate .Summary.ErrorRate}}</td></tr>
+{{if .AbortReason}}<tr><th>Aborted</th><td class="fail">{{.AbortReason}}</td></tr>{{end}}
+{{if .Result.Verdict}}<tr><th>SLO verdict</th><td class="{{.Result.Verdict}}">{{.Result.Verdict}}</td></tr>{{end}}
+</table>
+
+{{if .Latencies}}
+<h2>Latency</h2>
+<table>
+<tr>{{range .Latencies}}<th>{{.Name}}</th>{{end}}</tr>
+<tr>{{range .Latencies}}<td>{{.Value}} ms</td>{{end}}</tr>
+</table>
+{{end}}
+
+{{if .Bars}}
+<h2>Latency histogram</h2>
+<svg width="{{.Width}}" height="{{.Height}}" viewBox="0 0 {{.Width}} {{.Height}}" xmlns="http://www.w3.org/2000/svg">
+{{range .Bars}}<rect x="{{.X}}" y="{{.Y}}" width="{{.Width}}" height="{{.Height}}" fill="#00b39f"><title>{{.Title}}</title></rect>
+{{end}}<text x="4" y="{{.Height}}" dy="-4" font-size="11">{{.MinLatency}} ms</text>
+<text x="{{.Width}}" y="{{.Height}}" dx="-4" dy="-4" font-size="11" text-anchor="end">{{.MaxLatency}} ms</text>
+</svg>
+{{end}}
+
+{{if .RetCodes}}
+<h2>Status codes</h2>
+<table>
+<tr><th>Code</th><th>Count</th></tr>
+{{range .RetCodes}}<tr><td>{{.Code}}</td><td>{{.Count}}</td></tr>
+{{end}}</table>
+{{end}}
+
+{{if .Result.Assertions}}
+<h2>SLO assertions</h2>
+<table>
+<tr><th>Assertion</th><th>Actual</th><th>Outcome</th></tr>
+{{range .Result.Assertions}}<tr><td>{{assertion .}}</td><td>{{float .Actual}}</td>
+{{if .Error}}<td class="error">error: {{.Error}}</td>{{else if .Passed}}<td class="pass">pass</td>{{else}}<td class="fail">fail</td>{{end}}</tr>
+{{end}}</table>
+{{end}}
+
+{{if .Charts}}
+<h2>Server metrics</h2>
+{{range .Charts}}
+<h3>{{.Query}}</h3>
+<p>Average: {{.Average}}, from {{.MinValue}} to {{.MaxValue}}, {{.Start}} - {{.End}}</p>
+<svg width="{{$.Width}}" height="{{$.Height}}" viewBox="0 0 {{$.Width}} {{$.Height}}" xmlns="http://www.w3.org/2000/svg">
+{{range .Lines}}<polyline points="{{.Points}}" fill="none" stroke="{{.Color}}" stroke-width="1.5"><title>{{.Labels}}</title></polyline>
+{{end}}</svg>
+<div class="legend">{{range .Lines}}<div><span style="color: {{.Color}}">&#9632;</span> {{.Labels}}</div>{{end}}</div>
+{{end}}
+{{end}}
+
+{{if or .KubernetesVersion .Nodes}}
+<h2>Kubernetes</h2>
+{{if .KubernetesVersion}}<p>Server version: {{.KubernetesVersion}}</p>{{end}}
+{{range .Nodes}}
+<table>
+<tr><th colspan="2">{{.HostName}}</th></tr>
+{{range nodeFields .}}<tr><td>{{.Name}}</td><td>{{.Value}}</td></tr>
+{{end}}</table>
+{{end}}
+{{end}}
+
+{{if .Meshes}}
+<h2>Detected service meshes</h2>
+<table>
+<tr><th>Mesh</th><th>Version</th></tr>
+{{range .Meshes}}<tr><td>{{.Name}}</td><td>{{.Value}}</td></tr>
+{{end}}</table>
+{{end}}
+</body>
+</html>
+`))
+
+// exportResultHTML writes the result as a standalone HTML report, the charts being embedded as SVG
+func exportResultHTML(w io.Writer, report *resultReport) error {
+	data := &htmlReport{
+		resultReport: report,
+		Width:        chartWidth,
+		Height:       chartHeight,
+	}
+
+	if n := len(report.Histogram); n > 0 {
+		var maxCount int64
+		for _, b := range report.Histogram {
+			if b.Count > maxCount {
+				maxCount = b.Count
+			}
+		}
+		barWidth := chartWidth / float64(n)
+		for i, b := range report.Histogram {
+			height := 0.0
+			if maxCount > 0 {
+				height = float64(b.Count) / float64(maxCount) * (chartHeight - 20)
+			}
+			data.Bars = append(data.Bars, &svgBar{
+				X:      float64(i) * barWidth,
+				Y:      chartHeight - 20 - height,
+				Width:  math.Max(barWidth-1, 1),
+				Height: height,
+				Title:  fmt.Sprintf("%s - %s ms: %d requests (%.2f%%)", formatFloat(b.Start), formatFloat(b.End), b.Count, b.Percent),
+			})
+		}
+		data.MinLatency = formatFloat(report.Histogram[0].Start)
+		data.MaxLatency = formatFloat(report.Histogram[n-1].End)
+	}
+
+	for _, m := range report.ServerMetrics {
+		if chart := newLineChart(m); chart != nil {
+			data.Charts = append(data.Charts, chart)
+		}
+	}
+	return resultHTMLTemplate.Execute(w, data)
+}
+
+// newLineChart plots all the series of the metric on the same scale
+func newLineChart(m *serverMetric) *lineChart {
+	var (
+		start, end         time.Time
+		minValue, maxValue = math.Inf(1), math.Inf(-1)
+	)
+	for _, s := range m.Series {
+		for _, p := range s.Points {
+			if start.IsZero() || p.Time.Before(start) {
+				start = p.Time
+			}
+			if p.Time.After(end) {
+				end = p.Time
+			}
+			minValue = math.Min(minValue, p.Value)
+			maxValue = math.Max(maxValue, p.Value)
+		}
+	}
+	if start.IsZero() {
+		return nil
+	}
+	timeRange := end.Sub(start).Seconds()
+	valueRange := maxValue - minValue
+
+	chart := &lineChart{
+		Query:    m.Query,
+		Average:  formatFloat(m.Average),
+		MinValue: formatFloat(minValue),
+		MaxValue: formatFloat(maxValue),
+		Start:    start.Format(time.RFC3339),
+		End:      end.Format(time.RFC3339),
+	}
+	for i, s := range m.Series {
+		points := make([]string, 0, len(s.Points))
+		for _, p := range s.Points {
+			x, y := 0.0, chartHeight/2
+			if timeRange > 0 {
+				x = p.Time.Sub(start).Seconds() / timeRange * chartWidth
+			}
+			if valueRange > 0 {
+				y = chartHeight - 5 - (p.Value-minValue)/valueRange*(chartHeight-10)
+			}
+			points = append(points, fmt.Sprintf("%.1f,%.1f", x, y))
+		}
+		chart.Lines = append(chart.Lines, &svgLine{
+			Points: strings.Join(points, " "),
+			Color:  chartColors[i%len(chartColors)],
+			Labels: s.Labels,
+		})
+	}
+	return chart
+}
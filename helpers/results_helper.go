@@ -0,0 +1,110 @@
+package helpers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/layer5io/meshery/models"
+)
+
+const defaultResultsPageSize = 10
+
+// paginateResults filters the results by search, sorts them by order and returns the requested page.
+//
+// order is of the form "<column> <asc|desc>", where column is one of the columns displayed in the UI:
+// name, mesh, test_start_time, qps, duration, threads or a percentile like p50, p99_9.
+// By default the latest results are returned first.
+func paginateResults(results []*models.MesheryResult, page, pageSize uint64, search, order string) *models.ResultsPage {
+	if pageSize == 0 {
+		pageSize = defaultResultsPageSize
+	}
+
+	search = strings.ToLower(strings.TrimSpace(search))
+	filtered := []*models.MesheryResult{}
+	for _, r := range results {
+		if search == "" || strings.Contains(strings.ToLower(r.Name), search) || strings.Contains(strings.ToLower(r.Mesh), search) {
+			filtered = append(filtered, r)
+		}
+	}
+
+	field, desc := "test_start_time", true
+	orderParts := strings.Fields(strings.ToLower(order))
+	if len(orderParts) > 0 {
+		field = orderParts[0]
+		desc = len(orderParts) > 1 && orderParts[1] == "desc"
+	}
+
+	less := func(i, j int) bool {
+		switch field {
+		case "name":
+			return filtered[i].Name < filtered[j].Name
+		case "mesh":
+			return filtered[i].Mesh < filtered[j].Mesh
+		default:
+			return resultSortValue(filtered[i], field) < resultSortValue(filtered[j], field)
+		}
+	}
+	sort.SliceStable(filtered, func(i, j int) bool {
+		if desc {
+			return less(j, i)
+		}
+		return less(i, j)
+	})
+
+	total := uint64(len(filtered))
+	start := page * pageSize
+	if start > total {
+		start = total
+	}
+	end := start + pageSize
+	if end > total {
+		end = total
+	}
+
+	return &models.ResultsPage{
+		Page:       page,
+		PageSize:   pageSize,
+		TotalCount: len(filtered),
+		Results:    filtered[start:end],
+	}
+}
+
+// resultSortValue returns a numeric value for the given field from the fortio style runner results
+func resultSortValue(r *models.MesheryResult, field string) float64 {
+	if r.Result == nil {
+		return 0
+	}
+	switch field {
+	case "test_start_time":
+		st, _ := r.Result["StartTime"].(string)
+		t, err := time.Parse(time.RFC3339Nano, st)
+		if err != nil {
+			return 0
+		}
+		return float64(t.UnixNano())
+	case "qps":
+		v, _ := r.Result["ActualQPS"].(float64)
+		return v
+	case "duration":
+		v, _ := r.Result["ActualDuration"].(float64)
+		return v
+	case "threads":
+		v, _ := r.Result["NumThreads"].(float64)
+		return v
+	}
+	if strings.HasPrefix(field, "p") {
+		hist, _ := r.Result["DurationHistogram"].(map[string]interface{})
+		percentiles, _ := hist["Percentiles"].([]interface{})
+		for _, pI := range percentiles {
+			p, _ := pI.(map[string]interface{})
+			perc, _ := p["Percentile"].(float64)
+			if ("p" + strings.Replace(fmt.Sprintf("%g", perc), ".", "_", 1)) == field {
+				v, _ := p["Value"].(float64)
+				return v
+			}
+		}
+	}
+	return 0
+}
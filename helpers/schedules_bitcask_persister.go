@@ -0,0 +1,204 @@
+package helpers
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"sort"
+
+	"github.com/gofrs/uuid"
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+	"github.com/prologic/bitcask"
+	"github.com/sirupsen/logrus"
+)
+
+// schedulesMaxValueSize leaves room for the load test payload and certificates of a schedule
+const schedulesMaxValueSize = 1 << 26 // 64MB
+
+// BitCaskSchedulesPersister assists with persisting load test schedules in a Bitcask store
+type BitCaskSchedulesPersister struct {
+	fileName string
+	db       *bitcask.Bitcask
+}
+
+// NewBitCaskSchedulesPersister creates a new BitCaskSchedulesPersister instance
+func NewBitCaskSchedulesPersister(folderName string) (*BitCaskSchedulesPersister, error) {
+	_, err := os.Stat(folderName)
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = os.MkdirAll(folderName, os.ModePerm)
+			if err != nil {
+				logrus.Errorf("Unable to create the directory '%s' due to error: %v.", folderName, err)
+				return nil, err
+			}
+		} else {
+			logrus.Errorf("Unable to find/stat the folder '%s': %v,", folderName, err)
+			return nil, err
+		}
+	}
+
+	fileName := path.Join(folderName, "schedules.db")
+	db, err := bitcask.Open(fileName,
+		bitcask.WithSync(true),
+		bitcask.WithMaxValueSize(schedulesMaxValueSize),
+		bitcask.WithMaxDatafileSize(schedulesMaxValueSize),
+	)
+	if err != nil {
+		logrus.Errorf("Unable to open database: %v.", err)
+		return nil, err
+	}
+	bd := &BitCaskSchedulesPersister{
+		fileName: fileName,
+		db:       db,
+	}
+	return bd, nil
+}
+
+// GetSchedules - gets the schedules of the user, oldest first
+func (s *BitCaskSchedulesPersister) GetSchedules(userID string) ([]*models.LoadTestSchedule, error) {
+	if s.db == nil {
+		return nil, errors.New("Connection to DB does not exist.")
+	}
+
+RETRY:
+	locked, err := s.db.TryRLock()
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to obtain read lock from bitcask store")
+		logrus.Error(err)
+	}
+	if !locked {
+		goto RETRY
+	}
+	defer func() {
+		_ = s.db.Unlock()
+	}()
+
+	schedules := []*models.LoadTestSchedule{}
+	for key := range s.db.Keys() {
+		dataB, err := s.db.Get(key)
+		if err != nil {
+			err = errors.Wrapf(err, "Unable to read data from bitcask store")
+			logrus.Error(err)
+			return nil, err
+		}
+		schedule := &models.LoadTestSchedule{}
+		if err := json.Unmarshal(dataB, schedule); err != nil {
+			err = errors.Wrapf(err, "Unable to unmarshal data.")
+			logrus.Error(err)
+			return nil, err
+		}
+		if userID == "" || schedule.UserID == userID {
+			schedules = append(schedules, schedule)
+		}
+	}
+	sort.Slice(schedules, func(i, j int) bool {
+		return schedules[i].CreatedAt.Before(schedules[j].CreatedAt)
+	})
+	return schedules, nil
+}
+
+// GetSchedule - gets a single schedule
+func (s *BitCaskSchedulesPersister) GetSchedule(key uuid.UUID) (*models.LoadTestSchedule, error) {
+	if s.db == nil {
+		return nil, errors.New("Connection to DB does not exist.")
+	}
+
+RETRY:
+	locked, err := s.db.TryRLock()
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to obtain read lock from bitcask store")
+		logrus.Error(err)
+	}
+	if !locked {
+		goto RETRY
+	}
+	defer func() {
+		_ = s.db.Unlock()
+	}()
+
+	dataB, err := s.db.Get(key.Bytes())
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to read data from bitcask store")
+		logrus.Error(err)
+		return nil, err
+	}
+	schedule := &models.LoadTestSchedule{}
+	if err := json.Unmarshal(dataB, schedule); err != nil {
+		err = errors.Wrapf(err, "Unable to unmarshal data.")
+		logrus.Error(err)
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// WriteSchedule persists the schedule
+func (s *BitCaskSchedulesPersister) WriteSchedule(key uuid.UUID, schedule *models.LoadTestSchedule) error {
+	if s.db == nil {
+		return errors.New("Connection to DB does not exist.")
+	}
+
+	if schedule == nil {
+		return errors.New("Given schedule data is nil.")
+	}
+
+RETRY:
+	locked, err := s.db.TryLock()
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to obtain write lock from bitcask store")
+		logrus.Error(err)
+	}
+	if !locked {
+		goto RETRY
+	}
+	defer func() {
+		_ = s.db.Unlock()
+	}()
+
+	dataB, err := json.Marshal(schedule)
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to marshal the schedule data.")
+		logrus.Error(err)
+		return err
+	}
+
+	if err := s.db.Put(key.Bytes(), dataB); err != nil {
+		err = errors.Wrapf(err, "Unable to persist schedule data.")
+		return err
+	}
+	return nil
+}
+
+// DeleteSchedule removes the schedule
+func (s *BitCaskSchedulesPersister) DeleteSchedule(key uuid.UUID) error {
+	if s.db == nil {
+		return errors.New("Connection to DB does not exist.")
+	}
+
+RETRY:
+	locked, err := s.db.TryLock()
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to obtain write lock from bitcask store")
+		logrus.Error(err)
+	}
+	if !locked {
+		goto RETRY
+	}
+	defer func() {
+		_ = s.db.Unlock()
+	}()
+
+	if err := s.db.Delete(key.Bytes()); err != nil {
+		err = errors.Wrapf(err, "Unable to delete schedule data for the id: %s.", key)
+		return err
+	}
+	return nil
+}
+
+// Close closes the bitcask store
+func (s *BitCaskSchedulesPersister) Close() {
+	if s.db == nil {
+		return
+	}
+	_ = s.db.Close()
+}
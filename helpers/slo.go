@@ -0,0 +1,166 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+	promModel "github.com/prometheus/common/model"
+)
+
+// PromQueryFunc runs an instant Prometheus query at the given time
+type PromQueryFunc func(ctx context.Context, query string, ts time.Time) (promModel.Value, error)
+
+// EvaluateSLOAssertions evaluates the assertions against the results of a load test and returns the verdict.
+// promQuery is used for the prometheus assertions, which can not be evaluated when it is nil.
+func EvaluateSLOAssertions(ctx context.Context, assertions []*models.SLOAssertion, resultsMap map[string]interface{}, promQuery PromQueryFunc) ([]*models.SLOAssertionResult, models.SLOVerdict) {
+	verdict := models.SLOPass
+	results := make([]*models.SLOAssertionResult, 0, len(assertions))
+	for _, a := range assertions {
+		res := &models.SLOAssertionResult{
+			Assertion: a,
+		}
+		actual, err := sloMetricValue(ctx, a, resultsMap, promQuery)
+		if err != nil {
+			res.Error = err.Error()
+			if verdict == models.SLOPass {
+				verdict = models.SLOError
+			}
+		} else {
+			res.Actual = actual
+			res.Passed = compareSLO(actual, a.Operator, a.Value)
+			if !res.Passed {
+				verdict = models.SLOFail
+			}
+		}
+		results = append(results, res)
+	}
+	return results, verdict
+}
+
+func compareSLO(actual float64, op string, value float64) bool {
+	switch op {
+	case "<":
+		return actual < value
+	case "<=":
+		return actual <= value
+	case ">":
+		return actual > value
+	case ">=":
+		return actual >= value
+	}
+	return false
+}
+
+// sloMetricValue returns the value of the metric of the assertion, latencies in milliseconds and rates in percent
+func sloMetricValue(ctx context.Context, a *models.SLOAssertion, resultsMap map[string]interface{}, promQuery PromQueryFunc) (float64, error) {
+	switch a.Metric {
+	case models.SLOAvgLatency, models.SLOMaxLatency:
+		hist := histogramDataFromMap(resultsMap["DurationHistogram"])
+		if hist == nil || hist.Count == 0 {
+			return 0, errors.New("no latency data in the results")
+		}
+		if a.Metric == models.SLOAvgLatency {
+			return hist.Avg * 1000, nil
+		}
+		return hist.Max * 1000, nil
+	case models.SLOErrorRate:
+		return errorRate(resultsMap)
+	case models.SLOQPS:
+		qps, _ := resultsMap["ActualQPS"].(float64)
+		return qps, nil
+	case models.SLOQPSRatio:
+		qps, _ := resultsMap["ActualQPS"].(float64)
+		requested, _ := resultsMap["RequestedQPS"].(string)
+		requestedQPS, err := strconv.ParseFloat(requested, 64)
+		if err != nil || requestedQPS <= 0 {
+			return 0, fmt.Errorf("the requested qps is not a fixed rate: %s", requested)
+		}
+		return qps / requestedQPS * 100, nil
+	case models.SLOPrometheus:
+		return promMetricValue(ctx, a.Query, resultsMap, promQuery)
+	}
+	if p, ok := a.Percentile(); ok {
+		hist := histogramDataFromMap(resultsMap["DurationHistogram"])
+		if hist == nil || hist.Count == 0 {
+			return 0, errors.New("no latency data in the results")
+		}
+		percentiles := HistogramPercentiles(hist, []float64{p})
+		if len(percentiles) == 0 {
+			return 0, errors.New("no latency data in the results")
+		}
+		return percentiles[0].Value * 1000, nil
+	}
+	return 0, fmt.Errorf("unknown metric '%s'", a.Metric)
+}
+
+// errorRate returns the percentage of requests which got neither a 2xx/3xx http status nor a SERVING gRPC status.
+// wrk2 does not report the status codes, the rate is then the one of the failed requests it reports.
+func errorRate(resultsMap map[string]interface{}) (float64, error) {
+	var total, failed float64
+	for code, count := range retCodesFromMap(resultsMap["RetCodes"]) {
+		total += count
+		if c, err := strconv.Atoi(code); err == nil {
+			if c < 200 || c >= 400 {
+				failed += count
+			}
+		} else if code != "SERVING" {
+			failed += count
+		}
+	}
+	if errs, ok := resultsMap["Errors"].(float64); ok && total == 0 {
+		if hist := histogramDataFromMap(resultsMap["DurationHistogram"]); hist != nil {
+			total = float64(hist.Count)
+		}
+		// the requests which timed out are counted as errors without being counted as requests
+		total = math.Max(total, errs)
+		failed = errs
+	}
+	if total == 0 {
+		return 0, errors.New("no requests in the results")
+	}
+	return failed / total * 100, nil
+}
+
+// promMetricValue runs the query at the end of the load test and returns its value, the highest one for vectors
+func promMetricValue(ctx context.Context, query string, resultsMap map[string]interface{}, promQuery PromQueryFunc) (float64, error) {
+	if promQuery == nil {
+		return 0, errors.New("prometheus is not configured")
+	}
+	st, _ := resultsMap["StartTime"].(string)
+	startTime, err := time.Parse(time.RFC3339Nano, st)
+	if err != nil {
+		return 0, errors.New("no start time in the results")
+	}
+	d, _ := resultsMap["ActualDuration"].(float64)
+	duration := time.Duration(d)
+	rangeSeconds := int(math.Ceil(duration.Seconds()))
+	if rangeSeconds < 1 {
+		rangeSeconds = 1
+	}
+	query = strings.Replace(query, "$__range", fmt.Sprintf("%ds", rangeSeconds), -1)
+
+	val, err := promQuery(ctx, query, startTime.Add(duration))
+	if err != nil {
+		return 0, err
+	}
+	switch v := val.(type) {
+	case *promModel.Scalar:
+		return float64(v.Value), nil
+	case promModel.Vector:
+		if len(v) == 0 {
+			return 0, fmt.Errorf("no data for the query: %s", query)
+		}
+		max := math.Inf(-1)
+		for _, sample := range v {
+			max = math.Max(max, float64(sample.Value))
+		}
+		return max, nil
+	}
+	return 0, fmt.Errorf("the query has to return a scalar or an instant vector: %s", query)
+}
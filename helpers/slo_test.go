@@ -0,0 +1,234 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"fortio.org/fortio/periodic"
+	"fortio.org/fortio/stats"
+	"github.com/layer5io/meshery/models"
+	promModel "github.com/prometheus/common/model"
+)
+
+// wrk2Results returns the results of a wrk2 load test, which reports its p99 along with cumulative buckets and the
+// number of failed requests rather than their status codes
+func wrk2Results() map[string]interface{} {
+	hist := wrk2Histogram(0.003, 100,
+		stats.Bucket{Interval: stats.Interval{Start: 0.001, End: 0.002}, Count: 50, Percent: 50},
+		stats.Bucket{Interval: stats.Interval{Start: 0.002, End: 0.004}, Count: 90, Percent: 90},
+		stats.Bucket{Interval: stats.Interval{Start: 0.004, End: 0.01}, Count: 100, Percent: 100},
+	)
+	hist.Percentiles = []stats.Percentile{{Percentile: 99, Value: 0.0097}}
+	return map[string]interface{}{
+		"DurationHistogram": hist,
+		"ActualQPS":         95.0,
+		"RequestedQPS":      "100",
+		"Errors":            2.0,
+		"StartTime":         "2020-01-01T00:00:00Z",
+		"ActualDuration":    float64(30 * time.Second),
+	}
+}
+
+func TestSLOMetricValue(t *testing.T) {
+	fortioResults := map[string]interface{}{
+		"DurationHistogram": fortioHistogram(0.001, 0.002, 0.002, 0.004),
+		"RetCodes":          map[string]interface{}{"SERVING": 4.0},
+	}
+	promQuery := func(ctx context.Context, query string, ts time.Time) (promModel.Value, error) {
+		if query != "sum(rate(errors[30s]))" {
+			return nil, errors.New("unexpected query " + query)
+		}
+		if want := time.Date(2020, 1, 1, 0, 0, 30, 0, time.UTC); !ts.Equal(want) {
+			return nil, errors.New("unexpected time " + ts.String())
+		}
+		return promModel.Vector{{Value: 1}, {Value: 3}}, nil
+	}
+	tests := []struct {
+		name    string
+		metric  string
+		query   string
+		results map[string]interface{}
+		want    float64
+		wantErr bool
+	}{
+		{name: "wrk2 reported percentile", metric: "p99", results: wrk2Results(), want: 9.7},
+		{name: "wrk2 percentile from the buckets", metric: "p75", results: wrk2Results(), want: 3.25},
+		{name: "fortio percentile", metric: "p50", results: fortioResults, want: 1.5},
+		{name: "average latency", metric: models.SLOAvgLatency, results: wrk2Results(), want: 3},
+		{name: "maximum latency", metric: models.SLOMaxLatency, results: wrk2Results(), want: 10},
+		{name: "error rate", metric: models.SLOErrorRate, results: wrk2Results(), want: 2},
+		{name: "no error over grpc", metric: models.SLOErrorRate, results: fortioResults, want: 0},
+		{
+			name:    "error rate of the aggregated status codes",
+			metric:  models.SLOErrorRate,
+			results: map[string]interface{}{"RetCodes": map[string]float64{"200": 95, "-1": 5}},
+			want:    5,
+		},
+		{
+			name:    "wrk2 timeouts not counted as requests",
+			metric:  models.SLOErrorRate,
+			results: map[string]interface{}{"Errors": 3.0},
+			want:    100,
+		},
+		{name: "no requests", metric: models.SLOErrorRate, results: map[string]interface{}{}, wantErr: true},
+		{name: "qps ratio", metric: models.SLOQPSRatio, results: wrk2Results(), want: 95},
+		{name: "prometheus highest value", metric: models.SLOPrometheus, query: "sum(rate(errors[$__range]))", results: wrk2Results(), want: 3},
+		{name: "no latency data", metric: "p99", results: map[string]interface{}{}, wantErr: true},
+		{name: "no requested rate", metric: models.SLOQPSRatio, results: map[string]interface{}{"RequestedQPS": "max"}, wantErr: true},
+		{name: "unknown metric", metric: "latency", results: wrk2Results(), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &models.SLOAssertion{Metric: tt.metric, Query: tt.query}
+			got, err := sloMetricValue(context.Background(), a, tt.results, promQuery)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("sloMetricValue() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("sloMetricValue() = %g, want %g", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrorRateOfMergedResults(t *testing.T) {
+	// stepResults returns the results of a run of 50 requests, 5 of them failing, for the load generator
+	stepResults := func(lg models.LoadGenerator) (map[string]interface{}, *periodic.RunnerResults) {
+		result := &periodic.RunnerResults{
+			StartTime:         time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+			ActualDuration:    10 * time.Second,
+			DurationHistogram: wrk2Histogram(0.002, 50, bucket(0.001, 0.002, 25), bucket(0.002, 0.004, 50)),
+		}
+		resultsMap, err := runnerResultsToMap(result)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lg == models.Wrk2LG {
+			resultsMap["Errors"] = 5.0
+		} else {
+			resultsMap["RetCodes"] = map[string]interface{}{"200": 45.0, "503": 5.0}
+		}
+		return resultsMap, result
+	}
+	staged := func(lg models.LoadGenerator) map[string]interface{} {
+		opts := &models.LoadTestOptions{
+			LoadGenerator: lg,
+			Stages: []*models.LoadTestStage{
+				{Duration: 10 * time.Second, QPS: 5},
+				{Duration: 10 * time.Second, QPS: 5},
+			},
+		}
+		loadTest := func(ctx context.Context, opts *models.LoadTestOptions) (map[string]interface{}, *periodic.RunnerResults, error) {
+			resultsMap, result := stepResults(opts.LoadGenerator)
+			return resultsMap, result, nil
+		}
+		resultsMap, _, err := StagedLoadTest(context.Background(), opts, loadTest, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resultsMap
+	}
+	distributed := func(resultsMaps ...map[string]interface{}) map[string]interface{} {
+		results := make([]*periodic.RunnerResults, 0, len(resultsMaps))
+		addresses := make([]string, 0, len(resultsMaps))
+		for i := range resultsMaps {
+			_, result := stepResults(models.FortioLG)
+			results = append(results, result)
+			addresses = append(addresses, fmt.Sprintf("worker-%d:9091", i))
+		}
+		resultsMap, _, err := MergeWorkerResults(&models.LoadTestOptions{HTTPQPS: 10}, addresses, resultsMaps, results)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resultsMap
+	}
+	workerResults := func(lg models.LoadGenerator) map[string]interface{} {
+		resultsMap, _ := stepResults(lg)
+		return resultsMap
+	}
+
+	tests := []struct {
+		name    string
+		results map[string]interface{}
+	}{
+		{name: "staged", results: staged(models.FortioLG)},
+		{name: "staged wrk2", results: staged(models.Wrk2LG)},
+		{name: "distributed", results: distributed(workerResults(models.FortioLG), workerResults(models.FortioLG))},
+		{name: "distributed wrk2", results: distributed(workerResults(models.Wrk2LG), workerResults(models.Wrk2LG))},
+		{name: "distributed staged", results: distributed(staged(models.FortioLG), workerResults(models.FortioLG))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := errorRate(tt.results)
+			if err != nil {
+				t.Fatalf("errorRate() error = %v", err)
+			}
+			if math.Abs(got-10) > 1e-9 {
+				t.Errorf("errorRate() = %g, want 10", got)
+			}
+		})
+	}
+}
+
+func TestEvaluateSLOAssertions(t *testing.T) {
+	tests := []struct {
+		name        string
+		assertions  []*models.SLOAssertion
+		wantVerdict models.SLOVerdict
+		wantPassed  []bool
+	}{
+		{
+			name: "all passed",
+			assertions: []*models.SLOAssertion{
+				{Metric: "p99", Operator: "<", Value: 10},
+				{Metric: models.SLOErrorRate, Operator: "<=", Value: 2},
+			},
+			wantVerdict: models.SLOPass,
+			wantPassed:  []bool{true, true},
+		},
+		{
+			name: "failed",
+			assertions: []*models.SLOAssertion{
+				{Metric: "p99", Operator: "<", Value: 9},
+				{Metric: models.SLOQPS, Operator: ">=", Value: 90},
+			},
+			wantVerdict: models.SLOFail,
+			wantPassed:  []bool{false, true},
+		},
+		{
+			name: "not evaluated",
+			assertions: []*models.SLOAssertion{
+				{Metric: models.SLOQPS, Operator: ">", Value: 90},
+				{Metric: models.SLOPrometheus, Operator: "<", Value: 1, Query: "up"},
+			},
+			wantVerdict: models.SLOError,
+			wantPassed:  []bool{true, false},
+		},
+		{
+			name: "failure wins over errors",
+			assertions: []*models.SLOAssertion{
+				{Metric: models.SLOPrometheus, Operator: "<", Value: 1, Query: "up"},
+				{Metric: models.SLOAvgLatency, Operator: "<", Value: 1},
+			},
+			wantVerdict: models.SLOFail,
+			wantPassed:  []bool{false, false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, verdict := EvaluateSLOAssertions(context.Background(), tt.assertions, wrk2Results(), nil)
+			if verdict != tt.wantVerdict {
+				t.Errorf("verdict = %s, want %s", verdict, tt.wantVerdict)
+			}
+			for i, res := range results {
+				if res.Passed != tt.wantPassed[i] {
+					t.Errorf("assertion %s passed = %v, want %v", res.Assertion, res.Passed, tt.wantPassed[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"fortio.org/fortio/periodic"
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultMaxProbes = 20
+	// defaultRelativePrecision is the width of the interval around the knee, relative to the highest passing rate,
+	// at which the search stops when no precision is given
+	defaultRelativePrecision = 0.05
+	// minProbeQPSRatio is the share of the requested rate a probe has to achieve to pass
+	minProbeQPSRatio = 0.95
+)
+
+// FiredAlertsFunc returns the prometheus alerts which fired between start and end
+type FiredAlertsFunc func(ctx context.Context, start, end time.Time) ([]*models.PrometheusAlert, error)
+
+// DiscoverThroughput searches for the highest rate the target sustains while meeting the SLO assertions of opts,
+// running a load test at a constant rate for each probe. A probe passes when it achieved at least 95% of its rate, all
+// the assertions pass, no prometheus alert fired and the load test was not aborted. promQuery is used for the
+// prometheus assertions and firedAlerts for the alerts, each of them being evaluated over the window of the probe.
+// The returned results are the ones of the knee, or of the first probe when none passed, and hold all the probes
+// under the "throughput_discovery" key.
+func DiscoverThroughput(ctx context.Context, opts *models.LoadTestOptions, loadTest LoadTestFunc, promQuery PromQueryFunc, firedAlerts FiredAlertsFunc, progress func(string)) (map[string]interface{}, *periodic.RunnerResults, error) {
+	d := opts.Discovery
+	if d == nil {
+		return loadTest(ctx, opts)
+	}
+	maxProbes := d.MaxProbes
+	if maxProbes <= 0 {
+		maxProbes = defaultMaxProbes
+	}
+
+	var (
+		// lo is the highest passing rate and hi the lowest failing one, 0 until a probe fails
+		lo, hi      float64
+		discovery   = &models.ThroughputDiscoveryResult{}
+		kneeMap     map[string]interface{}
+		kneeResult  *periodic.RunnerResults
+		firstMap    map[string]interface{}
+		firstResult *periodic.RunnerResults
+	)
+	qps := d.MinQPS
+	for i := 0; i < maxProbes; i++ {
+		if progress != nil {
+			progress(fmt.Sprintf("Probing %g qps, probe %d of at most %d", qps, i+1, maxProbes))
+		}
+		probeOpts := *opts
+		probeOpts.Discovery = nil
+		probeOpts.HTTPQPS = qps
+		resultsMap, result, err := loadTest(ctx, &probeOpts)
+		if err != nil {
+			err = errors.Wrapf(err, "error while probing %g qps", qps)
+			logrus.Error(err)
+			return nil, nil, err
+		}
+		probe := newThroughputProbe(ctx, qps, opts.Assertions, resultsMap, result, promQuery, firedAlerts)
+		discovery.Probes = append(discovery.Probes, probe)
+		if firstMap == nil {
+			firstMap, firstResult = resultsMap, result
+		}
+		if probe.Passed {
+			lo = qps
+			kneeMap, kneeResult = resultsMap, result
+		} else {
+			hi = qps
+		}
+		if progress != nil {
+			progress(fmt.Sprintf("%g qps: %s", qps, describeProbe(probe)))
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		next := 0.0
+		switch {
+		case lo == 0:
+			// even the minimum rate fails
+		case hi == 0:
+			if d.MaxQPS <= 0 || lo < d.MaxQPS {
+				next = lo * 2
+				if d.MaxQPS > 0 && next > d.MaxQPS {
+					next = d.MaxQPS
+				}
+			}
+		default:
+			precision := d.Precision
+			if precision <= 0 {
+				precision = lo * defaultRelativePrecision
+			}
+			if hi-lo > precision {
+				next = (lo + hi) / 2
+			}
+		}
+		if next == 0 {
+			break
+		}
+		qps = next
+	}
+	discovery.KneeQPS = lo
+
+	if kneeMap == nil {
+		kneeMap, kneeResult = firstMap, firstResult
+	}
+	kneeMap["throughput_discovery"] = discovery
+	return kneeMap, kneeResult, nil
+}
+
+func newThroughputProbe(ctx context.Context, qps float64, assertions []*models.SLOAssertion, resultsMap map[string]interface{}, result *periodic.RunnerResults, promQuery PromQueryFunc, firedAlerts FiredAlertsFunc) *models.ThroughputProbe {
+	summary := SummarizeResult(&models.MesheryResult{Result: resultsMap})
+	probe := &models.ThroughputProbe{
+		QPS:       qps,
+		ActualQPS: summary.QPS,
+		ErrorRate: summary.ErrorRate,
+		Latencies: summary.Latencies,
+	}
+	probe.AbortReason, _ = resultsMap["abort_reason"].(string)
+	probe.Assertions, probe.Verdict = EvaluateSLOAssertions(ctx, assertions, resultsMap, promQuery)
+	if firedAlerts != nil && result != nil {
+		alerts, err := firedAlerts(ctx, result.StartTime, result.StartTime.Add(result.ActualDuration))
+		if err != nil {
+			logrus.Warn(errors.Wrapf(err, "unable to get the prometheus alerts fired while probing %g qps", qps))
+		}
+		probe.Alerts = alerts
+	}
+	probe.Passed = probe.ActualQPS >= qps*minProbeQPSRatio && probe.Verdict == models.SLOPass &&
+		len(probe.Alerts) == 0 && probe.AbortReason == ""
+	return probe
+}
+
+func describeProbe(probe *models.ThroughputProbe) string {
+	outcome := "pass"
+	if !probe.Passed {
+		outcome = "fail"
+	}
+	errs := "unknown error rate"
+	if probe.ErrorRate != nil {
+		errs = fmt.Sprintf("%.2f%% errors", *probe.ErrorRate)
+	}
+	msg := fmt.Sprintf("%s, %.1f actual qps, %s", outcome, probe.ActualQPS, errs)
+	if len(probe.Alerts) > 0 {
+		msg += fmt.Sprintf(", %d alerts fired", len(probe.Alerts))
+	}
+	if probe.AbortReason != "" {
+		msg += ", aborted: " + probe.AbortReason
+	}
+	return msg
+}
@@ -0,0 +1,161 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"fortio.org/fortio/periodic"
+	"github.com/layer5io/meshery/models"
+)
+
+// fakeTarget simulates a target sustaining up to capacity qps, failing the requests above errorsAbove qps
+type fakeTarget struct {
+	capacity    float64
+	errorsAbove float64
+	start       time.Time
+	probes      []float64
+}
+
+func (f *fakeTarget) loadTest(ctx context.Context, opts *models.LoadTestOptions) (map[string]interface{}, *periodic.RunnerResults, error) {
+	f.probes = append(f.probes, opts.HTTPQPS)
+	actual := opts.HTTPQPS
+	if f.capacity > 0 && actual > f.capacity {
+		actual = f.capacity
+	}
+	failed := 0.0
+	if f.errorsAbove > 0 && opts.HTTPQPS > f.errorsAbove {
+		failed = 10
+	}
+	// each probe lasts a minute, one after the other
+	start := f.start.Add(time.Duration(len(f.probes)-1) * time.Minute)
+	result := &periodic.RunnerResults{
+		StartTime:         start,
+		ActualDuration:    time.Minute,
+		ActualQPS:         actual,
+		DurationHistogram: fortioHistogram(0.001, 0.002),
+	}
+	resultsMap := map[string]interface{}{
+		"ActualQPS":      actual,
+		"StartTime":      start.Format(time.RFC3339Nano),
+		"ActualDuration": float64(time.Minute),
+		"RetCodes":       map[string]interface{}{"200": 100 - failed, "503": failed},
+	}
+	return resultsMap, result, nil
+}
+
+func TestDiscoverThroughput(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	errorRateSLO := []*models.SLOAssertion{{Metric: models.SLOErrorRate, Operator: "<", Value: 1}}
+	tests := []struct {
+		name        string
+		target      *fakeTarget
+		discovery   *models.ThroughputDiscovery
+		firedAlerts FiredAlertsFunc
+		wantKnee    float64
+		wantProbes  []float64
+	}{
+		{
+			name:       "knee found by the assertions",
+			target:     &fakeTarget{errorsAbove: 250},
+			discovery:  &models.ThroughputDiscovery{MinQPS: 100, Precision: 20},
+			wantKnee:   250,
+			wantProbes: []float64{100, 200, 400, 300, 250, 275, 262.5},
+		},
+		{
+			name:       "knee found by the achieved rate",
+			target:     &fakeTarget{capacity: 120},
+			discovery:  &models.ThroughputDiscovery{MinQPS: 100, Precision: 10},
+			wantKnee:   125, // 120 qps out of 125 is within the tolerance
+			wantProbes: []float64{100, 200, 150, 125, 137.5, 131.25},
+		},
+		{
+			name:   "knee found by the alerts",
+			target: &fakeTarget{},
+			firedAlerts: func(ctx context.Context, from, to time.Time) ([]*models.PrometheusAlert, error) {
+				// the alert fires during the third probe only
+				if from.Equal(start.Add(2*time.Minute)) && to.Equal(start.Add(3*time.Minute)) {
+					return []*models.PrometheusAlert{{Name: "HighLatency"}}, nil
+				}
+				return nil, nil
+			},
+			discovery:  &models.ThroughputDiscovery{MinQPS: 100, MaxProbes: 3},
+			wantKnee:   200,
+			wantProbes: []float64{100, 200, 400},
+		},
+		{
+			name:   "alerts not available",
+			target: &fakeTarget{},
+			firedAlerts: func(ctx context.Context, from, to time.Time) ([]*models.PrometheusAlert, error) {
+				return nil, errors.New("prometheus is down")
+			},
+			discovery:  &models.ThroughputDiscovery{MinQPS: 100, MaxQPS: 200},
+			wantKnee:   200,
+			wantProbes: []float64{100, 200},
+		},
+		{
+			name:       "capped by the max rate",
+			target:     &fakeTarget{},
+			discovery:  &models.ThroughputDiscovery{MinQPS: 100, MaxQPS: 300},
+			wantKnee:   300,
+			wantProbes: []float64{100, 200, 300},
+		},
+		{
+			name:       "minimum rate failing",
+			target:     &fakeTarget{errorsAbove: 50},
+			discovery:  &models.ThroughputDiscovery{MinQPS: 100},
+			wantKnee:   0,
+			wantProbes: []float64{100},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.target.start = start
+			opts := &models.LoadTestOptions{Discovery: tt.discovery, Assertions: errorRateSLO}
+			resultsMap, result, err := DiscoverThroughput(context.Background(), opts, tt.target.loadTest, nil, tt.firedAlerts, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			discovery, ok := resultsMap["throughput_discovery"].(*models.ThroughputDiscoveryResult)
+			if !ok {
+				t.Fatal("no throughput discovery in the results")
+			}
+			if discovery.KneeQPS != tt.wantKnee {
+				t.Errorf("knee = %g, want %g", discovery.KneeQPS, tt.wantKnee)
+			}
+			if len(tt.target.probes) != len(tt.wantProbes) {
+				t.Fatalf("probes = %v, want %v", tt.target.probes, tt.wantProbes)
+			}
+			for i := range tt.wantProbes {
+				if tt.target.probes[i] != tt.wantProbes[i] {
+					t.Fatalf("probes = %v, want %v", tt.target.probes, tt.wantProbes)
+				}
+			}
+			if len(discovery.Probes) != len(tt.wantProbes) {
+				t.Errorf("%d probes recorded, want %d", len(discovery.Probes), len(tt.wantProbes))
+			}
+			// the results are the ones of the knee, or of the first probe when none passed
+			wantQPS := tt.wantKnee
+			if wantQPS == 0 {
+				wantQPS = tt.wantProbes[0]
+			}
+			if tt.target.capacity > 0 && wantQPS > tt.target.capacity {
+				wantQPS = tt.target.capacity
+			}
+			if result.ActualQPS != wantQPS {
+				t.Errorf("results of the %g qps probe, want the ones of the %g qps probe", result.ActualQPS, wantQPS)
+			}
+		})
+	}
+}
+
+func TestDiscoverThroughputError(t *testing.T) {
+	opts := &models.LoadTestOptions{Discovery: &models.ThroughputDiscovery{MinQPS: 10}}
+	loadTest := func(ctx context.Context, opts *models.LoadTestOptions) (map[string]interface{}, *periodic.RunnerResults, error) {
+		return nil, nil, errors.New("connection refused")
+	}
+	if _, _, err := DiscoverThroughput(context.Background(), opts, loadTest, nil, nil, nil); err == nil {
+		t.Error("the error of a probe should stop the discovery")
+	}
+}
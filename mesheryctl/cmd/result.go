@@ -0,0 +1,124 @@
+// Copyright 2019 The Meshery Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// sessionCookie is the name of the cookie holding the Meshery session
+const sessionCookie = "meshery"
+
+var (
+	exportFormat string
+	exportOutput string
+	session      string
+)
+
+// resultCmd represents the result command
+var resultCmd = &cobra.Command{
+	Use:   "result",
+	Short: "Manage load test results",
+	Long:  `Manage the load test results stored by Meshery.`,
+}
+
+// resultExportCmd represents the result export command
+var resultExportCmd = &cobra.Command{
+	Use:   "export [result id]",
+	Short: "Export a load test result",
+	Long: `Export a load test result as csv, as JUnit XML for CI systems or as a standalone HTML report.
+The session is the value of the "meshery" cookie of a logged in browser, it can also be set as session in the config file.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if session == "" {
+			session = viper.GetString("session")
+		}
+		if session == "" {
+			log.Fatal("provide the Meshery session with --session")
+		}
+
+		req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/api/results/export?id=%s&format=%s", url, args[0], exportFormat), nil)
+		if err != nil {
+			log.Fatal(err)
+		}
+		req.AddCookie(&http.Cookie{
+			Name:  sessionCookie,
+			Value: session,
+		})
+		client := &http.Client{
+			// Meshery redirects to the login page when the session is invalid
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			log.Fatal("unable to reach Meshery: ", err)
+		}
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+		if resp.StatusCode == http.StatusFound {
+			log.Fatal("the Meshery session is invalid, please log in again")
+		}
+		if resp.StatusCode != http.StatusOK {
+			body, _ := ioutil.ReadAll(resp.Body)
+			log.Fatalf("unable to export the result: %s", strings.TrimSpace(string(body)))
+		}
+
+		if exportOutput == "" {
+			exportOutput = "result." + exportFormat
+			if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil && params["filename"] != "" {
+				exportOutput = filepath.Base(params["filename"])
+			}
+		}
+		out := io.Writer(os.Stdout)
+		if exportOutput != "-" {
+			file, err := os.Create(exportOutput)
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer func() {
+				_ = file.Close()
+			}()
+			out = file
+		}
+		if _, err = io.Copy(out, resp.Body); err != nil {
+			log.Fatal(err)
+		}
+		if exportOutput != "-" {
+			log.Info("Result exported to ", exportOutput)
+		}
+	},
+}
+
+func init() {
+	resultExportCmd.Flags().StringVarP(&exportFormat, "format", "f", "html", "format of the export: csv, junit or html")
+	resultExportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "file to write the export to, - for stdout (default is the name given by Meshery)")
+	resultExportCmd.Flags().StringVar(&session, "session", "", "Meshery session")
+	resultCmd.AddCommand(resultExportCmd)
+	rootCmd.AddCommand(resultCmd)
+}
@@ -146,24 +146,37 @@ func (g *GrafanaClient) ProcessBoard(c *sdk.Client, board *sdk.Board, link *sdk.
 	}
 	var err error
 	tmpDsName := map[string]string{}
+	tmpVarValues := map[string]string{}
+	// the references to the variables picked in the UI are left to it, the ones to the others are substituted
+	exprVarValues := map[string]string{}
 	if len(board.Templating.List) > 0 {
 		for _, tmpVar := range board.Templating.List {
 			// logrus.Debugf("tmpvar: %+#v", tmpVar)
 			var ds sdk.Datasource
 			var dsName string
-			if tmpVar.Type == "datasource" {
+			switch tmpVar.Type {
+			case "datasource":
 				dsName = tmpVar.Query // datasource name can be found in the query field
 				tmpDsName[tmpVar.Name] = dsName
-			} else if tmpVar.Type == "query" && tmpVar.Datasource != nil {
+			case "query":
+				if tmpVar.Datasource == nil {
+					logrus.Debugf("skipping template variable %s without datasource, its references are left unresolved", tmpVar.Name)
+					continue
+				}
 				if !strings.HasPrefix(*tmpVar.Datasource, "$") {
 					dsName = *tmpVar.Datasource
 				} else {
 					dsName = tmpDsName[strings.Replace(*tmpVar.Datasource, "$", "", 1)]
 				}
-			} else {
-				err := fmt.Errorf("unable to get datasource name for tmpvar: %+#v", tmpVar)
-				logrus.Error(err)
-				return nil, err
+			case "custom", "interval", "constant", "textbox":
+				if value, ok := fixedTemplateVarValue(tmpVar); ok {
+					tmpVarValues[tmpVar.Name] = value
+					exprVarValues[tmpVar.Name] = value
+				}
+				continue
+			default:
+				logrus.Debugf("skipping template variable %s of type %s, its references are left unresolved", tmpVar.Name, tmpVar.Type)
+				continue
 			}
 			if c != nil {
 				ds, err = c.GetDatasourceByName(dsName)
@@ -177,6 +190,9 @@ func (g *GrafanaClient) ProcessBoard(c *sdk.Client, board *sdk.Board, link *sdk.
 			}
 
 			tvVal := tmpVar.Current.Text
+			tmpVarValues[tmpVar.Name] = tvVal
+			// the UI only substitutes the $var references
+			exprVarValues[tmpVar.Name] = "$" + tmpVar.Name
 			// if tmpVar.Current. {
 			// 	tvVal = tmpVar.Current.Text
 			// }
@@ -193,39 +209,20 @@ func (g *GrafanaClient) ProcessBoard(c *sdk.Client, board *sdk.Board, link *sdk.
 		}
 	}
 	if len(board.Panels) > 0 {
-		for _, p1 := range board.Panels {
-			if p1.OfType != sdk.TextType && p1.OfType != sdk.TableType && p1.Type != "row" { // turning off text and table panels for now
-				if p1.Datasource != nil {
-					if strings.HasPrefix(*p1.Datasource, "$") {
-						*p1.Datasource = tmpDsName[strings.Replace(*p1.Datasource, "$", "", 1)]
-						// logrus.Debugf("updated panel datasource: %s", *panel.Datasource)
-					}
-				}
-				// grafPanel := &GrafanaPanel{
-				// 	ID:    panel.ID,
-				// 	PType: panel.Type,
-				// 	Title: panel.Title,
-				// }
-				// grafBoard.Panels = append(grafBoard.Panels, grafPanel)
-				// logrus.Debugf("board: %d, panel id: %d", board.ID, p1.ID)
-				grafBoard.Panels = append(grafBoard.Panels, p1)
-			}
-		}
+		grafBoard.Panels = processPanels(board.Panels, tmpDsName, tmpVarValues, exprVarValues)
 	} else if len(board.Rows) > 0 {
 		for _, r1 := range board.Rows {
-			for _, p2 := range r1.Panels {
-				if p2.OfType != sdk.TextType && p2.OfType != sdk.TableType && p2.Type != "row" { // turning off text and table panels for now
-					if strings.HasPrefix(*p2.Datasource, "$") {
-						*p2.Datasource = tmpDsName[strings.Replace(*p2.Datasource, "$", "", 1)]
-						// logrus.Debugf("updated panel datasource: %s", *panel.Datasource)
-					}
-					p3, _ := p2.MarshalJSON()
-					p4 := &sdk.Panel{}
-					_ = p4.UnmarshalJSON(p3)
-					logrus.Debugf("board: %d, Row panel id: %d", board.ID, p4.ID)
-					grafBoard.Panels = append(grafBoard.Panels, p4)
+			rowPanels := []*sdk.Panel{}
+			for i := range r1.Panels {
+				p2, err := copyPanel(&r1.Panels[i])
+				if err != nil {
+					logrus.Error(errors.Wrapf(err, "unable to copy panel %d of board %d", r1.Panels[i].ID, board.ID))
+					continue
 				}
+				logrus.Debugf("board: %d, Row panel id: %d", board.ID, p2.ID)
+				rowPanels = append(rowPanels, p2)
 			}
+			grafBoard.Panels = append(grafBoard.Panels, processPanels(rowPanels, tmpDsName, tmpVarValues, exprVarValues)...)
 		}
 	}
 	return grafBoard, nil
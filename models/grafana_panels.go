@@ -0,0 +1,241 @@
+package models
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/grafana-tools/sdk"
+	"github.com/sirupsen/logrus"
+)
+
+// grafanaRowType is the type of the row panels grouping the panels of a board
+const grafanaRowType = "row"
+
+// grafanaVarPattern matches the references to template variables: $var, ${var}, ${var:format} and [[var]]
+var grafanaVarPattern = regexp.MustCompile(`\$(\w+)|\$\{(\w+)(?::[^}]*)?\}|\[\[(\w+)(?::[^\]]*)?\]\]`)
+
+// substituteTemplateVars replaces the references to the template variables in s by their value, the references to
+// unknown variables being kept
+func substituteTemplateVars(s string, vars map[string]string) string {
+	if len(vars) == 0 || !strings.ContainsAny(s, "$[") {
+		return s
+	}
+	return grafanaVarPattern.ReplaceAllStringFunc(s, func(ref string) string {
+		m := grafanaVarPattern.FindStringSubmatch(ref)
+		name := m[1] + m[2] + m[3]
+		if val, ok := vars[name]; ok {
+			return val
+		}
+		return ref
+	})
+}
+
+// fixedTemplateVarValue returns the value of a template variable which is not picked in the UI, like the custom,
+// interval and constant ones, the values of a multi-value variable being formatted as a regex like Grafana does for
+// Prometheus. The automatic intervals, computed by Grafana, have no value.
+func fixedTemplateVarValue(v sdk.TemplateVar) (string, bool) {
+	var values []string
+	switch val := v.Current.Value.(type) {
+	case string:
+		values = append(values, val)
+	case []interface{}:
+		for _, vv := range val {
+			if s, ok := vv.(string); ok {
+				values = append(values, s)
+			}
+		}
+	}
+	if len(values) == 0 && v.Current.Text != "" {
+		values = append(values, v.Current.Text)
+	}
+	if len(values) == 0 && v.Query != "" && (v.Type == "constant" || v.Type == "textbox") {
+		values = append(values, v.Query)
+	}
+	if len(values) == 1 && values[0] == "$__all" {
+		if v.AllValue != "" {
+			return v.AllValue, true
+		}
+		values = nil
+		for _, option := range v.Options {
+			if option.Value != "$__all" {
+				values = append(values, option.Value)
+			}
+		}
+	}
+	if len(values) == 0 {
+		return "", false
+	}
+	for _, val := range values {
+		if strings.HasPrefix(val, "$__auto") || (v.Type == "interval" && val == "auto") {
+			return "", false
+		}
+	}
+	if len(values) == 1 {
+		return values[0], true
+	}
+	return "(" + strings.Join(values, "|") + ")", true
+}
+
+// resolveDatasource returns the name of the datasource ds refers to when it is a datasource template variable
+func resolveDatasource(ds string, dsNames map[string]string) string {
+	if !strings.HasPrefix(ds, "$") {
+		return ds
+	}
+	name := strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(ds, "$"), "{"), "}")
+	if dsName, ok := dsNames[name]; ok {
+		return dsName
+	}
+	return ds
+}
+
+// PanelTargets returns the targets of the panel, including the ones of the panels the sdk does not know, like stat,
+// gauge and heatmap panels
+func PanelTargets(panel *sdk.Panel) []sdk.Target {
+	if panel.OfType != sdk.CustomType {
+		if targets := panel.GetTargets(); targets != nil {
+			return *targets
+		}
+		return nil
+	}
+	if panel.CustomPanel == nil {
+		return nil
+	}
+	raw, ok := (*panel.CustomPanel)["targets"]
+	if !ok {
+		return nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil
+	}
+	targets := []sdk.Target{}
+	if err = json.Unmarshal(data, &targets); err != nil {
+		logrus.Debugf("unable to parse the targets of panel %d: %v", panel.ID, err)
+		return nil
+	}
+	return targets
+}
+
+// processPanels returns the panels with their datasources resolved and the template variables of their titles and
+// contents substituted with vars, the ones of the expressions of their targets with exprVars. The rows are left out,
+// their collapsed panels being added in their place.
+func processPanels(panels []*sdk.Panel, dsNames, vars, exprVars map[string]string) []*sdk.Panel {
+	processed := []*sdk.Panel{}
+	for _, panel := range panels {
+		if panel.Type == grafanaRowType {
+			processed = append(processed, processPanels(collapsedPanels(panel), dsNames, vars, exprVars)...)
+			continue
+		}
+		if panel.Datasource != nil {
+			*panel.Datasource = resolveDatasource(*panel.Datasource, dsNames)
+		}
+		panel.Title = substituteTemplateVars(panel.Title, vars)
+		switch {
+		case panel.TextPanel != nil:
+			panel.TextPanel.Content = substituteTemplateVars(panel.TextPanel.Content, vars)
+		case panel.CustomPanel != nil:
+			resolveCustomTargets(panel.CustomPanel, dsNames, exprVars)
+		default:
+			if targets := panel.GetTargets(); targets != nil {
+				for i := range *targets {
+					(*targets)[i].Datasource = resolveDatasource((*targets)[i].Datasource, dsNames)
+					(*targets)[i].Expr = substituteTemplateVars((*targets)[i].Expr, exprVars)
+				}
+			}
+		}
+		processed = append(processed, panel)
+	}
+	return processed
+}
+
+// collapsedPanels returns the panels of a collapsed row, the ones of an expanded row following it on the board
+func collapsedPanels(row *sdk.Panel) []*sdk.Panel {
+	if row.CustomPanel == nil {
+		return nil
+	}
+	raw, ok := (*row.CustomPanel)["panels"]
+	if !ok {
+		return nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil
+	}
+	panels := []*sdk.Panel{}
+	if err = json.Unmarshal(data, &panels); err != nil {
+		logrus.Errorf("unable to parse the panels of row %s: %v", row.Title, err)
+		return nil
+	}
+	return panels
+}
+
+// resolveCustomTargets resolves the datasources of the targets of a panel the sdk does not know and substitutes the
+// template variables of their expressions
+func resolveCustomTargets(panel *sdk.CustomPanel, dsNames, exprVars map[string]string) {
+	targets, _ := (*panel)["targets"].([]interface{})
+	for _, t := range targets {
+		target, ok := t.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if ds, ok := target["datasource"].(string); ok {
+			target["datasource"] = resolveDatasource(ds, dsNames)
+		}
+		if expr, ok := target["expr"].(string); ok {
+			target["expr"] = substituteTemplateVars(expr, exprVars)
+		}
+	}
+}
+
+// MarshalJSON marshals the board, keeping the fields of the panels the sdk does not know at the top level of the
+// panels like Grafana does
+func (b GrafanaBoard) MarshalJSON() ([]byte, error) {
+	type board GrafanaBoard
+	var panels []json.RawMessage
+	for _, panel := range b.Panels {
+		data, err := marshalPanel(panel)
+		if err != nil {
+			return nil, err
+		}
+		panels = append(panels, data)
+	}
+	return json.Marshal(struct {
+		board
+		Panels []json.RawMessage `json:"panels,omitempty"`
+	}{board(b), panels})
+}
+
+// marshalPanel marshals the panel, the sdk nesting the fields of the panels it does not know in a CustomPanel field
+func marshalPanel(panel *sdk.Panel) ([]byte, error) {
+	data, err := panel.MarshalJSON()
+	if err != nil || panel.OfType != sdk.CustomType || panel.CustomPanel == nil {
+		return data, err
+	}
+	common := map[string]interface{}{}
+	if err = json.Unmarshal(data, &common); err != nil {
+		return nil, err
+	}
+	delete(common, "CustomPanel")
+	fields := map[string]interface{}{}
+	for k, v := range *panel.CustomPanel {
+		fields[k] = v
+	}
+	for k, v := range common {
+		fields[k] = v
+	}
+	return json.Marshal(fields)
+}
+
+// copyPanel returns a deep copy of the panel
+func copyPanel(panel *sdk.Panel) (*sdk.Panel, error) {
+	data, err := marshalPanel(panel)
+	if err != nil {
+		return nil, err
+	}
+	p := &sdk.Panel{}
+	if err = p.UnmarshalJSON(data); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
@@ -24,8 +24,17 @@ type HandlerInterface interface {
 	InstalledMeshesHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, user *User)
 
 	LoadTestHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, user *User)
+	CancelLoadTestHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, user *User)
+	MeshOverheadHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, user *User)
+	LoadTestJobsHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, user *User)
+	LoadGeneratorsHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, user *User)
 	CollectStaticMetrics(config *SubmitMetricsConfig) error
+	MetricsDeadLettersHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, user *User)
 	FetchResultsHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, user *User)
+	CompareResultsHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, user *User)
+	ExportResultHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, user *User)
+	LoadTestSchedulesHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, user *User)
+	RunScheduledLoadTest(scheduleID, token string) error
 
 	MeshAdapterConfigHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, user *User)
 	MeshOpsHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, user *User)
@@ -45,6 +54,12 @@ type HandlerInterface interface {
 	PrometheusQueryRangeHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, user *User)
 	PrometheusStaticBoardHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, user *User)
 	SaveSelectedPrometheusBoardsHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, user *User)
+	PrometheusRulesHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, user *User)
+	PrometheusAlertsHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, user *User)
+	QueryTrackerStatsHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, user *User)
+
+	TelemetryDiscoveryHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, user *User)
+	QueryCacheStatsHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, user *User)
 
 	SessionSyncHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, user *User)
 }
@@ -59,13 +74,33 @@ type HandlerConfig struct {
 	SaaSTokenName string
 	SaaSBaseURL   string
 
-	AdapterTracker AdaptersTrackerInterface
-	QueryTracker   QueryTrackerInterface
+	AdapterTracker  AdaptersTrackerInterface
+	QueryTracker    QueryTrackerInterface
+	LoadTestTracker LoadTestTrackerInterface
+	// LoadTestQueue, when set, limits the load tests running concurrently
+	LoadTestQueue LoadTestQueueInterface
+	// QueryCache, when set, caches the results of the range queries of the prometheus and grafana proxy endpoints
+	QueryCache QueryCacheInterface
 
 	Queue taskq.Queue
+	// MetricsQueue runs the collections of the server metrics, which are retried until they succeed
+	MetricsQueue taskq.Queue
 
 	SessionPersister SessionPersister
 
+	ResultPersister     ResultsPersister
+	SyncResultsWithSaaS bool
+
+	SchedulePersister LoadTestSchedulePersister
+
+	// MetricsTaskPersister keeps the pending server metrics collections and the ones given up on
+	MetricsTaskPersister MetricsTaskPersister
+
+	// LoadTestWorkers are the addresses of the load generator workers load tests can be shared among
+	LoadTestWorkers []string
+	// LoadTestWorkerSecurity is how Meshery authenticates to the load generator workers
+	LoadTestWorkerSecurity *LoadTestWorkerSecurity
+
 	KubeConfigFolder string
 
 	GrafanaClient         *GrafanaClient
@@ -78,6 +113,28 @@ type HandlerConfig struct {
 // SubmitMetricsConfig is used to store config used for submitting metrics
 type SubmitMetricsConfig struct {
 	TestUUID, ResultID, PromURL string
+	SaaSResultID                string
 	StartTime, EndTime          time.Time
-	TokenKey, TokenVal          string
+	// UserID is the owner of the load test, whose session holds the credentials of prometheus
+	UserID             string
+	TokenKey, TokenVal string
+	// PromAuth holds the credentials of prometheus.
+	// Neither them nor the SaaS token are persisted with the task, see Redacted.
+	PromAuth *PrometheusAuth
+
+	// TaskID identifies the persisted metrics task
+	TaskID string
+	// Queries are the queries tracked for the load test, saved along with the task so they outlive a restart
+	Queries []string
+	// Meshes are the service meshes detected in the cluster, whose static boards are collected as well
+	Meshes []string
+}
+
+// Redacted returns a copy of the config without the SaaS token and the prometheus credentials, for the config to be
+// persisted or shown
+func (c *SubmitMetricsConfig) Redacted() *SubmitMetricsConfig {
+	r := *c
+	r.TokenVal = ""
+	r.PromAuth = nil
+	return &r
 }
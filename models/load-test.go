@@ -1,6 +1,9 @@
 package models
 
 import (
+	"encoding/json"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/gofrs/uuid"
@@ -15,6 +18,9 @@ const (
 
 	// Wrk2LG - represents the wrk2 load generator
 	Wrk2LG LoadGenerator = "wrk2"
+
+	// NativeLG - represents the load generator built into Meshery
+	NativeLG LoadGenerator = "native"
 )
 
 // LoadTestOptions represents the load test options
@@ -26,21 +32,134 @@ type LoadTestOptions struct {
 
 	HTTPNumThreads int
 
+	// HTTPMethod defaults to GET, or POST when a body is given
+	HTTPMethod string
+	// HTTPHeaders are extra request headers, each of the form "Key: Value"
+	HTTPHeaders     []string
+	HTTPBody        []byte
+	HTTPContentType string
+	// HTTPUserCredentials are used for basic auth and are of the form "user:password"
+	HTTPUserCredentials string
+	HTTPReqTimeout      time.Duration
+
 	IsInsecure bool
 	Duration   time.Duration
 
+	// HTTP2 sends the requests over HTTP/2, with prior knowledge for plain http URLs
+	HTTP2 bool
+	// OpenModel sends the requests at a constant arrival rate of HTTPQPS, regardless of how long the responses take.
+	// HTTPNumThreads is then the maximum number of requests in flight, the requests which would go over it are dropped.
+	OpenModel bool
+
 	LoadGenerator LoadGenerator
 
 	Cert, Key, CACert string
 
 	AllowInitialErrors bool
 
+	// AbortOn, when not 0, is the http status code which aborts the run, -1 being socket errors
+	AbortOn int
+	// MaxErrorRate, when greater than 0, is the ratio of failed requests, like 0.05 for 5%,
+	// above which the run is aborted
+	MaxErrorRate float64
+
 	IsGRPC           bool
 	GRPCStreamsCount int
 	GRPCDoHealth     bool
 	GRPCHealthSvc    string
 	GRPCDoPing       bool
 	GRPCPingDelay    time.Duration
+
+	// Assertions are the SLOs evaluated on the results of the load test
+	Assertions []*SLOAssertion
+
+	// SnapshotInterval, when greater than 0, is how often a snapshot of the metrics is taken while the load test runs
+	SnapshotInterval time.Duration
+	// OnSnapshot, when set, is called with each snapshot as soon as it is taken
+	OnSnapshot func(*LoadTestSnapshot) `json:"-"`
+
+	// Stages, when present, describe a multi-step load profile which is run instead of the
+	// single constant phase described by HTTPQPS, HTTPNumThreads and Duration
+	Stages []*LoadTestStage
+
+	// Discovery, when set, searches for the highest rate meeting the SLO assertions instead of running at HTTPQPS
+	Discovery *ThroughputDiscovery
+}
+
+// Redacted returns a copy of the options with the credentials, the header values and the client key masked, for
+// them to be shown
+func (o *LoadTestOptions) Redacted() *LoadTestOptions {
+	if o == nil {
+		return nil
+	}
+	r := *o
+	for _, s := range []*string{&r.HTTPUserCredentials, &r.Key} {
+		if *s != "" {
+			*s = redactedValue
+		}
+	}
+	if len(o.HTTPHeaders) > 0 {
+		r.HTTPHeaders = make([]string, 0, len(o.HTTPHeaders))
+		for _, hdr := range o.HTTPHeaders {
+			key := strings.SplitN(hdr, ":", 2)[0]
+			r.HTTPHeaders = append(r.HTTPHeaders, key+": "+redactedValue)
+		}
+	}
+	return &r
+}
+
+// LoadTestStage - represents a single stage of a multi-step load profile.
+// A ramp stage changes the rate linearly from StartQPS to QPS over the duration of the stage,
+// any other stage (hold, step, spike, soak) runs at a constant rate of QPS.
+// The rates have to be positive, only the start or the end of a ramp can be 0.
+type LoadTestStage struct {
+	Name       string        `json:"name,omitempty"`
+	Duration   time.Duration `json:"duration,omitempty"`
+	Ramp       bool          `json:"ramp,omitempty"`
+	StartQPS   float64       `json:"start_qps,omitempty"`
+	QPS        float64       `json:"qps,omitempty"`
+	NumThreads int           `json:"threads,omitempty"`
+}
+
+type loadTestStageAlias LoadTestStage
+
+// MarshalJSON - marshals the stage with a human readable duration
+func (s *LoadTestStage) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		Duration string `json:"duration,omitempty"`
+		*loadTestStageAlias
+	}{
+		Duration:           s.Duration.String(),
+		loadTestStageAlias: (*loadTestStageAlias)(s),
+	})
+}
+
+// UnmarshalJSON - accepts the duration either as a duration string like "2m" or in nanoseconds
+func (s *LoadTestStage) UnmarshalJSON(data []byte) error {
+	aux := &struct {
+		Duration interface{} `json:"duration,omitempty"`
+		*loadTestStageAlias
+	}{
+		loadTestStageAlias: (*loadTestStageAlias)(s),
+	}
+	if err := json.Unmarshal(data, aux); err != nil {
+		return err
+	}
+	switch dur := aux.Duration.(type) {
+	case nil:
+		s.Duration = 0
+	case float64:
+		s.Duration = time.Duration(dur)
+	case string:
+		d, err := time.ParseDuration(dur)
+		if err != nil {
+			return err
+		}
+		s.Duration = d
+	default:
+		return fmt.Errorf("invalid stage duration: %v", dur)
+	}
+	return nil
 }
 
 // LoadTestStatus - used for representing load test status
@@ -55,6 +174,12 @@ const (
 
 	// LoadTestSuccess - represents a success status
 	LoadTestSuccess LoadTestStatus = "success"
+
+	// LoadTestInterim - represents an interim snapshot of the metrics of a running load test
+	LoadTestInterim LoadTestStatus = "interim"
+
+	// LoadTestQueued - represents a load test waiting in the load test queue
+	LoadTestQueued LoadTestStatus = "queued"
 )
 
 // LoadTestResponse - used to bundle the response with status to the client
@@ -62,15 +187,61 @@ type LoadTestResponse struct {
 	Status  LoadTestStatus `json:"status,omitempty"`
 	Message string         `json:"message,omitempty"`
 	Result  *MesheryResult `json:"result,omitempty"`
+	// RunID identifies the running load test, it is the test uuid when one is given and can be used to cancel the load test
+	RunID string `json:"run_id,omitempty"`
+	// Snapshot is set for the interim status
+	Snapshot *LoadTestSnapshot `json:"snapshot,omitempty"`
+	// Position is the position in the load test queue, set for the queued status
+	Position int `json:"position,omitempty"`
+}
+
+// LoadTestSnapshot - represents the metrics of the requests completed since the previous snapshot of a running
+// load test, latencies are in milliseconds
+type LoadTestSnapshot struct {
+	Time     time.Time `json:"time"`
+	Requests int64     `json:"requests"`
+	Errors   int64     `json:"errors"`
+	QPS      float64   `json:"qps"`
+	Avg      float64   `json:"avg"`
+	P50      float64   `json:"p50"`
+	P90      float64   `json:"p90"`
+	P99      float64   `json:"p99"`
 }
 
+const (
+	// ServerMetricsNodesKey is the key of the server metrics of the nodes in MesheryResult.ServerMetrics
+	ServerMetricsNodesKey = "nodes"
+	// ServerMetricsMeshesKey is the key of the server metrics of the service meshes in MesheryResult.ServerMetrics
+	ServerMetricsMeshesKey = "meshes"
+)
+
 // MesheryResult - represents the results from Meshery test run to be shipped
 type MesheryResult struct {
-	ID     uuid.UUID              `json:"meshery_id,omitempty"`
+	ID uuid.UUID `json:"meshery_id,omitempty"`
+	// UserID is the user who ran the load test, the local results being only visible to them
+	UserID string                 `json:"user_id,omitempty"`
 	Name   string                 `json:"name,omitempty"`
 	Mesh   string                 `json:"mesh,omitempty"`
 	Result map[string]interface{} `json:"runner_results,omitempty"`
 
+	// ServerMetrics holds the results of the cluster queries keyed by query, along with the ones of the node
+	// queries keyed by node under ServerMetricsNodesKey and the ones of the mesh queries keyed by mesh under
+	// ServerMetricsMeshesKey
 	ServerMetrics     interface{} `json:"server_metrics,omitempty"`
 	ServerBoardConfig interface{} `json:"server_board_config,omitempty"`
+	// NodeBoardConfigs holds the static board config of each node, keyed by node
+	NodeBoardConfigs interface{} `json:"node_board_configs,omitempty"`
+	// MeshBoardConfigs holds the static board config of each of the meshes detected, keyed by mesh
+	MeshBoardConfigs interface{} `json:"mesh_board_configs,omitempty"`
+
+	// Alerts are the prometheus alerts which fired during the load test, AlertsFired flagging the result when there
+	// are some
+	Alerts      []*PrometheusAlert `json:"alerts,omitempty"`
+	AlertsFired bool               `json:"alerts_fired,omitempty"`
+
+	Assertions []*SLOAssertionResult `json:"assertions,omitempty"`
+	Verdict    SLOVerdict            `json:"verdict,omitempty"`
+
+	// Overhead is set for the mesh overhead benchmarks, the result being the one of the run with sidecars
+	Overhead *MeshOverhead `json:"mesh_overhead,omitempty"`
 }
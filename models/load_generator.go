@@ -0,0 +1,32 @@
+package models
+
+import (
+	"context"
+
+	"fortio.org/fortio/periodic"
+)
+
+// LoadGeneratorInterface defines the methods a load generator should define
+type LoadGeneratorInterface interface {
+	Name() LoadGenerator
+	Capabilities() *LoadGeneratorCapabilities
+	// Validate checks the load generator supports the load test options
+	Validate(opts *LoadTestOptions) error
+	// Run runs the load test until it is done or ctx is done, and returns the results in the fortio format
+	Run(ctx context.Context, opts *LoadTestOptions) (map[string]interface{}, *periodic.RunnerResults, error)
+}
+
+// LoadGeneratorCapabilities - represents the features supported by a load generator
+type LoadGeneratorCapabilities struct {
+	GRPC bool `json:"grpc"`
+	// HTTPMethods are the supported http methods
+	HTTPMethods []string `json:"http_methods,omitempty"`
+	HTTP2       bool     `json:"http2"`
+	OpenModel   bool     `json:"open_model"`
+	// ClientCerts tells whether client certificates can be used for http load tests
+	ClientCerts bool `json:"client_certs"`
+	// AbortConditions tells whether the load test can be aborted on a status code or an error rate
+	AbortConditions bool `json:"abort_conditions"`
+	// Snapshots tells whether interim snapshots of the metrics are taken during http load tests
+	Snapshots bool `json:"snapshots"`
+}
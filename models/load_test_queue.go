@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+)
+
+// LoadTestJobStatus - represents the status of a queued load test
+type LoadTestJobStatus string
+
+const (
+	// LoadTestJobQueued - the load test waits for its turn
+	LoadTestJobQueued LoadTestJobStatus = "queued"
+	// LoadTestJobRunning - the load test is running
+	LoadTestJobRunning LoadTestJobStatus = "running"
+	// LoadTestJobDone - the load test ran
+	LoadTestJobDone LoadTestJobStatus = "done"
+	// LoadTestJobCancelled - the load test was cancelled before it started
+	LoadTestJobCancelled LoadTestJobStatus = "cancelled"
+)
+
+// LoadTestJob - represents a load test going through the load test queue
+type LoadTestJob struct {
+	ID     string `json:"id"`
+	UserID string `json:"user_id,omitempty"`
+	Name   string `json:"name,omitempty"`
+	// Target is the host and port the load test is run against
+	Target string            `json:"target,omitempty"`
+	Status LoadTestJobStatus `json:"status"`
+	// Position is the position of a queued load test in the queue, starting at 1
+	Position int `json:"position,omitempty"`
+
+	QueuedAt   time.Time `json:"queued_at"`
+	StartedAt  time.Time `json:"started_at,omitempty"`
+	FinishedAt time.Time `json:"finished_at,omitempty"`
+}
+
+// LoadTestQueueInterface defines the methods of the queue limiting the load tests running concurrently
+type LoadTestQueueInterface interface {
+	// Enqueue adds the job to the queue, run is called in a goroutine of its own when its turn comes and
+	// onPosition, if not nil, each time its position in the queue changes
+	Enqueue(job *LoadTestJob, run func(), onPosition func(int)) error
+	// Remove removes the job with the given id if it is still queued
+	Remove(id string) bool
+	GetJob(id string) (*LoadTestJob, bool)
+	// GetJobs returns the jobs of the user, or the ones of all the users when userID is empty
+	GetJobs(userID string) []*LoadTestJob
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// LoadTestSchedule - represents a named load test which is run on a cron schedule
+type LoadTestSchedule struct {
+	ID     uuid.UUID `json:"id,omitempty"`
+	UserID string    `json:"user_id,omitempty"`
+	Name   string    `json:"name,omitempty"`
+	Mesh   string    `json:"mesh,omitempty"`
+	// Schedule is a standard cron expression like "0 2 * * *" or a descriptor like "@daily" or "@every 6h"
+	Schedule string           `json:"schedule,omitempty"`
+	Enabled  bool             `json:"enabled"`
+	Options  *LoadTestOptions `json:"options,omitempty"`
+
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+	NextRun   time.Time `json:"next_run,omitempty"`
+
+	// History holds the latest runs, most recent last
+	History []*LoadTestScheduleRun `json:"history,omitempty"`
+}
+
+// Redacted returns a copy of the schedule with the secrets of its options masked, for it to be shown
+func (s *LoadTestSchedule) Redacted() *LoadTestSchedule {
+	r := *s
+	r.Options = s.Options.Redacted()
+	return &r
+}
+
+// LoadTestScheduleRun - represents a single run of a scheduled load test
+type LoadTestScheduleRun struct {
+	StartTime time.Time      `json:"start_time,omitempty"`
+	EndTime   time.Time      `json:"end_time,omitempty"`
+	Status    LoadTestStatus `json:"status,omitempty"`
+	Message   string         `json:"message,omitempty"`
+	ResultID  string         `json:"result_id,omitempty"`
+}
+
+// LoadTestSchedulePersister defines methods for a load test schedule persister
+type LoadTestSchedulePersister interface {
+	GetSchedules(userID string) ([]*LoadTestSchedule, error)
+	GetSchedule(key uuid.UUID) (*LoadTestSchedule, error)
+	WriteSchedule(key uuid.UUID, schedule *LoadTestSchedule) error
+	DeleteSchedule(key uuid.UUID) error
+
+	Close()
+}
@@ -0,0 +1,12 @@
+package models
+
+import (
+	"context"
+)
+
+// LoadTestTrackerInterface defines the methods for keeping track of the running load tests
+type LoadTestTrackerInterface interface {
+	AddRun(ctx context.Context, id, userID string, cancel context.CancelFunc) error
+	CancelRun(ctx context.Context, id, userID string) bool
+	RemoveRun(ctx context.Context, id string)
+}
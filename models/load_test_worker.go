@@ -0,0 +1,16 @@
+package models
+
+// LoadTestWorkerSecurity - represents how Meshery and its load generator workers authenticate each other, with a
+// mutual TLS along with an optional shared token
+type LoadTestWorkerSecurity struct {
+	// Token is the shared token sent along with each load test job, only over mutual TLS
+	Token string
+	// CertFile and KeyFile are the certificate presented to the other side and CAFile the CA verifying the one of
+	// the other side, all of them being needed for mutual TLS
+	CertFile, KeyFile, CAFile string
+}
+
+// MutualTLS tells whether mutual TLS is configured
+func (s *LoadTestWorkerSecurity) MutualTLS() bool {
+	return s != nil && s.CertFile != "" && s.KeyFile != "" && s.CAFile != ""
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// MetricsTask - represents the collection of the server metrics of a load test result.
+// It is persisted until it succeeds, or kept as a dead letter once all its attempts failed.
+type MetricsTask struct {
+	ID         uuid.UUID            `json:"id"`
+	Config     *SubmitMetricsConfig `json:"config"`
+	Attempts   int                  `json:"attempts"`
+	LastError  string               `json:"last_error,omitempty"`
+	DeadLetter bool                 `json:"dead_letter"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// MetricsTaskPersister defines methods for a metrics task persister
+type MetricsTaskPersister interface {
+	GetTasks() ([]*MetricsTask, error)
+	GetTask(key uuid.UUID) (*MetricsTask, error)
+	WriteTask(key uuid.UUID, task *MetricsTask) error
+	DeleteTask(key uuid.UUID) error
+
+	Close()
+}
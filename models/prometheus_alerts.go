@@ -0,0 +1,162 @@
+package models
+
+import (
+	"time"
+
+	promQAPI "github.com/prometheus/client_golang/api/prometheus/v1"
+	promModel "github.com/prometheus/common/model"
+)
+
+// PrometheusAlert - represents an alert of Prometheus
+type PrometheusAlert struct {
+	Name        string            `json:"name"`
+	State       string            `json:"state"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Value       string            `json:"value,omitempty"`
+	ActiveAt    time.Time         `json:"active_at,omitempty"`
+
+	// FiringFrom and FiringTo bound the time the alert was seen firing, for the alerts fired during a window
+	FiringFrom time.Time `json:"firing_from,omitempty"`
+	FiringTo   time.Time `json:"firing_to,omitempty"`
+}
+
+// PrometheusRuleType - represents the type of a Prometheus rule
+type PrometheusRuleType string
+
+const (
+	// PrometheusAlertingRule - the rule fires alerts
+	PrometheusAlertingRule PrometheusRuleType = "alerting"
+	// PrometheusRecordingRule - the rule records the result of its query as a new series
+	PrometheusRecordingRule PrometheusRuleType = "recording"
+)
+
+// PrometheusRule - represents an alerting or recording rule of Prometheus
+type PrometheusRule struct {
+	Type        PrometheusRuleType `json:"type"`
+	Name        string             `json:"name"`
+	Query       string             `json:"query"`
+	Labels      map[string]string  `json:"labels,omitempty"`
+	Annotations map[string]string  `json:"annotations,omitempty"`
+	// Duration is the time in seconds the condition of an alerting rule has to hold before the alert fires
+	Duration  float64            `json:"duration,omitempty"`
+	Alerts    []*PrometheusAlert `json:"alerts,omitempty"`
+	Health    string             `json:"health"`
+	LastError string             `json:"last_error,omitempty"`
+}
+
+// PrometheusRuleGroup - represents a group of Prometheus rules
+type PrometheusRuleGroup struct {
+	Name string `json:"name"`
+	File string `json:"file"`
+	// Interval is the evaluation interval of the rules in seconds
+	Interval float64           `json:"interval"`
+	Rules    []*PrometheusRule `json:"rules"`
+}
+
+func newPrometheusAlert(alert promQAPI.Alert) *PrometheusAlert {
+	labels := labelSetMap(alert.Labels)
+	return &PrometheusAlert{
+		Name:        labels[promModel.AlertNameLabel],
+		State:       string(alert.State),
+		Labels:      labels,
+		Annotations: labelSetMap(alert.Annotations),
+		Value:       alert.Value,
+		ActiveAt:    alert.ActiveAt,
+	}
+}
+
+func newPrometheusRuleGroup(group promQAPI.RuleGroup) *PrometheusRuleGroup {
+	g := &PrometheusRuleGroup{
+		Name:     group.Name,
+		File:     group.File,
+		Interval: group.Interval,
+		Rules:    []*PrometheusRule{},
+	}
+	for _, r := range group.Rules {
+		switch rule := r.(type) {
+		case promQAPI.AlertingRule:
+			alerts := []*PrometheusAlert{}
+			for _, alert := range rule.Alerts {
+				alerts = append(alerts, newPrometheusAlert(*alert))
+			}
+			g.Rules = append(g.Rules, &PrometheusRule{
+				Type:        PrometheusAlertingRule,
+				Name:        rule.Name,
+				Query:       rule.Query,
+				Labels:      labelSetMap(rule.Labels),
+				Annotations: labelSetMap(rule.Annotations),
+				Duration:    rule.Duration,
+				Alerts:      alerts,
+				Health:      string(rule.Health),
+				LastError:   rule.LastError,
+			})
+		case promQAPI.RecordingRule:
+			g.Rules = append(g.Rules, &PrometheusRule{
+				Type:      PrometheusRecordingRule,
+				Name:      rule.Name,
+				Query:     rule.Query,
+				Labels:    labelSetMap(rule.Labels),
+				Health:    string(rule.Health),
+				LastError: rule.LastError,
+			})
+		}
+	}
+	return g
+}
+
+func labelSetMap(labelSet promModel.LabelSet) map[string]string {
+	if len(labelSet) == 0 {
+		return nil
+	}
+	m := make(map[string]string, len(labelSet))
+	for name, value := range labelSet {
+		m[string(name)] = string(value)
+	}
+	return m
+}
+
+const (
+	// defaultEvaluationInterval is the evaluation interval of the rules of prometheus when its rules are unknown
+	defaultEvaluationInterval = time.Minute
+	// alertsGraceIntervals is the number of evaluation intervals after a window during which the alerts it caused
+	// are looked for, the alerts firing at the earliest on the evaluation following their condition
+	alertsGraceIntervals = 2
+	// MaxAlertsGracePeriod bounds the grace period after a window, for the rules evaluated rarely
+	MaxAlertsGracePeriod = 5 * time.Minute
+)
+
+// alertsEvaluationIntervals returns the shortest evaluation interval of the groups having alerting rules and the
+// grace period after a window, up to MaxAlertsGracePeriod, defaultEvaluationInterval being used when the intervals are unknown
+func alertsEvaluationIntervals(groups []*PrometheusRuleGroup) (shortest, grace time.Duration) {
+	var longest time.Duration
+	for _, group := range groups {
+		interval := time.Duration(group.Interval * float64(time.Second))
+		if interval <= 0 || !hasAlertingRules(group) {
+			continue
+		}
+		if shortest == 0 || interval < shortest {
+			shortest = interval
+		}
+		if interval > longest {
+			longest = interval
+		}
+	}
+	if shortest == 0 {
+		shortest, longest = defaultEvaluationInterval, defaultEvaluationInterval
+	}
+	grace = alertsGraceIntervals * longest
+	if grace > MaxAlertsGracePeriod {
+		grace = MaxAlertsGracePeriod
+	}
+	return shortest, grace
+}
+
+func hasAlertingRules(group *PrometheusRuleGroup) bool {
+	for _, rule := range group.Rules {
+		if rule.Type == PrometheusAlertingRule {
+			return true
+		}
+	}
+	return false
+}
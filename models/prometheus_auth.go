@@ -0,0 +1,118 @@
+package models
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// PrometheusAuth - represents the credentials and TLS settings used to reach Prometheus
+type PrometheusAuth struct {
+	Username    string `json:"username,omitempty"`
+	Password    string `json:"password,omitempty"`
+	BearerToken string `json:"bearerToken,omitempty"`
+	// TenantID is sent in the X-Scope-OrgID header, used by Cortex and Thanos for multi-tenancy
+	TenantID string `json:"tenantID,omitempty"`
+	// Headers are added to each of the requests
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// CACert, ClientCert and ClientKey are PEM encoded, the client certificate and key being used for mTLS
+	CACert             string `json:"caCert,omitempty"`
+	ClientCert         string `json:"clientCert,omitempty"`
+	ClientKey          string `json:"clientKey,omitempty"`
+	InsecureSkipVerify bool   `json:"insecureSkipVerify,omitempty"`
+}
+
+// tenantHeader is the header carrying the tenant for Cortex and Thanos
+const tenantHeader = "X-Scope-OrgID"
+
+// hasTLS tells whether the TLS settings of the transport have to be changed
+func (a *PrometheusAuth) hasTLS() bool {
+	return a.CACert != "" || a.ClientCert != "" || a.ClientKey != "" || a.InsecureSkipVerify
+}
+
+// tlsConfig builds the TLS config from the certificates
+func (a *PrometheusAuth) tlsConfig() (*tls.Config, error) {
+	config := &tls.Config{
+		InsecureSkipVerify: a.InsecureSkipVerify,
+	}
+	if a.CACert != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(a.CACert)) {
+			return nil, errors.New("unable to parse the CA certificate of prometheus")
+		}
+		config.RootCAs = pool
+	}
+	if a.ClientCert != "" || a.ClientKey != "" {
+		cert, err := tls.X509KeyPair([]byte(a.ClientCert), []byte(a.ClientKey))
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+// tlsKey identifies the TLS settings, so that the transports are shared by the sessions having the same ones.
+// It is a digest, for the key material not to be kept around as a map key.
+func (a *PrometheusAuth) tlsKey() string {
+	h := sha256.New()
+	for _, s := range []string{a.CACert, a.ClientCert, a.ClientKey} {
+		_, _ = h.Write([]byte(s))
+		_, _ = h.Write([]byte{0})
+	}
+	if a.InsecureSkipVerify {
+		_, _ = h.Write([]byte("insecure"))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// redactedValue replaces the secrets in the redacted copies
+const redactedValue = "********"
+
+// Redacted returns a copy of the credentials with the secrets masked, for them to be shown
+func (a *PrometheusAuth) Redacted() *PrometheusAuth {
+	if a == nil {
+		return nil
+	}
+	r := *a
+	for _, s := range []*string{&r.Password, &r.BearerToken, &r.ClientKey} {
+		if *s != "" {
+			*s = redactedValue
+		}
+	}
+	if len(a.Headers) > 0 {
+		r.Headers = make(map[string]string, len(a.Headers))
+		for key := range a.Headers {
+			r.Headers[key] = redactedValue
+		}
+	}
+	return &r
+}
+
+// prometheusRoundTripper adds the credentials to the requests sent to Prometheus
+type prometheusRoundTripper struct {
+	auth *PrometheusAuth
+	rt   http.RoundTripper
+}
+
+func (t *prometheusRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	// a RoundTripper must not modify the request it is given
+	req = req.Clone(req.Context())
+	for key, value := range t.auth.Headers {
+		req.Header.Set(key, value)
+	}
+	if t.auth.TenantID != "" {
+		req.Header.Set(tenantHeader, t.auth.TenantID)
+	}
+	if t.auth.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+t.auth.BearerToken)
+	} else if t.auth.Username != "" {
+		req.SetBasicAuth(t.auth.Username, t.auth.Password)
+	}
+	return t.rt.RoundTrip(req)
+}
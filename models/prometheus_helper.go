@@ -2,10 +2,14 @@ package models
 
 import (
 	"bytes"
+	"container/list"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/url"
+	"sort"
+	"sync"
 	"text/template"
 	"time"
 
@@ -15,12 +19,34 @@ import (
 	promQAPI "github.com/prometheus/client_golang/api/prometheus/v1"
 	promModel "github.com/prometheus/common/model"
 	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
 )
 
 // PrometheusClient represents a prometheus client in Meshery
 type PrometheusClient struct {
 	grafanaClient *GrafanaClient
-	promURL       string
+	httpClient    *http.Client
+
+	// transports holds the transports with the TLS settings of the sessions, keyed by a digest of the TLS settings.
+	// The least recently used ones are evicted beyond maxPrometheusTransports.
+	transports    map[string]*list.Element
+	transportsLRU *list.List
+	// scrapeIntervals caches the scrape intervals, keyed by prometheus URL
+	scrapeIntervals map[string]*scrapeInterval
+	lock            *sync.Mutex
+}
+
+// maxPrometheusTransports is the number of TLS settings whose transports are kept
+const maxPrometheusTransports = 32
+
+type cachedTransport struct {
+	key       string
+	transport *http.Transport
+}
+
+type scrapeInterval struct {
+	interval  time.Duration
+	fetchedAt time.Time
 }
 
 // NewPrometheusClient returns a PrometheusClient
@@ -31,19 +57,123 @@ func NewPrometheusClient() *PrometheusClient {
 // NewPrometheusClientWithHTTPClient returns a PrometheusClient with a given http.Client
 func NewPrometheusClientWithHTTPClient(client *http.Client) *PrometheusClient {
 	return &PrometheusClient{
-		grafanaClient: NewGrafanaClientForPrometheusWithHTTPClient(client),
+		grafanaClient:   NewGrafanaClientForPrometheusWithHTTPClient(client),
+		httpClient:      client,
+		transports:      map[string]*list.Element{},
+		transportsLRU:   list.New(),
+		scrapeIntervals: map[string]*scrapeInterval{},
+		lock:            &sync.Mutex{},
 	}
 }
 
-// Validate - helps validate the connection
-func (p *PrometheusClient) Validate(ctx context.Context, promURL string) error {
-	_, err := p.grafanaClient.makeRequest(ctx, promURL+"/api/v1/status/config", "")
+// Validate - helps validate the connection, along with the credentials.
+// It runs a trivial query, the config endpoint not being exposed by Cortex and Thanos.
+func (p *PrometheusClient) Validate(ctx context.Context, prom *Prometheus) error {
+	g, err := p.grafanaClientFor(prom)
+	if err != nil {
+		return err
+	}
+	_, err = g.makeRequest(ctx, prom.PrometheusURL+"/api/v1/query?query=1", "")
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
+// roundTripper returns the RoundTripper sending the requests with the credentials of prom, nil when there are none
+func (p *PrometheusClient) roundTripper(prom *Prometheus) (http.RoundTripper, error) {
+	if prom.KubernetesService != nil {
+		if prom.Transport == nil {
+			err := errors.New("the kubernetes credentials reaching prometheus are not available")
+			logrus.Error(err)
+			return nil, err
+		}
+		return prom.Transport, nil
+	}
+	if prom.Auth == nil {
+		return nil, nil
+	}
+	rt := p.httpClient.Transport
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	if prom.Auth.hasTLS() {
+		transport, err := p.tlsTransport(prom.Auth, rt)
+		if err != nil {
+			return nil, err
+		}
+		rt = transport
+	}
+	return &prometheusRoundTripper{
+		auth: prom.Auth,
+		rt:   rt,
+	}, nil
+}
+
+// tlsTransport returns the transport with the TLS settings of auth, based on rt when it is an http.Transport
+func (p *PrometheusClient) tlsTransport(auth *PrometheusAuth, rt http.RoundTripper) (*http.Transport, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	key := auth.tlsKey()
+	if el, ok := p.transports[key]; ok {
+		p.transportsLRU.MoveToFront(el)
+		return el.Value.(*cachedTransport).transport, nil
+	}
+	config, err := auth.tlsConfig()
+	if err != nil {
+		err = errors.Wrap(err, "invalid prometheus TLS settings")
+		logrus.Error(err)
+		return nil, err
+	}
+	base, ok := rt.(*http.Transport)
+	if !ok {
+		base = http.DefaultTransport.(*http.Transport)
+	}
+	transport := base.Clone()
+	transport.TLSClientConfig = config
+	p.transports[key] = p.transportsLRU.PushFront(&cachedTransport{
+		key:       key,
+		transport: transport,
+	})
+	for p.transportsLRU.Len() > maxPrometheusTransports {
+		el := p.transportsLRU.Back()
+		evicted := p.transportsLRU.Remove(el).(*cachedTransport)
+		delete(p.transports, evicted.key)
+		evicted.transport.CloseIdleConnections()
+	}
+	return transport, nil
+}
+
+// grafanaClientFor returns the GrafanaClient sending the requests with the credentials of prom
+func (p *PrometheusClient) grafanaClientFor(prom *Prometheus) (*GrafanaClient, error) {
+	rt, err := p.roundTripper(prom)
+	if err != nil || rt == nil {
+		return p.grafanaClient, err
+	}
+	return NewGrafanaClientForPrometheusWithHTTPClient(&http.Client{
+		Transport: rt,
+		Timeout:   p.httpClient.Timeout,
+	}), nil
+}
+
+// apiClient returns the client_golang API client sending the requests with the credentials of prom
+func (p *PrometheusClient) apiClient(prom *Prometheus) (promQAPI.API, error) {
+	rt, err := p.roundTripper(prom)
+	if err != nil {
+		return nil, err
+	}
+	c, err := promAPI.NewClient(promAPI.Config{
+		Address:      prom.PrometheusURL,
+		RoundTripper: rt,
+	})
+	if err != nil {
+		err = errors.Wrap(err, "unable to create the prometheus client")
+		logrus.Error(err)
+		return nil, err
+	}
+	return promQAPI.NewAPI(c), nil
+}
+
 // ImportGrafanaBoard takes raw Grafana board json and returns GrafanaBoard pointer for use in Meshery
 func (p *PrometheusClient) ImportGrafanaBoard(ctx context.Context, boardData []byte) (*GrafanaBoard, error) {
 	board := &sdk.Board{}
@@ -59,36 +189,96 @@ func (p *PrometheusClient) ImportGrafanaBoard(ctx context.Context, boardData []b
 }
 
 // Query queries prometheus using the GrafanaClient
-func (p *PrometheusClient) Query(ctx context.Context, promURL string, queryData *url.Values) ([]byte, error) {
-	return p.grafanaClient.GrafanaQuery(ctx, promURL, "", queryData)
+func (p *PrometheusClient) Query(ctx context.Context, prom *Prometheus, queryData *url.Values) ([]byte, error) {
+	g, err := p.grafanaClientFor(prom)
+	if err != nil {
+		return nil, err
+	}
+	return g.GrafanaQuery(ctx, prom.PrometheusURL, "", queryData)
 }
 
 // QueryRange queries prometheus using the GrafanaClient
-func (p *PrometheusClient) QueryRange(ctx context.Context, promURL string, queryData *url.Values) ([]byte, error) {
-	return p.grafanaClient.GrafanaQueryRange(ctx, promURL, "", queryData)
+func (p *PrometheusClient) QueryRange(ctx context.Context, prom *Prometheus, queryData *url.Values) ([]byte, error) {
+	g, err := p.grafanaClientFor(prom)
+	if err != nil {
+		return nil, err
+	}
+	return g.GrafanaQueryRange(ctx, prom.PrometheusURL, "", queryData)
 }
 
 // GetClusterStaticBoard retrieves the cluster static board config
-func (p *PrometheusClient) GetClusterStaticBoard(ctx context.Context, promURL string) (*GrafanaBoard, error) {
+func (p *PrometheusClient) GetClusterStaticBoard(ctx context.Context, prom *Prometheus) (*GrafanaBoard, error) {
 	return p.ImportGrafanaBoard(ctx, []byte(staticBoardCluster))
 }
 
+// GetMeshStaticBoards retrieves the static board config of each of the given meshes, keyed by mesh, the meshes
+// without a static board being skipped
+func (p *PrometheusClient) GetMeshStaticBoards(ctx context.Context, meshes []string) (map[string]*GrafanaBoard, error) {
+	boards := map[string]*GrafanaBoard{}
+	for _, mesh := range meshes {
+		boardData, err := meshStaticBoard(mesh)
+		if err != nil {
+			err = errors.Wrapf(err, "unable to build the static board of %s", mesh)
+			logrus.Error(err)
+			return nil, err
+		}
+		if boardData == nil {
+			continue
+		}
+		board, err := p.ImportGrafanaBoard(ctx, boardData)
+		if err != nil {
+			return nil, err
+		}
+		boards[mesh] = board
+	}
+	return boards, nil
+}
+
 // Close - closes idle connections
 func (p *PrometheusClient) Close() {
 	p.grafanaClient.Close()
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for el := p.transportsLRU.Front(); el != nil; el = el.Next() {
+		el.Value.(*cachedTransport).transport.CloseIdleConnections()
+	}
 }
 
 // GetNodesStaticBoard retrieves the per node static board config
-func (p *PrometheusClient) GetNodesStaticBoard(ctx context.Context, promURL string) (*GrafanaBoard, error) {
-	var buf bytes.Buffer
-	ttt := template.New("staticBoard").Delims("[[", "]]")
-	instances, err := p.getAllNodes(ctx, promURL)
+func (p *PrometheusClient) GetNodesStaticBoard(ctx context.Context, prom *Prometheus) (*GrafanaBoard, error) {
+	instances, err := p.getAllNodes(ctx, prom, time.Now().Add(-5*time.Minute), time.Now())
 	if err != nil {
 		err = errors.Wrapf(err, "unable to get all the nodes")
 		logrus.Error(err)
 		return nil, err
 	}
 	logrus.Debugf("Instances: %v, length: %d", instances, len(instances))
+	return p.nodesStaticBoard(ctx, instances)
+}
+
+// GetNodeStaticBoards retrieves the static board config of each of the nodes which were up between start and end,
+// keyed by node
+func (p *PrometheusClient) GetNodeStaticBoards(ctx context.Context, prom *Prometheus, start, end time.Time) (map[string]*GrafanaBoard, error) {
+	instances, err := p.getAllNodes(ctx, prom, start, end)
+	if err != nil {
+		err = errors.Wrapf(err, "unable to get all the nodes")
+		logrus.Error(err)
+		return nil, err
+	}
+	boards := map[string]*GrafanaBoard{}
+	for _, instance := range instances {
+		board, err := p.nodesStaticBoard(ctx, []string{instance})
+		if err != nil {
+			return nil, err
+		}
+		boards[instance] = board
+	}
+	return boards, nil
+}
+
+func (p *PrometheusClient) nodesStaticBoard(ctx context.Context, instances []string) (*GrafanaBoard, error) {
+	var buf bytes.Buffer
+	ttt := template.New("staticBoard").Delims("[[", "]]")
 	tpl := template.Must(ttt.Parse(staticBoardNodes))
 	if err := tpl.Execute(&buf, map[string]interface{}{
 		"instances":  instances,
@@ -102,35 +292,52 @@ func (p *PrometheusClient) GetNodesStaticBoard(ctx context.Context, promURL stri
 	return p.ImportGrafanaBoard(ctx, buf.Bytes())
 }
 
-func (p *PrometheusClient) getAllNodes(ctx context.Context, promURL string) ([]string, error) {
+func (p *PrometheusClient) getAllNodes(ctx context.Context, prom *Prometheus, start, end time.Time) ([]string, error) {
 	// api/datasources/proxy/1/api/v1/series?match[]=node_boot_time_seconds%7Bcluster%3D%22%22%2C%20job%3D%22node-exporter%22%7D&start=1568392571&end=1568396171
-	c, _ := promAPI.NewClient(promAPI.Config{
-		Address: promURL,
-	})
-	qc := promQAPI.NewAPI(c)
-	labelSet, _, err := qc.Series(ctx, []string{`node_boot_time_seconds{cluster="", job="node-exporter"}`}, time.Now().Add(-5*time.Minute), time.Now())
+	qc, err := p.apiClient(prom)
+	if err != nil {
+		return nil, err
+	}
+	labelSet, _, err := qc.Series(ctx, []string{`node_boot_time_seconds{cluster="", job="node-exporter"}`}, start, end)
 	if err != nil {
 		err = errors.Wrapf(err, "unable to get the label set series")
 		logrus.Error(err)
 		return nil, err
 	}
 	result := []string{}
+	seen := map[string]bool{}
 	for _, l := range labelSet {
 		inst, _ := l["instance"]
 		ins := string(inst)
-		if ins != "" {
+		if ins != "" && !seen[ins] {
+			seen[ins] = true
 			result = append(result, ins)
 		}
 	}
 	return result, nil
 }
 
+// QueryUsingClient performs an instant query at the given time
+func (p *PrometheusClient) QueryUsingClient(ctx context.Context, prom *Prometheus, query string, ts time.Time) (promModel.Value, error) {
+	qc, err := p.apiClient(prom)
+	if err != nil {
+		return nil, err
+	}
+	result, _, err := qc.Query(ctx, query, ts)
+	if err != nil {
+		err := errors.Wrapf(err, "error fetching data for query: %s, at: %v", query, ts)
+		logrus.Error(err)
+		return nil, err
+	}
+	return result, nil
+}
+
 // QueryRangeUsingClient performs a range query within a window
-func (p *PrometheusClient) QueryRangeUsingClient(ctx context.Context, promURL, query string, startTime, endTime time.Time, step time.Duration) (promModel.Value, error) {
-	c, _ := promAPI.NewClient(promAPI.Config{
-		Address: promURL,
-	})
-	qc := promQAPI.NewAPI(c)
+func (p *PrometheusClient) QueryRangeUsingClient(ctx context.Context, prom *Prometheus, query string, startTime, endTime time.Time, step time.Duration) (promModel.Value, error) {
+	qc, err := p.apiClient(prom)
+	if err != nil {
+		return nil, err
+	}
 	result, _, err := qc.QueryRange(ctx, query, promQAPI.Range{
 		Start: startTime,
 		End:   endTime,
@@ -144,45 +351,197 @@ func (p *PrometheusClient) QueryRangeUsingClient(ctx context.Context, promURL, q
 	return result, nil
 }
 
-// ComputeStep computes the step size for a window
-func (p *PrometheusClient) ComputeStep(ctx context.Context, start, end time.Time) time.Duration {
-	step := 5 * time.Second
-	diff := end.Sub(start)
-	// all calc. here are approx.
-	if diff <= 10*time.Minute { // 10 mins
-		step = 5 * time.Second
-	} else if diff <= 30*time.Minute { // 30 mins
-		step = 10 * time.Second
-	} else if diff > 30*time.Minute && diff <= time.Hour { // 60 mins/1hr
-		step = 20 * time.Second
-	} else if diff > 1*time.Hour && diff <= 3*time.Hour { // 3 time.Hour
-		step = 1 * time.Minute
-	} else if diff > 3*time.Hour && diff <= 6*time.Hour { // 6 time.Hour
-		step = 2 * time.Minute
-	} else if diff > 6*time.Hour && diff <= 1*24*time.Hour { // 24 time.Hour/1 day
-		step = 8 * time.Minute
-	} else if diff > 1*24*time.Hour && diff <= 2*24*time.Hour { // 2 24*time.Hour
-		step = 16 * time.Minute
-	} else if diff > 2*24*time.Hour && diff <= 4*24*time.Hour { // 4 24*time.Hour
-		step = 32 * time.Minute
-	} else if diff > 4*24*time.Hour && diff <= 7*24*time.Hour { // 7 24*time.Hour
-		step = 56 * time.Minute
-	} else if diff > 7*24*time.Hour && diff <= 15*24*time.Hour { // 15 24*time.Hour
-		step = 2 * time.Hour
-	} else if diff > 15*24*time.Hour && diff <= 1*30*24*time.Hour { // 30 24*time.Hour/1 month
-		step = 4 * time.Hour
-	} else if diff > 1*30*24*time.Hour && diff <= 3*30*24*time.Hour { // 3 months
-		step = 12 * time.Hour
-	} else if diff > 3*30*24*time.Hour && diff <= 6*30*24*time.Hour { // 6 months
-		step = 1 * 24 * time.Hour
-	} else if diff > 6*30*24*time.Hour && diff <= 1*12*30*24*time.Hour { // 1 year/12 months
-		step = 2 * 24 * time.Hour
-	} else if diff > 1*12*30*24*time.Hour && diff <= 2*12*30*24*time.Hour { // 2 years
-		step = 4 * 24 * time.Hour
-	} else if diff > 2*12*30*24*time.Hour && diff <= 5*12*30*24*time.Hour { // 5 years
-		step = 10 * 24 * time.Hour
-	} else {
-		step = 30 * 24 * time.Hour
-	}
-	return step
+// GetRules retrieves the alerting and recording rules of prometheus
+func (p *PrometheusClient) GetRules(ctx context.Context, prom *Prometheus) ([]*PrometheusRuleGroup, error) {
+	qc, err := p.apiClient(prom)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := qc.Rules(ctx)
+	if err != nil {
+		err = errors.Wrap(err, "unable to get the prometheus rules")
+		logrus.Error(err)
+		return nil, err
+	}
+	groups := []*PrometheusRuleGroup{}
+	for _, group := range rules.Groups {
+		groups = append(groups, newPrometheusRuleGroup(group))
+	}
+	return groups, nil
+}
+
+// GetAlerts retrieves the alerts of prometheus which are pending or firing
+func (p *PrometheusClient) GetAlerts(ctx context.Context, prom *Prometheus) ([]*PrometheusAlert, error) {
+	qc, err := p.apiClient(prom)
+	if err != nil {
+		return nil, err
+	}
+	result, err := qc.Alerts(ctx)
+	if err != nil {
+		err = errors.Wrap(err, "unable to get the prometheus alerts")
+		logrus.Error(err)
+		return nil, err
+	}
+	alerts := []*PrometheusAlert{}
+	for _, alert := range result.Alerts {
+		alerts = append(alerts, newPrometheusAlert(alert))
+	}
+	return alerts, nil
+}
+
+// GetFiredAlerts retrieves the alerts which fired between start and end from the ALERTS series, the annotations
+// being taken from the alerting rules when they are available. The alerts firing up to the grace period after end
+// are included, as far as it has elapsed: see WaitForFiredAlerts for the windows which just ended.
+func (p *PrometheusClient) GetFiredAlerts(ctx context.Context, prom *Prometheus, start, end time.Time) ([]*PrometheusAlert, error) {
+	groups, err := p.GetRules(ctx, prom)
+	if err != nil {
+		// the firing alerts are still looked for, without the annotations
+		groups = nil
+	}
+	return p.firedAlerts(ctx, prom, groups, start, end)
+}
+
+// WaitForFiredAlerts retrieves the alerts which fired between start and end like GetFiredAlerts, waiting for the
+// grace period after end to elapse first, so that the alerts caused by the end of the window have been evaluated
+func (p *PrometheusClient) WaitForFiredAlerts(ctx context.Context, prom *Prometheus, start, end time.Time) ([]*PrometheusAlert, error) {
+	groups, err := p.GetRules(ctx, prom)
+	if err != nil {
+		groups = nil
+	}
+	_, grace := alertsEvaluationIntervals(groups)
+	if wait := time.Until(end.Add(grace)); wait > 0 {
+		logrus.Debugf("waiting %s for the prometheus alerts to be evaluated", wait)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, errors.Wrap(ctx.Err(), "unable to wait for the prometheus alerts")
+		}
+	}
+	return p.firedAlerts(ctx, prom, groups, start, end)
+}
+
+// firedAlerts retrieves the alerts which fired between start and the grace period after end, with the annotations
+// of the alerting rules of groups. The step of the query is the evaluation interval of the rules at most, so that
+// the alerts firing for a single evaluation are not missed. The alerts already firing at start, like the Watchdog
+// one, are left out unless they stop and fire again.
+func (p *PrometheusClient) firedAlerts(ctx context.Context, prom *Prometheus, groups []*PrometheusRuleGroup, start, end time.Time) ([]*PrometheusAlert, error) {
+	step, grace := alertsEvaluationIntervals(groups)
+	end = end.Add(grace)
+	if now := time.Now(); end.After(now) {
+		end = now
+	}
+	if !end.After(start) {
+		return []*PrometheusAlert{}, nil
+	}
+	// the query looks back over a whole step, so that a larger step keeping the points below the limit does not
+	// miss any evaluation either
+	if minStep := end.Sub(start) / maxQueryPoints; step < minStep {
+		step = minStep.Truncate(time.Second) + time.Second
+	}
+	query := fmt.Sprintf(`max_over_time(ALERTS{alertstate="firing"}[%s])`, promModel.Duration(step))
+	result, err := p.QueryRangeUsingClient(ctx, prom, query, start, end, step)
+	if err != nil {
+		return nil, err
+	}
+	matrix, ok := result.(promModel.Matrix)
+	if !ok {
+		return nil, errors.Errorf("unexpected result type %s for the alerts", result.Type())
+	}
+
+	annotations := map[string]map[string]string{}
+	for _, group := range groups {
+		for _, rule := range group.Rules {
+			if rule.Type == PrometheusAlertingRule {
+				annotations[rule.Name] = rule.Annotations
+			}
+		}
+	}
+
+	alerts := []*PrometheusAlert{}
+	for _, stream := range matrix {
+		values := firingSince(stream.Values, start, step)
+		if len(values) == 0 {
+			continue
+		}
+		labels := labelSetMap(promModel.LabelSet(stream.Metric))
+		delete(labels, promModel.MetricNameLabel)
+		delete(labels, "alertstate")
+		name := labels[promModel.AlertNameLabel]
+		alerts = append(alerts, &PrometheusAlert{
+			Name:        name,
+			State:       string(promQAPI.AlertStateFiring),
+			Labels:      labels,
+			Annotations: annotations[name],
+			FiringFrom:  values[0].Timestamp.Time(),
+			FiringTo:    values[len(values)-1].Timestamp.Time(),
+		})
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		return alerts[i].FiringFrom.Before(alerts[j].FiringFrom)
+	})
+	return alerts, nil
+}
+
+// firingSince drops the points of the evaluations, from start on, at which the alert was firing already, up to the
+// first one it was not firing at
+func firingSince(values []promModel.SamplePair, start time.Time, step time.Duration) []promModel.SamplePair {
+	i := 0
+	for i < len(values) && !values[i].Timestamp.Time().After(start.Add(time.Duration(i)*step)) {
+		i++
+	}
+	return values[i:]
+}
+
+// ComputeStep computes the step of the range queries between start and end, driven by the options of the panel and
+// the scrape interval of prometheus. Up to stepOversampling times the max data points are fetched, so that the series
+// can be downsampled keeping their shape.
+func (p *PrometheusClient) ComputeStep(ctx context.Context, prom *Prometheus, start, end time.Time, opts *StepOptions) time.Duration {
+	return computeStep(start, end, p.ScrapeInterval(ctx, prom), opts)
+}
+
+// ScrapeInterval returns the global scrape interval of prometheus, 0 when it is unknown
+func (p *PrometheusClient) ScrapeInterval(ctx context.Context, prom *Prometheus) time.Duration {
+	p.lock.Lock()
+	cached, ok := p.scrapeIntervals[prom.PrometheusURL]
+	p.lock.Unlock()
+	if ok && time.Since(cached.fetchedAt) < scrapeIntervalTTL {
+		return cached.interval
+	}
+
+	var interval time.Duration
+	qc, err := p.apiClient(prom)
+	if err == nil {
+		var config promQAPI.ConfigResult
+		config, err = qc.Config(ctx)
+		if err == nil {
+			interval, err = parseScrapeInterval(config.YAML)
+		}
+	}
+	if err != nil {
+		// Cortex and Thanos do not expose the config
+		logrus.Debugf("unable to get the scrape interval of prometheus: %v", err)
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.scrapeIntervals[prom.PrometheusURL] = &scrapeInterval{
+		interval:  interval,
+		fetchedAt: time.Now(),
+	}
+	return interval
+}
+
+func parseScrapeInterval(config string) (time.Duration, error) {
+	c := struct {
+		Global struct {
+			ScrapeInterval string `yaml:"scrape_interval"`
+		} `yaml:"global"`
+	}{}
+	if err := yaml.Unmarshal([]byte(config), &c); err != nil {
+		return 0, err
+	}
+	if c.Global.ScrapeInterval == "" {
+		return 0, nil
+	}
+	d, err := promModel.ParseDuration(c.Global.ScrapeInterval)
+	return time.Duration(d), err
 }
@@ -0,0 +1,180 @@
+package models
+
+import (
+	"encoding/json"
+)
+
+// meshBoardPanel describes a graph panel of a mesh static board
+type meshBoardPanel struct {
+	title, expr, legend, unit string
+}
+
+// meshStaticBoards holds the panels of the static board of each of the meshes found by the kubernetes scan, keyed by
+// the name of the mesh. The queries rely on the metrics of the sidecars, Envoy for Istio and Consul, linkerd-proxy
+// for Linkerd, and on cAdvisor for the resources used by the sidecars.
+var meshStaticBoards = map[string][]meshBoardPanel{
+	"Istio": {
+		{
+			title:  "Request Rate by Workload",
+			expr:   `sum(rate(istio_requests_total{reporter="destination"}[1m])) by (destination_workload_namespace, destination_workload)`,
+			legend: "{{destination_workload_namespace}}/{{destination_workload}}",
+			unit:   "reqps",
+		},
+		{
+			title:  "Success Rate by Workload",
+			expr:   `sum(rate(istio_requests_total{reporter="destination", response_code!~"5.*"}[1m])) by (destination_workload_namespace, destination_workload) / sum(rate(istio_requests_total{reporter="destination"}[1m])) by (destination_workload_namespace, destination_workload)`,
+			legend: "{{destination_workload_namespace}}/{{destination_workload}}",
+			unit:   "percentunit",
+		},
+		{
+			title:  "P99 Latency by Workload",
+			expr:   `histogram_quantile(0.99, sum(rate(istio_request_duration_seconds_bucket{reporter="destination"}[1m])) by (le, destination_workload_namespace, destination_workload))`,
+			legend: "{{destination_workload_namespace}}/{{destination_workload}}",
+			unit:   "s",
+		},
+		{
+			title:  "Sidecar CPU Usage",
+			expr:   `sum(rate(container_cpu_usage_seconds_total{container_name="istio-proxy"}[1m])) by (namespace, pod_name)`,
+			legend: "{{namespace}}/{{pod_name}}",
+			unit:   "short",
+		},
+		{
+			title:  "Sidecar Memory Usage",
+			expr:   `sum(container_memory_working_set_bytes{container_name="istio-proxy"}) by (namespace, pod_name)`,
+			legend: "{{namespace}}/{{pod_name}}",
+			unit:   "bytes",
+		},
+	},
+	"Linkerd": {
+		{
+			title:  "Request Rate by Workload",
+			expr:   `sum(rate(request_total{direction="inbound"}[1m])) by (namespace, deployment)`,
+			legend: "{{namespace}}/{{deployment}}",
+			unit:   "reqps",
+		},
+		{
+			title:  "Success Rate by Workload",
+			expr:   `sum(rate(response_total{direction="inbound", classification="success"}[1m])) by (namespace, deployment) / sum(rate(response_total{direction="inbound"}[1m])) by (namespace, deployment)`,
+			legend: "{{namespace}}/{{deployment}}",
+			unit:   "percentunit",
+		},
+		{
+			title:  "P99 Latency by Workload",
+			expr:   `histogram_quantile(0.99, sum(rate(response_latency_ms_bucket{direction="inbound"}[1m])) by (le, namespace, deployment))`,
+			legend: "{{namespace}}/{{deployment}}",
+			unit:   "ms",
+		},
+		{
+			title:  "Sidecar CPU Usage",
+			expr:   `sum(rate(container_cpu_usage_seconds_total{container_name="linkerd-proxy"}[1m])) by (namespace, pod_name)`,
+			legend: "{{namespace}}/{{pod_name}}",
+			unit:   "short",
+		},
+		{
+			title:  "Sidecar Memory Usage",
+			expr:   `sum(container_memory_working_set_bytes{container_name="linkerd-proxy"}) by (namespace, pod_name)`,
+			legend: "{{namespace}}/{{pod_name}}",
+			unit:   "bytes",
+		},
+	},
+	"Consul": {
+		{
+			title:  "Request Rate by Workload",
+			expr:   `sum(rate(envoy_http_downstream_rq_total{envoy_http_conn_manager_prefix="public_listener_http"}[1m])) by (kubernetes_namespace, kubernetes_pod_name)`,
+			legend: "{{kubernetes_namespace}}/{{kubernetes_pod_name}}",
+			unit:   "reqps",
+		},
+		{
+			title:  "Success Rate by Workload",
+			expr:   `1 - sum(rate(envoy_http_downstream_rq_xx{envoy_http_conn_manager_prefix="public_listener_http", envoy_response_code_class="5"}[1m])) by (kubernetes_namespace, kubernetes_pod_name) / sum(rate(envoy_http_downstream_rq_total{envoy_http_conn_manager_prefix="public_listener_http"}[1m])) by (kubernetes_namespace, kubernetes_pod_name)`,
+			legend: "{{kubernetes_namespace}}/{{kubernetes_pod_name}}",
+			unit:   "percentunit",
+		},
+		{
+			title:  "P99 Latency by Workload",
+			expr:   `histogram_quantile(0.99, sum(rate(envoy_http_downstream_rq_time_bucket{envoy_http_conn_manager_prefix="public_listener_http"}[1m])) by (le, kubernetes_namespace, kubernetes_pod_name))`,
+			legend: "{{kubernetes_namespace}}/{{kubernetes_pod_name}}",
+			unit:   "ms",
+		},
+		{
+			title:  "Sidecar CPU Usage",
+			expr:   `sum(rate(container_cpu_usage_seconds_total{container_name="consul-connect-envoy-sidecar"}[1m])) by (namespace, pod_name)`,
+			legend: "{{namespace}}/{{pod_name}}",
+			unit:   "short",
+		},
+		{
+			title:  "Sidecar Memory Usage",
+			expr:   `sum(container_memory_working_set_bytes{container_name="consul-connect-envoy-sidecar"}) by (namespace, pod_name)`,
+			legend: "{{namespace}}/{{pod_name}}",
+			unit:   "bytes",
+		},
+	},
+}
+
+// meshStaticBoard returns the Grafana board json of the static board of the mesh, nil when there is none
+func meshStaticBoard(mesh string) ([]byte, error) {
+	panels, ok := meshStaticBoards[mesh]
+	if !ok {
+		return nil, nil
+	}
+	boardPanels := []map[string]interface{}{}
+	for i, panel := range panels {
+		boardPanels = append(boardPanels, map[string]interface{}{
+			"id":         i + 1,
+			"type":       "graph",
+			"title":      panel.title,
+			"datasource": "prometheus",
+			"gridPos": map[string]interface{}{
+				"h": 7,
+				"w": 12,
+				"x": (i % 2) * 12,
+				"y": (i / 2) * 7,
+			},
+			"lines":         true,
+			"linewidth":     1,
+			"fill":          1,
+			"nullPointMode": "null as zero",
+			"legend": map[string]interface{}{
+				"show": true,
+			},
+			"targets": []map[string]interface{}{
+				{
+					"expr":           panel.expr,
+					"format":         "time_series",
+					"intervalFactor": 2,
+					"legendFormat":   panel.legend,
+					"refId":          "A",
+				},
+			},
+			"xaxis": map[string]interface{}{
+				"mode": "time",
+				"show": true,
+			},
+			"yaxes": []map[string]interface{}{
+				{
+					"format":  panel.unit,
+					"logBase": 1,
+					"min":     0,
+					"show":    true,
+				},
+				{
+					"format":  "short",
+					"logBase": 1,
+					"show":    false,
+				},
+			},
+		})
+	}
+	return json.Marshal(map[string]interface{}{
+		"title":    mesh + " / Service Mesh",
+		"editable": false,
+		"panels":   boardPanels,
+		"templating": map[string]interface{}{
+			"list": []interface{}{},
+		},
+		"time": map[string]interface{}{
+			"from": "now-1h",
+			"to":   "now",
+		},
+	})
+}
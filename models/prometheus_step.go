@@ -0,0 +1,118 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/grafana-tools/sdk"
+	promModel "github.com/prometheus/common/model"
+)
+
+const (
+	// defaultMaxDataPoints is the number of points of each series when the panel does not set it
+	defaultMaxDataPoints = 300
+	// stepOversampling is how many more points than the max data points are fetched, the series being downsampled
+	// afterwards so that the spikes between the points kept are not lost
+	stepOversampling = 4
+	// maxQueryPoints is the limit of points per series of a range query of prometheus
+	maxQueryPoints = 11000
+	// scrapeIntervalTTL is how long the scrape interval of a prometheus is cached
+	scrapeIntervalTTL = 10 * time.Minute
+)
+
+// StepOptions - represents the settings of a panel driving the step of its range queries
+type StepOptions struct {
+	// MaxDataPoints is the number of points of each series, defaultMaxDataPoints when 0
+	MaxDataPoints int `json:"max_data_points,omitempty"`
+	// MinInterval is the smallest step
+	MinInterval time.Duration `json:"min_interval,omitempty"`
+}
+
+// GetMaxDataPoints returns the number of points of each series
+func (o *StepOptions) GetMaxDataPoints() int {
+	if o == nil || o.MaxDataPoints <= 0 {
+		return defaultMaxDataPoints
+	}
+	return o.MaxDataPoints
+}
+
+func (o *StepOptions) getMinInterval() time.Duration {
+	if o == nil {
+		return 0
+	}
+	return o.MinInterval
+}
+
+// PanelStepOptions returns the step options set on the panel and on its target, the interval of the target taking
+// precedence over the one of the panel
+func PanelStepOptions(panel *sdk.Panel, target *sdk.Target) *StepOptions {
+	opts := &StepOptions{}
+	if panel.SinglestatPanel != nil && panel.SinglestatPanel.MaxDataPoints != nil && panel.SinglestatPanel.MaxDataPoints.Valid {
+		opts.MaxDataPoints = int(panel.SinglestatPanel.MaxDataPoints.Value)
+	}
+	var panelInterval string
+	if panel.CustomPanel != nil {
+		if maxDataPoints, ok := (*panel.CustomPanel)["maxDataPoints"].(float64); ok {
+			opts.MaxDataPoints = int(maxDataPoints)
+		}
+		panelInterval, _ = (*panel.CustomPanel)["interval"].(string)
+	}
+	if target != nil {
+		opts.MinInterval = ParseInterval(target.Interval)
+	}
+	if opts.MinInterval == 0 {
+		opts.MinInterval = ParseInterval(panelInterval)
+	}
+	return opts
+}
+
+// BoardStepOptions returns the step options of each of the queries of the board
+func BoardStepOptions(board *GrafanaBoard) map[string]*StepOptions {
+	options := map[string]*StepOptions{}
+	for _, panel := range board.Panels {
+		targets := PanelTargets(panel)
+		for i := range targets {
+			target := &targets[i]
+			if _, ok := options[target.Expr]; target.Expr != "" && !ok {
+				options[target.Expr] = PanelStepOptions(panel, target)
+			}
+		}
+	}
+	return options
+}
+
+// ParseInterval parses a Grafana interval like 15s or >1m, 0 being returned when it is not set or is a template
+// variable
+func ParseInterval(interval string) time.Duration {
+	interval = strings.TrimPrefix(strings.TrimSpace(interval), ">")
+	if interval == "" || strings.HasPrefix(interval, "$") {
+		return 0
+	}
+	d, err := promModel.ParseDuration(interval)
+	if err != nil {
+		return 0
+	}
+	return time.Duration(d)
+}
+
+// computeStep returns the step fetching enough points between start and end to downsample the series to the max
+// data points of opts, which is not smaller than the scrape interval and the min interval of opts
+func computeStep(start, end time.Time, scrapeInterval time.Duration, opts *StepOptions) time.Duration {
+	window := end.Sub(start)
+	step := window / time.Duration(opts.GetMaxDataPoints()*stepOversampling)
+	if minStep := window / maxQueryPoints; step < minStep {
+		step = minStep
+	}
+	if step < scrapeInterval {
+		step = scrapeInterval
+	}
+	if minInterval := opts.getMinInterval(); step < minInterval {
+		step = minInterval
+	}
+	// prometheus handles steps in seconds best
+	step = step.Truncate(time.Second)
+	if step < time.Second {
+		step = time.Second
+	}
+	return step
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+)
+
+// QueryCacheInterface defines the methods of the cache of the query results of the proxy endpoints
+type QueryCacheInterface interface {
+	// Get returns the cached result for key, or the one of fetch which is cached for ttl when it succeeds.
+	// Concurrent calls for the same key share a single call of fetch.
+	Get(key string, ttl time.Duration, fetch func() ([]byte, error)) ([]byte, error)
+	Stats() *QueryCacheStats
+}
+
+// QueryCacheStats - represents metrics on the query cache since Meshery started
+type QueryCacheStats struct {
+	Entries int `json:"entries"`
+	// Bytes is the size of the keys and data of the entries
+	Bytes  int64 `json:"bytes"`
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+	// Coalesced is the number of queries which waited for the same query in flight instead of reaching the backend
+	Coalesced int64 `json:"coalesced"`
+	Evictions int64 `json:"evictions"`
+	Errors    int64 `json:"errors"`
+}
@@ -9,4 +9,15 @@ type QueryTrackerInterface interface {
 	AddOrFlagQuery(ctx context.Context, uuid, query string, flag bool)
 	RemoveUUID(ctx context.Context, uuid string)
 	GetQueriesForUUID(ctx context.Context, uuid string) map[string]bool
+	Stats(ctx context.Context) *QueryTrackerStats
+}
+
+// QueryTrackerStats - represents metrics on the queries tracked for the load tests
+type QueryTrackerStats struct {
+	TrackedUUIDs   int `json:"tracked_uuids"`
+	TrackedQueries int `json:"tracked_queries"`
+	// ExpiredUUIDs is the number of UUIDs removed since Meshery started because their metrics were never collected
+	ExpiredUUIDs int64 `json:"expired_uuids"`
+	// DroppedQueries is the number of queries not tracked since Meshery started because their UUID had too many
+	DroppedQueries int64 `json:"dropped_queries"`
 }
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"github.com/gofrs/uuid"
+)
+
+// ResultsPage - represents a page of results, in the same shape as the one returned by SaaS
+type ResultsPage struct {
+	Page       uint64           `json:"page"`
+	PageSize   uint64           `json:"page_size"`
+	TotalCount int              `json:"total_count"`
+	Results    []*MesheryResult `json:"results"`
+}
+
+// ResultsPersister defines methods for a results persister
+type ResultsPersister interface {
+	GetResults(userID string, page, pageSize uint64, search, order string) (*ResultsPage, error)
+	GetResult(key uuid.UUID) (*MesheryResult, error)
+	WriteResult(key uuid.UUID, result *MesheryResult) error
+	DeleteResult(key uuid.UUID) error
+
+	Close()
+}
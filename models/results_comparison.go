@@ -0,0 +1,72 @@
+package models
+
+import (
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// ResultSummary - represents the metrics of a result normalized for comparisons, latencies are in milliseconds
+// and rates in percent
+type ResultSummary struct {
+	ID        uuid.UUID `json:"meshery_id,omitempty"`
+	Name      string    `json:"name,omitempty"`
+	Mesh      string    `json:"mesh,omitempty"`
+	RunType   string    `json:"run_type,omitempty"`
+	StartTime time.Time `json:"start_time,omitempty"`
+
+	Latencies     map[string]float64 `json:"latencies,omitempty"`
+	LatencyStdDev float64            `json:"latency_std_dev"`
+	Requests      int64              `json:"requests"`
+	RequestedQPS  string             `json:"requested_qps,omitempty"`
+	QPS           float64            `json:"qps"`
+	// ErrorRate is nil when the results hold no request counts
+	ErrorRate *float64 `json:"error_rate"`
+	// ServerMetrics holds the average value of each of the Prometheus queries collected during the load test
+	ServerMetrics map[string]float64 `json:"server_metrics,omitempty"`
+}
+
+// MetricDelta - represents the difference of a metric between a result and the baseline
+type MetricDelta struct {
+	Baseline float64 `json:"baseline"`
+	Value    float64 `json:"value"`
+	Delta    float64 `json:"delta"`
+	// DeltaPercent is relative to the baseline, it is not set when the baseline is 0
+	DeltaPercent *float64 `json:"delta_percent,omitempty"`
+}
+
+// Significance - represents the outcome of a statistical test on the difference between two results
+type Significance struct {
+	Test        string  `json:"test,omitempty"`
+	Statistic   float64 `json:"statistic"`
+	PValue      float64 `json:"p_value"`
+	Significant bool    `json:"significant"`
+}
+
+// ResultDelta - represents the differences between a result and the baseline
+type ResultDelta struct {
+	ID            uuid.UUID               `json:"meshery_id,omitempty"`
+	Latencies     map[string]*MetricDelta `json:"latencies,omitempty"`
+	QPS           *MetricDelta            `json:"qps,omitempty"`
+	ErrorRate     *MetricDelta            `json:"error_rate,omitempty"`
+	ServerMetrics map[string]*MetricDelta `json:"server_metrics,omitempty"`
+
+	// LatencySignificance tells whether the difference of the average latencies is significant
+	LatencySignificance *Significance `json:"latency_significance,omitempty"`
+	// ErrorRateSignificance tells whether the difference of the error rates is significant
+	ErrorRateSignificance *Significance `json:"error_rate_significance,omitempty"`
+}
+
+// ResultsComparison - represents the comparison of several results with the first one, the baseline
+type ResultsComparison struct {
+	Results []*ResultSummary `json:"results"`
+	Deltas  []*ResultDelta   `json:"deltas"`
+}
+
+// MeshOverhead - represents the overhead of a service mesh, measured by running the same load test on an application
+// without and with sidecars
+type MeshOverhead struct {
+	Baseline *ResultSummary `json:"baseline"`
+	Mesh     *ResultSummary `json:"mesh"`
+	Overhead *ResultDelta   `json:"overhead"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import "fmt"
+
+// ResultExportFormat - represents a format a result can be exported to
+type ResultExportFormat string
+
+const (
+	// CSVExport exports the metrics of a result as comma separated values
+	CSVExport ResultExportFormat = "csv"
+	// JUnitExport exports a result as a JUnit XML test suite, the SLO assertions being its test cases
+	JUnitExport ResultExportFormat = "junit"
+	// HTMLExport exports a result as a standalone HTML report with embedded charts
+	HTMLExport ResultExportFormat = "html"
+)
+
+// ParseResultExportFormat returns the export format with the given name
+func ParseResultExportFormat(format string) (ResultExportFormat, error) {
+	switch f := ResultExportFormat(format); f {
+	case CSVExport, JUnitExport, HTMLExport:
+		return f, nil
+	}
+	return "", fmt.Errorf("unsupported export format: %s, expecting csv, junit or html", format)
+}
+
+// ContentType returns the media type of the exported result
+func (f ResultExportFormat) ContentType() string {
+	switch f {
+	case CSVExport:
+		return "text/csv; charset=utf-8"
+	case JUnitExport:
+		return "application/xml; charset=utf-8"
+	}
+	return "text/html; charset=utf-8"
+}
+
+// Extension returns the file extension of the exported result
+func (f ResultExportFormat) Extension() string {
+	if f == JUnitExport {
+		return "xml"
+	}
+	return string(f)
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"encoding/gob"
+	"net/http"
 
 	"github.com/grafana-tools/sdk"
 )
@@ -63,6 +64,13 @@ type SelectedGrafanaConfig struct {
 type Prometheus struct {
 	PrometheusURL                   string                   `json:"prometheusURL,omitempty"`
 	SelectedPrometheusBoardsConfigs []*SelectedGrafanaConfig `json:"selectedPrometheusBoardsConfigs,omitempty"`
+	// Auth holds the credentials and TLS settings, nil when Prometheus is reached without
+	Auth *PrometheusAuth `json:"auth,omitempty"`
+	// KubernetesService is set when Prometheus is a service reached through the kubernetes API, the requests being
+	// authenticated with the kubeconfig of the session when they are sent rather than with Auth
+	KubernetesService *KubernetesService `json:"kubernetesService,omitempty"`
+	// Transport sends the requests to the kubernetes API, it is resolved from the kubeconfig and never persisted
+	Transport http.RoundTripper `json:"-"`
 }
 
 // Session represents the data stored in session / local DB
@@ -0,0 +1,97 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SLO assertion metrics, latencies are in milliseconds and rates in percent
+const (
+	// SLOAvgLatency - the average latency
+	SLOAvgLatency = "avg_latency"
+	// SLOMaxLatency - the maximum latency
+	SLOMaxLatency = "max_latency"
+	// SLOErrorRate - the percentage of failed requests
+	SLOErrorRate = "error_rate"
+	// SLOQPS - the achieved queries per second
+	SLOQPS = "qps"
+	// SLOQPSRatio - the achieved queries per second as a percentage of the requested ones
+	SLOQPSRatio = "qps_ratio"
+	// SLOPrometheus - the value of a Prometheus query
+	SLOPrometheus = "prometheus"
+)
+
+// SLOVerdict - represents the outcome of the SLO assertions of a load test
+type SLOVerdict string
+
+const (
+	// SLOPass - all the assertions passed
+	SLOPass SLOVerdict = "pass"
+	// SLOFail - at least one of the assertions failed
+	SLOFail SLOVerdict = "fail"
+	// SLOError - none of the assertions failed, but at least one of them could not be evaluated
+	SLOError SLOVerdict = "error"
+)
+
+// SLOAssertion - represents a target the load test results have to meet, like {"metric": "p99", "op": "<", "value": 200}
+type SLOAssertion struct {
+	Name string `json:"name,omitempty"`
+	// Metric is one of the SLO metrics or a latency percentile like p50, p99 or p99.9
+	Metric   string  `json:"metric,omitempty"`
+	Operator string  `json:"op,omitempty"`
+	Value    float64 `json:"value"`
+	// Query is the PromQL query of the prometheus metric, evaluated at the end of the load test.
+	// $__range is replaced by the duration of the load test.
+	Query string `json:"query,omitempty"`
+}
+
+// Validate - checks the assertion is well formed
+func (a *SLOAssertion) Validate() error {
+	switch a.Operator {
+	case "<", "<=", ">", ">=":
+	default:
+		return fmt.Errorf("invalid operator '%s', expecting one of <, <=, >, >=", a.Operator)
+	}
+	switch a.Metric {
+	case SLOAvgLatency, SLOMaxLatency, SLOErrorRate, SLOQPS, SLOQPSRatio:
+	case SLOPrometheus:
+		if strings.TrimSpace(a.Query) == "" {
+			return fmt.Errorf("a query is needed for the prometheus metric")
+		}
+	default:
+		if _, ok := a.Percentile(); !ok {
+			return fmt.Errorf("unknown metric '%s'", a.Metric)
+		}
+	}
+	return nil
+}
+
+// Percentile - returns the latency percentile of the assertion, if its metric is one like p99 or p99.9
+func (a *SLOAssertion) Percentile() (float64, bool) {
+	if !strings.HasPrefix(a.Metric, "p") {
+		return 0, false
+	}
+	p, err := strconv.ParseFloat(strings.Replace(a.Metric[1:], "_", ".", 1), 64)
+	if err != nil || p <= 0 || p > 100 {
+		return 0, false
+	}
+	return p, true
+}
+
+// String - returns a human readable version of the assertion
+func (a *SLOAssertion) String() string {
+	if a.Name != "" {
+		return a.Name
+	}
+	return fmt.Sprintf("%s %s %g", a.Metric, a.Operator, a.Value)
+}
+
+// SLOAssertionResult - represents the evaluation of an SLO assertion
+type SLOAssertionResult struct {
+	Assertion *SLOAssertion `json:"assertion,omitempty"`
+	Actual    float64       `json:"actual"`
+	Passed    bool          `json:"passed"`
+	// Error is set when the assertion could not be evaluated
+	Error string `json:"error,omitempty"`
+}
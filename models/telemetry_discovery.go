@@ -0,0 +1,31 @@
+package models
+
+// TelemetryType - represents the type of a telemetry service
+type TelemetryType string
+
+const (
+	// TelemetryPrometheus - the service is a Prometheus
+	TelemetryPrometheus TelemetryType = "prometheus"
+	// TelemetryGrafana - the service is a Grafana
+	TelemetryGrafana TelemetryType = "grafana"
+)
+
+// TelemetryCandidate - represents a Prometheus or Grafana service found in the cluster
+type TelemetryCandidate struct {
+	Type      TelemetryType `json:"type"`
+	Name      string        `json:"name"`
+	Namespace string        `json:"namespace"`
+	Port      int32         `json:"port"`
+	// URL is the in-cluster URL of the service, which can be used when Meshery runs in the cluster
+	URL string `json:"url"`
+	// ProxyURL reaches the service through the service proxy of the kubernetes API server
+	ProxyURL string `json:"proxy_url,omitempty"`
+}
+
+// KubernetesService - represents a service of the cluster of the session reached through the service proxy of the
+// kubernetes API server
+type KubernetesService struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Port      int32  `json:"port"`
+}
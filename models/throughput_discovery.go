@@ -0,0 +1,38 @@
+package models
+
+// ThroughputDiscovery - describes the search of the highest rate the target sustains while meeting the SLO assertions
+// of the load test. Each probe is a load test at a constant rate lasting the duration of the load test.
+// The rate is doubled from MinQPS until a probe fails, then the search narrows down on the knee by bisection.
+// A probe fails when it does not achieve 95% of its rate, an assertion fails, an alert fires or it is aborted.
+type ThroughputDiscovery struct {
+	MinQPS float64 `json:"min_qps"`
+	// MaxQPS, when greater than 0, is the highest rate probed
+	MaxQPS float64 `json:"max_qps,omitempty"`
+	// Precision is the width, in qps, of the interval around the knee at which the search stops.
+	// It defaults to 5% of the highest passing rate.
+	Precision float64 `json:"precision,omitempty"`
+	// MaxProbes bounds the number of load tests run by the search
+	MaxProbes int `json:"max_probes,omitempty"`
+}
+
+// ThroughputProbe - represents the outcome of one of the load tests of a throughput discovery, latencies are in
+// milliseconds and rates in percent
+type ThroughputProbe struct {
+	QPS        float64               `json:"qps"`
+	ActualQPS  float64               `json:"actual_qps"`
+	ErrorRate  *float64              `json:"error_rate"`
+	Latencies  map[string]float64    `json:"latencies,omitempty"`
+	Assertions []*SLOAssertionResult `json:"assertions,omitempty"`
+	Verdict    SLOVerdict            `json:"verdict,omitempty"`
+	// Alerts are the prometheus alerts which fired during the probe
+	Alerts      []*PrometheusAlert `json:"alerts,omitempty"`
+	AbortReason string             `json:"abort_reason,omitempty"`
+	Passed      bool               `json:"passed"`
+}
+
+// ThroughputDiscoveryResult - represents all the probes of a throughput discovery along with the knee found
+type ThroughputDiscoveryResult struct {
+	// KneeQPS is the highest rate which passed, 0 when even MinQPS failed
+	KneeQPS float64            `json:"knee_qps"`
+	Probes  []*ThroughputProbe `json:"probes"`
+}
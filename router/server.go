@@ -27,7 +27,14 @@ func NewRouter(ctx context.Context, h models.HandlerInterface, port int) *Router
 	mux.Handle("/api/mesh/scan", h.AuthMiddleware(h.SessionInjectorMiddleware(h.InstalledMeshesHandler)))
 
 	mux.Handle("/api/load-test", h.AuthMiddleware(h.SessionInjectorMiddleware(h.LoadTestHandler)))
+	mux.Handle("/api/load-test/overhead", h.AuthMiddleware(h.SessionInjectorMiddleware(h.MeshOverheadHandler)))
+	mux.Handle("/api/load-test/jobs", h.AuthMiddleware(h.SessionInjectorMiddleware(h.LoadTestJobsHandler)))
+	mux.Handle("/api/load-test/schedules", h.AuthMiddleware(h.SessionInjectorMiddleware(h.LoadTestSchedulesHandler)))
+	mux.Handle("/api/load-test/generators", h.AuthMiddleware(h.SessionInjectorMiddleware(h.LoadGeneratorsHandler)))
 	mux.Handle("/api/results", h.AuthMiddleware(h.SessionInjectorMiddleware(h.FetchResultsHandler)))
+	mux.Handle("/api/results/compare", h.AuthMiddleware(h.SessionInjectorMiddleware(h.CompareResultsHandler)))
+	mux.Handle("/api/metrics/dead-letters", h.AuthMiddleware(h.SessionInjectorMiddleware(h.MetricsDeadLettersHandler)))
+	mux.Handle("/api/results/export", h.AuthMiddleware(h.SessionInjectorMiddleware(h.ExportResultHandler)))
 
 	mux.Handle("/api/mesh/manage", h.AuthMiddleware(h.SessionInjectorMiddleware(h.MeshAdapterConfigHandler)))
 	mux.Handle("/api/mesh/ops", h.AuthMiddleware(h.SessionInjectorMiddleware(h.MeshOpsHandler)))
@@ -46,6 +53,12 @@ func NewRouter(ctx context.Context, h models.HandlerInterface, port int) *Router
 	mux.Handle("/api/prometheus/query_range", h.AuthMiddleware(h.SessionInjectorMiddleware(h.PrometheusQueryRangeHandler)))
 	mux.Handle("/api/prometheus/static_board", h.AuthMiddleware(h.SessionInjectorMiddleware(h.PrometheusStaticBoardHandler)))
 	mux.Handle("/api/prometheus/boards", h.AuthMiddleware(h.SessionInjectorMiddleware(h.SaveSelectedPrometheusBoardsHandler)))
+	mux.Handle("/api/prometheus/rules", h.AuthMiddleware(h.SessionInjectorMiddleware(h.PrometheusRulesHandler)))
+	mux.Handle("/api/prometheus/alerts", h.AuthMiddleware(h.SessionInjectorMiddleware(h.PrometheusAlertsHandler)))
+	mux.Handle("/api/prometheus/query_tracker", h.AuthMiddleware(h.SessionInjectorMiddleware(h.QueryTrackerStatsHandler)))
+
+	mux.Handle("/api/telemetry/discover", h.AuthMiddleware(h.SessionInjectorMiddleware(h.TelemetryDiscoveryHandler)))
+	mux.Handle("/api/telemetry/query_cache", h.AuthMiddleware(h.SessionInjectorMiddleware(h.QueryCacheStatsHandler)))
 
 	mux.HandleFunc("/logout", h.LogoutHandler)
 	mux.HandleFunc("/login", h.LoginHandler)
@@ -0,0 +1,43 @@
+package workers
+
+import (
+	"context"
+
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+)
+
+// Client represents a gRPC load generator worker client
+type Client struct {
+	WClient LoadTestWorkerClient
+	conn    *grpc.ClientConn
+}
+
+// NewClient creates a Client for the worker listening at the given address, authenticating as configured by security
+func NewClient(ctx context.Context, address string, security *models.LoadTestWorkerSecurity) (*Client, error) {
+	opts, err := dialOptions(security)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	conn, err := grpc.DialContext(ctx, address, opts...)
+	if err != nil {
+		err = errors.Wrapf(err, "unable to connect to the load generator worker at %s", address)
+		logrus.Error(err)
+		return nil, err
+	}
+	return &Client{
+		WClient: NewLoadTestWorkerClient(conn),
+		conn:    conn,
+	}, nil
+}
+
+// Close closes the Client
+func (c *Client) Close() error {
+	if c.conn != nil {
+		return c.conn.Close()
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package workers
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// tokenMetadataKey is the gRPC metadata carrying the shared token
+const tokenMetadataKey = "authorization"
+
+// tlsConfig returns the TLS config presenting the certificate of the security and verifying the other side with
+// its CA
+func tlsConfig(security *models.LoadTestWorkerSecurity, server bool) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(security.CertFile, security.KeyFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to load the worker certificate")
+	}
+	ca, err := ioutil.ReadFile(security.CAFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read the worker CA certificate")
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, errors.New("unable to parse the worker CA certificate, expecting PEM")
+	}
+	if server {
+		return &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			ClientAuth:   tls.RequireAndVerifyClientCert,
+			ClientCAs:    pool,
+		}, nil
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+	}, nil
+}
+
+// serverOptions returns the options of the gRPC server authenticating the jobs as configured by security.
+// Without any security the server can only listen on a loopback address. The token is only accepted over mutual
+// TLS, as it would be sent in cleartext otherwise.
+func serverOptions(address string, security *models.LoadTestWorkerSecurity) ([]grpc.ServerOption, error) {
+	if security != nil && security.Token != "" && !security.MutualTLS() {
+		return nil, errors.New("the worker token needs the worker certificate, key and CA for TLS")
+	}
+	if !security.MutualTLS() {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid worker address %s", address)
+		}
+		if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+			return nil, fmt.Errorf("refusing to accept load test jobs on %s without mutual TLS", address)
+		}
+		return nil, nil
+	}
+	cfg, err := tlsConfig(security, true)
+	if err != nil {
+		return nil, err
+	}
+	opts := []grpc.ServerOption{grpc.Creds(credentials.NewTLS(cfg))}
+	if security.Token != "" {
+		opts = append(opts, grpc.UnaryInterceptor(tokenInterceptor(security.Token)))
+	}
+	return opts, nil
+}
+
+// tokenInterceptor rejects the calls which do not carry the token
+func tokenInterceptor(token string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		values := md.Get(tokenMetadataKey)
+		if len(values) != 1 || subtle.ConstantTimeCompare([]byte(values[0]), []byte("Bearer "+token)) != 1 {
+			return nil, status.Error(codes.Unauthenticated, "invalid worker token")
+		}
+		return handler(ctx, req)
+	}
+}
+
+// dialOptions returns the options of the gRPC client authenticating to the worker as configured by security
+func dialOptions(security *models.LoadTestWorkerSecurity) ([]grpc.DialOption, error) {
+	if !security.MutualTLS() {
+		if security != nil && security.Token != "" {
+			return nil, errors.New("the worker token needs the worker certificate, key and CA for TLS")
+		}
+		return []grpc.DialOption{grpc.WithInsecure()}, nil
+	}
+	cfg, err := tlsConfig(security, false)
+	if err != nil {
+		return nil, err
+	}
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(credentials.NewTLS(cfg))}
+	if security.Token != "" {
+		opts = append(opts, grpc.WithPerRPCCredentials(tokenCredentials(security.Token)))
+	}
+	return opts, nil
+}
+
+// tokenCredentials sends the shared token with each call, only over TLS
+type tokenCredentials string
+
+func (c tokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{
+		tokenMetadataKey: "Bearer " + string(c),
+	}, nil
+}
+
+func (c tokenCredentials) RequireTransportSecurity() bool {
+	return true
+}
@@ -0,0 +1,194 @@
+package workers
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/layer5io/meshery/models"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// writeTestCertificates writes a self-signed certificate, its key and itself as the CA in dir and returns the
+// security using them
+func writeTestCertificates(t *testing.T, dir string) *models.LoadTestWorkerSecurity {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		DNSNames:              []string{"localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	security := &models.LoadTestWorkerSecurity{
+		CertFile: filepath.Join(dir, "worker.crt"),
+		KeyFile:  filepath.Join(dir, "worker.key"),
+		CAFile:   filepath.Join(dir, "ca.crt"),
+	}
+	for file, block := range map[string]*pem.Block{
+		security.CertFile: {Type: "CERTIFICATE", Bytes: der},
+		security.KeyFile:  {Type: "EC PRIVATE KEY", Bytes: keyDer},
+		security.CAFile:   {Type: "CERTIFICATE", Bytes: der},
+	} {
+		if err = ioutil.WriteFile(file, pem.EncodeToMemory(block), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return security
+}
+
+func TestServerOptions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "worker-certs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	mutualTLS := writeTestCertificates(t, dir)
+	mutualTLSAndToken := *mutualTLS
+	mutualTLSAndToken.Token = "secret"
+
+	tests := []struct {
+		name     string
+		address  string
+		security *models.LoadTestWorkerSecurity
+		wantErr  bool
+		wantOpts int
+	}{
+		{name: "loopback without security", address: "127.0.0.1:9091"},
+		{name: "localhost without security", address: "localhost:9091"},
+		{name: "ipv6 loopback without security", address: "[::1]:9091"},
+		{name: "all interfaces without security", address: ":9091", wantErr: true},
+		{name: "remote address without security", address: "10.0.0.1:9091", security: &models.LoadTestWorkerSecurity{}, wantErr: true},
+		{name: "all interfaces with a token only", address: ":9091", security: &models.LoadTestWorkerSecurity{Token: "secret"}, wantErr: true},
+		{name: "loopback with a token only", address: "127.0.0.1:9091", security: &models.LoadTestWorkerSecurity{Token: "secret"}, wantErr: true},
+		{
+			name:     "token without the CA",
+			address:  ":9091",
+			security: &models.LoadTestWorkerSecurity{Token: "secret", CertFile: mutualTLS.CertFile, KeyFile: mutualTLS.KeyFile},
+			wantErr:  true,
+		},
+		{name: "all interfaces with mutual TLS", address: ":9091", security: mutualTLS, wantOpts: 1},
+		{name: "all interfaces with mutual TLS and a token", address: ":9091", security: &mutualTLSAndToken, wantOpts: 2},
+		{
+			name:     "missing certificates",
+			address:  ":9091",
+			security: &models.LoadTestWorkerSecurity{CertFile: "missing.crt", KeyFile: "missing.key", CAFile: "ca.crt"},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := serverOptions(tt.address, tt.security)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("serverOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(opts) != tt.wantOpts {
+				t.Errorf("serverOptions() returned %d options, want %d", len(opts), tt.wantOpts)
+			}
+		})
+	}
+}
+
+func TestDialOptions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "worker-certs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	mutualTLSAndToken := writeTestCertificates(t, dir)
+	mutualTLSAndToken.Token = "secret"
+
+	tests := []struct {
+		name     string
+		security *models.LoadTestWorkerSecurity
+		wantErr  bool
+		wantOpts int
+	}{
+		{name: "without security", wantOpts: 1},
+		{name: "token only", security: &models.LoadTestWorkerSecurity{Token: "secret"}, wantErr: true},
+		{name: "mutual TLS and a token", security: mutualTLSAndToken, wantOpts: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := dialOptions(tt.security)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("dialOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(opts) != tt.wantOpts {
+				t.Errorf("dialOptions() returned %d options, want %d", len(opts), tt.wantOpts)
+			}
+		})
+	}
+}
+
+func TestTokenInterceptor(t *testing.T) {
+	interceptor := tokenInterceptor("secret")
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	}
+	tests := []struct {
+		name     string
+		md       metadata.MD
+		wantCode codes.Code
+	}{
+		{name: "valid token", md: metadata.Pairs(tokenMetadataKey, "Bearer secret"), wantCode: codes.OK},
+		{name: "invalid token", md: metadata.Pairs(tokenMetadataKey, "Bearer guess"), wantCode: codes.Unauthenticated},
+		{name: "token without scheme", md: metadata.Pairs(tokenMetadataKey, "secret"), wantCode: codes.Unauthenticated},
+		{name: "no token", md: metadata.MD{}, wantCode: codes.Unauthenticated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), tt.md)
+			_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{}, handler)
+			if code := status.Code(err); code != tt.wantCode {
+				t.Errorf("code = %v, want %v", code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestTokenCredentials(t *testing.T) {
+	creds := tokenCredentials("secret")
+	md, err := creds.GetRequestMetadata(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md[tokenMetadataKey] != "Bearer secret" {
+		t.Errorf("metadata = %v, want the bearer token", md)
+	}
+	if !creds.RequireTransportSecurity() {
+		t.Error("the token can be sent in cleartext, want it only sent over TLS")
+	}
+}
@@ -0,0 +1,76 @@
+package workers
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/layer5io/meshery/helpers"
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Server runs the load test jobs sent by a Meshery coordinator
+type Server struct{}
+
+// RunLoadTest waits for the start time of the job and then runs it
+func (s *Server) RunLoadTest(ctx context.Context, job *LoadTestJob) (*LoadTestJobResult, error) {
+	opts, err := job.LoadTestOptions()
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	startAt := job.StartTime()
+	logrus.Infof("received load test job for %s, starting at %v", opts.URL, startAt)
+	if wait := time.Until(startAt); wait > 0 {
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, status.FromContextError(ctx.Err()).Err()
+		}
+	}
+
+	lg, err := helpers.GetLoadGenerator(opts.LoadGenerator)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err = lg.Validate(opts); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	resultsMap, result, err := helpers.StagedLoadTest(ctx, opts, lg.Run, nil)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	res, err := newLoadTestJobResult(resultsMap, result)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return res, nil
+}
+
+// ListenAndServe serves the worker API on the given address until ctx is done. The jobs are authenticated as
+// configured by security, which can only be left empty when listening on a loopback address.
+func ListenAndServe(ctx context.Context, address string, security *models.LoadTestWorkerSecurity) error {
+	opts, err := serverOptions(address, security)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	lis, err := net.Listen("tcp", address)
+	if err != nil {
+		err = errors.Wrapf(err, "unable to listen on %s", address)
+		logrus.Error(err)
+		return err
+	}
+	s := grpc.NewServer(opts...)
+	RegisterLoadTestWorkerServer(s, &Server{})
+	go func() {
+		<-ctx.Done()
+		s.Stop()
+	}()
+	logrus.Infof("Load generator worker listening on %s", address)
+	return s.Serve(lis)
+}
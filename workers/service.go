@@ -0,0 +1,68 @@
+package workers
+
+import (
+	"encoding/json"
+	"time"
+
+	"fortio.org/fortio/periodic"
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+)
+
+// NewLoadTestJob returns the job running the share of a load test described by opts from startAt
+func NewLoadTestJob(opts *models.LoadTestOptions, startAt time.Time) (*LoadTestJob, error) {
+	bd, err := json.Marshal(opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to marshal the load test options")
+	}
+	return &LoadTestJob{
+		Options: bd,
+		StartAt: startAt.UnixNano(),
+	}, nil
+}
+
+// LoadTestOptions returns the load test options of the job
+func (j *LoadTestJob) LoadTestOptions() (*models.LoadTestOptions, error) {
+	if len(j.GetOptions()) == 0 {
+		return nil, errors.New("load test options are missing")
+	}
+	opts := &models.LoadTestOptions{}
+	if err := json.Unmarshal(j.GetOptions(), opts); err != nil {
+		return nil, errors.Wrap(err, "unable to parse the load test options")
+	}
+	return opts, nil
+}
+
+// StartTime returns when the job should start generating load
+func (j *LoadTestJob) StartTime() time.Time {
+	return time.Unix(0, j.GetStartAt())
+}
+
+// newLoadTestJobResult returns the result of a job from the results of its load test
+func newLoadTestJobResult(resultsMap map[string]interface{}, result *periodic.RunnerResults) (*LoadTestJobResult, error) {
+	results, err := json.Marshal(resultsMap)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to marshal the load test results")
+	}
+	runnerResults, err := json.Marshal(result)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to marshal the load test runner results")
+	}
+	return &LoadTestJobResult{
+		Results:       results,
+		RunnerResults: runnerResults,
+	}, nil
+}
+
+// LoadTestResults returns the results map and the runner results of the load test of the job
+func (r *LoadTestJobResult) LoadTestResults() (map[string]interface{}, *periodic.RunnerResults, error) {
+	resultsMap := map[string]interface{}{}
+	if err := json.Unmarshal(r.GetResults(), &resultsMap); err != nil {
+		return nil, nil, errors.Wrap(err, "unable to parse the load test results")
+	}
+	result := &periodic.RunnerResults{}
+	if err := json.Unmarshal(r.GetRunnerResults(), result); err != nil {
+		return nil, nil, errors.Wrap(err, "unable to parse the load test runner results")
+	}
+	return resultsMap, result, nil
+}
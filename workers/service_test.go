@@ -0,0 +1,48 @@
+package workers
+
+import (
+	"testing"
+	"time"
+
+	"fortio.org/fortio/periodic"
+	"github.com/layer5io/meshery/models"
+)
+
+func TestLoadTestJob(t *testing.T) {
+	startAt := time.Date(2020, 1, 1, 0, 0, 0, 42, time.UTC)
+	job, err := NewLoadTestJob(&models.LoadTestOptions{URL: "http://localhost:8080", HTTPQPS: 10}, startAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts, err := job.LoadTestOptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.URL != "http://localhost:8080" || opts.HTTPQPS != 10 {
+		t.Errorf("options = %+v, want the options of the job", opts)
+	}
+	if !job.StartTime().Equal(startAt) {
+		t.Errorf("start time = %v, want %v", job.StartTime(), startAt)
+	}
+
+	if _, err = (&LoadTestJob{}).LoadTestOptions(); err == nil {
+		t.Error("a job without options should be rejected")
+	}
+}
+
+func TestLoadTestJobResult(t *testing.T) {
+	res, err := newLoadTestJobResult(map[string]interface{}{"RequestedQPS": "10"}, &periodic.RunnerResults{NumThreads: 4, ActualQPS: 9.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resultsMap, result, err := res.LoadTestResults()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resultsMap["RequestedQPS"] != "10" {
+		t.Errorf("results = %v, want the results of the job", resultsMap)
+	}
+	if result.NumThreads != 4 || result.ActualQPS != 9.5 {
+		t.Errorf("runner results = %+v, want the runner results of the job", result)
+	}
+}
@@ -0,0 +1,231 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: workers.proto
+
+package workers
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+// LoadTestJob is the share of a load test run by a single worker
+type LoadTestJob struct {
+	// options are the load test options of the share, JSON encoded like the other Meshery load test options
+	Options []byte `protobuf:"bytes,1,opt,name=options,proto3" json:"options,omitempty"`
+	// start_at is when the worker should start generating load, in nanoseconds since the epoch, so all the
+	// workers start in sync
+	StartAt              int64    `protobuf:"varint,2,opt,name=start_at,json=startAt,proto3" json:"start_at,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *LoadTestJob) Reset()         { *m = LoadTestJob{} }
+func (m *LoadTestJob) String() string { return proto.CompactTextString(m) }
+func (*LoadTestJob) ProtoMessage()    {}
+func (*LoadTestJob) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a521883ad0ab7dc9, []int{0}
+}
+
+func (m *LoadTestJob) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_LoadTestJob.Unmarshal(m, b)
+}
+func (m *LoadTestJob) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_LoadTestJob.Marshal(b, m, deterministic)
+}
+func (m *LoadTestJob) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LoadTestJob.Merge(m, src)
+}
+func (m *LoadTestJob) XXX_Size() int {
+	return xxx_messageInfo_LoadTestJob.Size(m)
+}
+func (m *LoadTestJob) XXX_DiscardUnknown() {
+	xxx_messageInfo_LoadTestJob.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LoadTestJob proto.InternalMessageInfo
+
+func (m *LoadTestJob) GetOptions() []byte {
+	if m != nil {
+		return m.Options
+	}
+	return nil
+}
+
+func (m *LoadTestJob) GetStartAt() int64 {
+	if m != nil {
+		return m.StartAt
+	}
+	return 0
+}
+
+// LoadTestJobResult is the results of a LoadTestJob
+type LoadTestJobResult struct {
+	// results are the JSON encoded results map of the load test
+	Results []byte `protobuf:"bytes,1,opt,name=results,proto3" json:"results,omitempty"`
+	// runner_results are the JSON encoded fortio runner results of the load test
+	RunnerResults        []byte   `protobuf:"bytes,2,opt,name=runner_results,json=runnerResults,proto3" json:"runner_results,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *LoadTestJobResult) Reset()         { *m = LoadTestJobResult{} }
+func (m *LoadTestJobResult) String() string { return proto.CompactTextString(m) }
+func (*LoadTestJobResult) ProtoMessage()    {}
+func (*LoadTestJobResult) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a521883ad0ab7dc9, []int{1}
+}
+
+func (m *LoadTestJobResult) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_LoadTestJobResult.Unmarshal(m, b)
+}
+func (m *LoadTestJobResult) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_LoadTestJobResult.Marshal(b, m, deterministic)
+}
+func (m *LoadTestJobResult) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LoadTestJobResult.Merge(m, src)
+}
+func (m *LoadTestJobResult) XXX_Size() int {
+	return xxx_messageInfo_LoadTestJobResult.Size(m)
+}
+func (m *LoadTestJobResult) XXX_DiscardUnknown() {
+	xxx_messageInfo_LoadTestJobResult.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LoadTestJobResult proto.InternalMessageInfo
+
+func (m *LoadTestJobResult) GetResults() []byte {
+	if m != nil {
+		return m.Results
+	}
+	return nil
+}
+
+func (m *LoadTestJobResult) GetRunnerResults() []byte {
+	if m != nil {
+		return m.RunnerResults
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*LoadTestJob)(nil), "workers.LoadTestJob")
+	proto.RegisterType((*LoadTestJobResult)(nil), "workers.LoadTestJobResult")
+}
+
+func init() { proto.RegisterFile("workers.proto", fileDescriptor_a521883ad0ab7dc9) }
+
+var fileDescriptor_a521883ad0ab7dc9 = []byte{
+	// 180 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0xe2, 0x2d, 0xcf, 0x2f, 0xca,
+	0x4e, 0x2d, 0x2a, 0xd6, 0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x17, 0x62, 0x87, 0x72, 0x95, 0x9c, 0xb8,
+	0xb8, 0x7d, 0xf2, 0x13, 0x53, 0x42, 0x52, 0x8b, 0x4b, 0xbc, 0xf2, 0x93, 0x84, 0x24, 0xb8, 0xd8,
+	0xf3, 0x0b, 0x4a, 0x32, 0xf3, 0xf3, 0x8a, 0x25, 0x18, 0x15, 0x18, 0x35, 0x78, 0x82, 0x60, 0x5c,
+	0x21, 0x49, 0x2e, 0x8e, 0xe2, 0x92, 0xc4, 0xa2, 0x92, 0xf8, 0xc4, 0x12, 0x09, 0x26, 0x05, 0x46,
+	0x0d, 0xe6, 0x20, 0x76, 0x30, 0xdf, 0xb1, 0x44, 0x29, 0x84, 0x4b, 0x10, 0xc9, 0x8c, 0xa0, 0xd4,
+	0xe2, 0xd2, 0x9c, 0x12, 0x90, 0x49, 0x45, 0x60, 0x16, 0xdc, 0x24, 0x28, 0x57, 0x48, 0x95, 0x8b,
+	0xaf, 0xa8, 0x34, 0x2f, 0x2f, 0xb5, 0x28, 0x1e, 0xa6, 0x80, 0x09, 0xac, 0x80, 0x17, 0x22, 0x0a,
+	0xd1, 0x5f, 0x6c, 0x14, 0xcc, 0xc5, 0x07, 0x33, 0x35, 0x1c, 0xec, 0x58, 0x21, 0x47, 0x2e, 0xee,
+	0xa0, 0xd2, 0x3c, 0x98, 0xa0, 0x90, 0x88, 0x1e, 0xcc, 0x4f, 0x48, 0xb6, 0x4b, 0x49, 0x61, 0x13,
+	0x85, 0x98, 0xa9, 0xc4, 0x90, 0xc4, 0x06, 0xf6, 0xbe, 0x31, 0x60, 0x00, 0x60, 0x67, 0x5c, 0xb7,
+	0x0f, 0x01, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// LoadTestWorkerClient is the client API for LoadTestWorker service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type LoadTestWorkerClient interface {
+	// RunLoadTest waits for the start time of the job, runs it and returns its results
+	RunLoadTest(ctx context.Context, in *LoadTestJob, opts ...grpc.CallOption) (*LoadTestJobResult, error)
+}
+
+type loadTestWorkerClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewLoadTestWorkerClient(cc *grpc.ClientConn) LoadTestWorkerClient {
+	return &loadTestWorkerClient{cc}
+}
+
+func (c *loadTestWorkerClient) RunLoadTest(ctx context.Context, in *LoadTestJob, opts ...grpc.CallOption) (*LoadTestJobResult, error) {
+	out := new(LoadTestJobResult)
+	err := c.cc.Invoke(ctx, "/workers.LoadTestWorker/RunLoadTest", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// LoadTestWorkerServer is the server API for LoadTestWorker service.
+type LoadTestWorkerServer interface {
+	// RunLoadTest waits for the start time of the job, runs it and returns its results
+	RunLoadTest(context.Context, *LoadTestJob) (*LoadTestJobResult, error)
+}
+
+// UnimplementedLoadTestWorkerServer can be embedded to have forward compatible implementations.
+type UnimplementedLoadTestWorkerServer struct {
+}
+
+func (*UnimplementedLoadTestWorkerServer) RunLoadTest(ctx context.Context, req *LoadTestJob) (*LoadTestJobResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RunLoadTest not implemented")
+}
+
+func RegisterLoadTestWorkerServer(s *grpc.Server, srv LoadTestWorkerServer) {
+	s.RegisterService(&_LoadTestWorker_serviceDesc, srv)
+}
+
+func _LoadTestWorker_RunLoadTest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LoadTestJob)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LoadTestWorkerServer).RunLoadTest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/workers.LoadTestWorker/RunLoadTest",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LoadTestWorkerServer).RunLoadTest(ctx, req.(*LoadTestJob))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _LoadTestWorker_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "workers.LoadTestWorker",
+	HandlerType: (*LoadTestWorkerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "RunLoadTest",
+			Handler:    _LoadTestWorker_RunLoadTest_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "workers.proto",
+}